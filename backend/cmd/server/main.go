@@ -1,9 +1,27 @@
 package main
 
 import (
+	"FlashQuiz/internal/api/middleware"
 	"FlashQuiz/internal/api/routes"
+	"FlashQuiz/internal/cache"
+	"FlashQuiz/internal/config"
+	"FlashQuiz/internal/mailer"
 	"FlashQuiz/internal/models"
+	"FlashQuiz/internal/notify"
+	"FlashQuiz/internal/password"
+	"FlashQuiz/internal/stats"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+	_ "time/tzdata" // Embed timezone data so user timezones resolve on minimal hosts
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -31,56 +49,267 @@ func initDB() (*gorm.DB, error) {
 		return nil, err
 	}
 
+	if err := configurePool(db); err != nil {
+		return nil, err
+	}
+
 	// Auto-migrate all models
 	log.Println("Running database migrations...")
 	err = db.AutoMigrate(
 		&models.User{},
 		&models.Deck{},
+		&models.DeckCollaborator{},
 		&models.FlashCard{},
+		&models.Tag{},
+		&models.CardTag{},
+		&models.CardNote{},
 		&models.CardProgress{},
+		&models.ReviewLog{},
+		&models.StudySession{},
+		&models.DeckDueCount{},
+		&models.StudyGoal{},
 		&models.Quiz{},
 		&models.QuizQuestion{},
+		&models.QuizInvite{},
+		&models.QuizIdempotencyKey{},
+		&models.PasswordResetToken{},
+		&models.UserSettings{},
+		&models.OutboxMessage{},
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := ensureUsernameIndex(db); err != nil {
+		return nil, err
+	}
+
 	log.Println("Database migrations completed successfully")
 
 	return db, nil
 }
 
+// ensureUsernameIndex -> Makes usernames unique regardless of case at the database level.
+// Accounts registered before that only differ in case are left alone (renaming them would lock
+// their owners out), they're logged and the index waits until they've been resolved
+func ensureUsernameIndex(db *gorm.DB) error {
+	var collisions []string
+	if err := db.Unscoped().Model(&models.User{}).
+		Select("LOWER(username)").
+		Group("LOWER(username)").
+		Having("COUNT(*) > 1").
+		Pluck("LOWER(username)", &collisions).Error; err != nil {
+		return err
+	}
+	if len(collisions) > 0 {
+		log.Printf("Usernames differing only in case exist, skipping the case-insensitive username index: %v", collisions)
+		return nil
+	}
+
+	return db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_lower ON users (LOWER(username))").Error
+}
+
+// configurePool -> Applies the DB_* connection pool env vars to the underlying sql.DB.
+// Defaults match database/sql's own: unlimited open connections, 2 idle, no max lifetime
+func configurePool(db *gorm.DB) error {
+	maxOpen := config.Int("DB_MAX_OPEN_CONNS", 0)
+	maxIdle := config.Int("DB_MAX_IDLE_CONNS", 2)
+	maxLifetime := config.Duration("DB_CONN_MAX_LIFETIME", 0)
+
+	if maxOpen < 0 || maxOpen > 1000 {
+		return fmt.Errorf("DB_MAX_OPEN_CONNS must be between 0 (unlimited) and 1000, got %d", maxOpen)
+	}
+	if maxIdle < 0 || (maxOpen > 0 && maxIdle > maxOpen) {
+		return fmt.Errorf("DB_MAX_IDLE_CONNS must be between 0 and DB_MAX_OPEN_CONNS (%d), got %d", maxOpen, maxIdle)
+	}
+	if maxLifetime < 0 || maxLifetime > 24*time.Hour {
+		return fmt.Errorf("DB_CONN_MAX_LIFETIME must be between 0 (forever) and 24h, got %s", maxLifetime)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	sqlDB.SetMaxOpenConns(maxOpen)
+	sqlDB.SetMaxIdleConns(maxIdle)
+	sqlDB.SetConnMaxLifetime(maxLifetime)
+
+	log.Printf("Database pool: max_open_conns=%d max_idle_conns=%d conn_max_lifetime=%s", maxOpen, maxIdle, maxLifetime)
+	return nil
+}
+
+// seedAdmin -> Promotes (or creates) the user named by ADMIN_USERNAME to the admin role
+func seedAdmin(db *gorm.DB) error {
+	username := config.String("ADMIN_USERNAME", "")
+	if username == "" {
+		return nil
+	}
+
+	user, err := models.FindUserByUsername(db, username)
+	if err == nil {
+		if user.Role == models.RoleAdmin {
+			return nil
+		}
+		log.Printf("Promoting user %s to admin", username)
+		return db.Model(&user).Update("role", models.RoleAdmin).Error
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	// Creating a fresh admin needs credentials as well
+	email := config.String("ADMIN_EMAIL", "")
+	password := config.String("ADMIN_PASSWORD", "")
+	if email == "" || password == "" {
+		return fmt.Errorf("admin user %s doesn't exist, set ADMIN_EMAIL and ADMIN_PASSWORD to create it", username)
+	}
+
+	user = models.User{
+		Username: username,
+		Email:    email,
+		Role:     models.RoleAdmin,
+	}
+	if err := user.HashPassword(password); err != nil {
+		return err
+	}
+
+	log.Printf("Creating admin user %s", username)
+	return db.Create(&user).Error
+}
+
 func main() {
+	seed := flag.Bool("seed", false, "populate the database with demo data and exit")
+	flag.Parse()
+
+	// Catch a misspelled PASSWORD_HASHER now rather than on the first registration
+	if _, err := password.Preferred(); err != nil {
+		log.Fatalf("Invalid password hashing setup: %v", err)
+	}
+
 	db, err := initDB()
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
+
+	if err := seedAdmin(db); err != nil {
+		log.Fatalf("Failed to seed admin user: %v", err)
+	}
+
+	if *seed {
+		if err := seedDemoData(db); err != nil {
+			log.Fatalf("Failed to seed demo data: %v", err)
+		}
+		return
+	}
 	// Router Initilization
-	router := gin.Default()
+	// gin.New() instead of gin.Default() so only our structured logger writes access logs
+	router := gin.New()
+	router.Use(gin.Recovery())
 
 	// Config CORS
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{
-		"http://localhost:3000",
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowOrigins = config.AllowedOrigins()
+	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", middleware.RequestIDHeader, "Idempotency-Key", "If-None-Match"}
+	corsConfig.ExposeHeaders = []string{middleware.RequestIDHeader, "Idempotent-Replayed", "Retry-After", "ETag", "X-Total-Count", "Link"}
+	corsConfig.AllowCredentials = true
+
+	// Browsers reject credentialed requests to a wildcard origin, so refuse to start with that combination
+	for _, origin := range corsConfig.AllowOrigins {
+		if origin == "*" && corsConfig.AllowCredentials {
+			log.Fatalf("CORS_ALLOWED_ORIGINS must list explicit origins, \"*\" can't be combined with credentials")
+		}
 	}
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
-	config.AllowCredentials = true
-	router.Use(cors.New(config))
+	log.Printf("CORS allowed origins: %v", corsConfig.AllowOrigins)
+	router.Use(cors.New(corsConfig))
 
-	// Set trusted Proxies
-	router.SetTrustedProxies([]string{"127.0.0.1"})
+	// Set trusted Proxies, see config.TrustedProxies before widening this
+	trustedProxies := config.TrustedProxies()
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	if trustedProxies == nil {
+		log.Println("Trusting no proxies, client IPs are the connecting addresses")
+	} else {
+		log.Printf("Trusted proxies: %v", trustedProxies)
+	}
+
+	// Optional Redis cache for public deck reads, disabled when REDIS_URL is unset
+	deckCache, err := cache.New(config.String("REDIS_URL", ""), config.Duration("REDIS_CACHE_TTL", time.Minute))
+	if err != nil {
+		log.Fatalf("Failed to connect to Redis: %v", err)
+	}
+	if deckCache == nil {
+		log.Println("REDIS_URL not set, deck caching disabled")
+	}
+
+	// Emails are only logged until a real provider is configured
+	mail := mailer.NewLogMailer()
+
+	routes.SetupRoutes(router, db, deckCache, mail)
 
 	router.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"message": "Welcome to QuizGo API"})
 	})
 
-	routes.SetupRoutes(router, db)
+	// Stop on Ctrl+C or SIGTERM, letting in-flight requests and the background workers finish
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Background refresh of the due counts GetStudyStats reads
+	dueCountInterval := config.Duration("DUE_COUNT_REFRESH_INTERVAL", 5*time.Minute)
+	if dueCountInterval <= 0 {
+		log.Fatalf("DUE_COUNT_REFRESH_INTERVAL must be positive, got %s", dueCountInterval)
+	}
+	var workers sync.WaitGroup
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		stats.NewDueCountWorker(db, dueCountInterval).Run(ctx)
+	}()
+	log.Printf("Due count worker refreshing every %s", dueCountInterval)
+
+	// Daily due review reminders, queued once each user's reminder time passes in their timezone
+	reminderInterval := config.Duration("REMINDER_CHECK_INTERVAL", 15*time.Minute)
+	if reminderInterval <= 0 {
+		log.Fatalf("REMINDER_CHECK_INTERVAL must be positive, got %s", reminderInterval)
+	}
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		notify.NewReminderWorker(db, reminderInterval).Run(ctx)
+	}()
+	log.Printf("Reminder worker checking every %s", reminderInterval)
+
+	// Delivery of queued notifications, retried until the user's channel accepts them
+	outboxInterval := config.Duration("OUTBOX_DISPATCH_INTERVAL", 10*time.Second)
+	if outboxInterval <= 0 {
+		log.Fatalf("OUTBOX_DISPATCH_INTERVAL must be positive, got %s", outboxInterval)
+	}
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		notify.NewOutboxDispatcher(db, outboxInterval, notify.NewEmailChannel(mail), notify.NewLogChannel()).Run(ctx)
+	}()
+	log.Printf("Outbox dispatcher running every %s", outboxInterval)
+
+	server := &http.Server{Addr: ":8080", Handler: router}
+	go func() {
+		log.Printf("Server starting on port %s", "8080")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down server...")
 
-	log.Printf("Server starting on port %s", "8080")
-	if err := router.Run(":8080"); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server forced to shut down: %v", err)
 	}
+	workers.Wait()
 
 }
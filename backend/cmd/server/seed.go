@@ -0,0 +1,139 @@
+package main
+
+import (
+	"FlashQuiz/internal/config"
+	"FlashQuiz/internal/models"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// demoDeck -> A deck created by the -seed flag, cards are front/back pairs
+type demoDeck struct {
+	title       string
+	description string
+	category    string
+	public      bool
+	cards       [][2]string
+}
+
+var demoDecks = []demoDeck{
+	{
+		title:       "World Capitals",
+		description: "Capital cities from around the world",
+		category:    "Geography",
+		public:      true,
+		cards: [][2]string{
+			{"France", "Paris"},
+			{"Japan", "Tokyo"},
+			{"Canada", "Ottawa"},
+			{"Australia", "Canberra"},
+			{"Brazil", "Brasília"},
+			{"Kenya", "Nairobi"},
+			{"The capital of Germany is ___.", "Berlin"},
+		},
+	},
+	{
+		title:       "Go Basics",
+		description: "Core Go language concepts",
+		category:    "Programming",
+		public:      false,
+		cards: [][2]string{
+			{"Keyword to start a goroutine", "go"},
+			{"Zero value of a pointer", "nil"},
+			{"Built-in to append to a slice", "append"},
+			{"Statement that runs a call when the function returns", "defer"},
+			{"Channels are closed with the ___ built-in.", "close"},
+		},
+	},
+	{
+		title:       "Spanish Greetings",
+		description: "Everyday Spanish phrases",
+		category:    "Languages",
+		public:      true,
+		cards: [][2]string{
+			{"Hello", "Hola"},
+			{"Good morning", "Buenos días"},
+			{"Thank you", "Gracias"},
+			{"See you later", "Hasta luego"},
+		},
+	},
+}
+
+// seedDemoData -> Populates the database with a demo user, decks, cards and some study progress.
+// Every row is looked up before it's created, so running it again changes nothing
+func seedDemoData(db *gorm.DB) error {
+	username := config.String("DEMO_USERNAME", "demo")
+	password := config.String("DEMO_PASSWORD", "demo-password")
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		user, err := models.FindUserByUsername(tx, username)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			user = models.User{Username: username, Email: username + "@example.com"}
+			if err := user.HashPassword(password); err != nil {
+				return err
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+			log.Printf("Seed: created user %s", username)
+		} else if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for i, demo := range demoDecks {
+			deck := models.Deck{
+				Title:       demo.title,
+				Description: demo.description,
+				Category:    demo.category,
+				IsPublic:    demo.public,
+				UserID:      user.ID,
+			}
+			if err := tx.Where("user_id = ? AND title = ?", user.ID, demo.title).FirstOrCreate(&deck).Error; err != nil {
+				return err
+			}
+
+			for j, pair := range demo.cards {
+				card := models.FlashCard{DeckID: deck.ID, FrontContent: pair[0], BackContent: pair[1]}
+				if err := tx.Where("deck_id = ? AND front_content = ?", deck.ID, pair[0]).FirstOrCreate(&card).Error; err != nil {
+					return err
+				}
+
+				// Progress on the first deck only, leaving the others new. Cards alternate between
+				// due now and due later so both the study queue and the stats have something to show
+				if i != 0 || j >= 4 {
+					continue
+				}
+				interval := 1 + j*3
+				progress := models.CardProgress{
+					UserID:         user.ID,
+					CardID:         card.ID,
+					EaseFactor:     2.5,
+					Interval:       interval,
+					ReviewCount:    j + 1,
+					CorrectCount:   j,
+					LastReviewedAt: now.AddDate(0, 0, -interval),
+					NextReviewDate: now.AddDate(0, 0, interval*(j%2)),
+					Status:         "learning",
+				}
+				if err := tx.Where("user_id = ? AND card_id = ?", user.ID, card.ID).FirstOrCreate(&progress).Error; err != nil {
+					return err
+				}
+			}
+
+			var cardCount int64
+			if err := tx.Model(&models.FlashCard{}).Where("deck_id = ?", deck.ID).Count(&cardCount).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&deck).Update("card_count", cardCount).Error; err != nil {
+				return err
+			}
+		}
+
+		log.Printf("Seed: %d demo decks ready for user %s", len(demoDecks), username)
+		return nil
+	})
+}
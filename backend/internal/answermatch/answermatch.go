@@ -0,0 +1,175 @@
+package answermatch
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Matching modes, from strictest to most lenient
+const (
+	ModeExact             = "exact"              // Byte for byte
+	ModeCaseInsensitive   = "case_insensitive"   // Ignores case and surrounding whitespace
+	ModeIgnorePunctuation = "ignore_punctuation" // Also ignores punctuation and extra whitespace
+	ModeFuzzy             = "fuzzy"              // Like ignore_punctuation, tolerating typos up to a similarity threshold
+)
+
+// DefaultFuzzyThreshold -> Similarity fuzzy matching needs when the config doesn't set one
+const DefaultFuzzyThreshold = 0.8
+
+// Config -> How a typed answer is compared with the expected one. The zero value has no mode,
+// which lets the caller fall back to another config (e.g. the user's own preference)
+type Config struct {
+	Mode           string  `json:"mode"`
+	IgnoreAccents  bool    `json:"ignore_accents"`  // "café" matches "cafe", in any mode but exact
+	FuzzyThreshold float64 `json:"fuzzy_threshold"` // Fuzzy only: minimum similarity from 0 to 1
+}
+
+// Errors returned by Validate, worded to be shown to the user as they are
+var (
+	ErrUnknownMode      = errors.New("answer match mode must be one of exact, case_insensitive, ignore_punctuation, fuzzy")
+	ErrBadThreshold     = errors.New("fuzzy threshold must be between 0 and 1")
+	ErrAccentsWithExact = errors.New("ignore_accents can't be combined with exact matching")
+)
+
+// Validate -> Returns the first problem with the config, nil when it's usable. The zero value is valid
+func (c Config) Validate() error {
+	switch c.Mode {
+	case "", ModeExact, ModeCaseInsensitive, ModeIgnorePunctuation, ModeFuzzy:
+	default:
+		return ErrUnknownMode
+	}
+	if c.FuzzyThreshold < 0 || c.FuzzyThreshold > 1 {
+		return ErrBadThreshold
+	}
+	if c.Mode == ModeExact && c.IgnoreAccents {
+		return ErrAccentsWithExact
+	}
+	return nil
+}
+
+// IsSet -> Whether the config picks a mode, rather than deferring to a fallback
+func (c Config) IsSet() bool {
+	return c.Mode != ""
+}
+
+// Normalizer -> One transformation applied to both answers before comparing them
+type Normalizer func(string) string
+
+// Chain -> A normalizer applying each of the given ones in order
+func Chain(normalizers ...Normalizer) Normalizer {
+	return func(s string) string {
+		for _, normalize := range normalizers {
+			s = normalize(s)
+		}
+		return s
+	}
+}
+
+// TrimSpace -> Removes leading and trailing whitespace
+func TrimSpace(s string) string {
+	return strings.TrimSpace(s)
+}
+
+// CollapseSpace -> Trims and turns every run of whitespace into a single space
+func CollapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Lowercase -> Folds the text to lowercase
+func Lowercase(s string) string {
+	return strings.ToLower(s)
+}
+
+// StripPunctuation -> Drops punctuation and symbols, keeping letters, digits and whitespace
+func StripPunctuation(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// stripMarks -> Decomposes characters and drops the combining marks, so "é" becomes "e"
+var stripMarks = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// StripAccents -> Removes diacritics, leaving the base letters
+func StripAccents(s string) string {
+	result, _, err := transform.String(stripMarks, s)
+	if err != nil {
+		return s
+	}
+	return result
+}
+
+// normalizer -> The normalizations the config applies to both answers
+func (c Config) normalizer() Normalizer {
+	var steps []Normalizer
+	switch c.Mode {
+	case ModeCaseInsensitive:
+		steps = []Normalizer{TrimSpace, Lowercase}
+	case ModeIgnorePunctuation, ModeFuzzy:
+		steps = []Normalizer{StripPunctuation, CollapseSpace, Lowercase}
+	}
+	if c.IgnoreAccents && c.Mode != ModeExact {
+		steps = append(steps, StripAccents)
+	}
+	return Chain(steps...)
+}
+
+// Match -> Whether the given answer counts as the expected one under the config. A config
+// without a mode matches exactly
+func (c Config) Match(given, expected string) bool {
+	normalize := c.normalizer()
+	given, expected = normalize(given), normalize(expected)
+	if given == expected {
+		return true
+	}
+	if c.Mode != ModeFuzzy {
+		return false
+	}
+
+	threshold := c.FuzzyThreshold
+	if threshold == 0 {
+		threshold = DefaultFuzzyThreshold
+	}
+	return Similarity(given, expected) >= threshold
+}
+
+// Similarity -> How alike two strings are from 0 (nothing in common) to 1 (identical), based on the
+// edit distance between them relative to the longer one. Counted in characters, not bytes
+func Similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein -> Fewest single-character insertions, deletions and substitutions turning a into b
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,81 @@
+package answermatch
+
+import "testing"
+
+func TestConfigMatch(t *testing.T) {
+	exact := Config{Mode: ModeExact}
+	caseInsensitive := Config{Mode: ModeCaseInsensitive}
+	punctuation := Config{Mode: ModeIgnorePunctuation}
+	fuzzy := Config{Mode: ModeFuzzy}
+
+	tests := []struct {
+		name     string
+		config   Config
+		given    string
+		expected string
+		want     bool
+	}{
+		// Exact
+		{"exact identical", exact, "Paris", "Paris", true},
+		{"exact case differs", exact, "paris", "Paris", false},
+		{"exact surrounding space", exact, " Paris ", "Paris", false},
+		{"exact accents differ", exact, "cafe", "café", false},
+		{"no mode is exact", Config{}, "paris", "Paris", false},
+
+		// Case
+		{"case differs", caseInsensitive, "pARIS", "Paris", true},
+		{"case surrounding space", caseInsensitive, "  paris\t", "Paris", true},
+		{"case inner space still counts", caseInsensitive, "new  york", "New York", false},
+		{"case punctuation still counts", caseInsensitive, "st louis", "St. Louis", false},
+		{"case non-ASCII letters", caseInsensitive, "ÉCOLE", "école", true},
+
+		// Whitespace and punctuation
+		{"punctuation ignored", punctuation, "st louis", "St. Louis", true},
+		{"punctuation inner space collapsed", punctuation, "new   york", "New York", true},
+		{"punctuation symbols ignored", punctuation, "rock n roll", "Rock 'n' Roll!", true},
+		{"punctuation words still count", punctuation, "new yorks", "New York", false},
+		{"punctuation accents still count", punctuation, "cafe", "café", false},
+
+		// Accents
+		{"accents ignored case insensitive", Config{Mode: ModeCaseInsensitive, IgnoreAccents: true}, "CAFE", "café", true},
+		{"accents ignored with punctuation", Config{Mode: ModeIgnorePunctuation, IgnoreAccents: true}, "creme brulee!", "Crème brûlée", true},
+		// Typed with a combining accent, which only matches once accents are ignored
+		{"accents precomposed and combining", Config{Mode: ModeCaseInsensitive}, "cafe\u0301", "café", false},
+		{"accents combining ignored", Config{Mode: ModeCaseInsensitive, IgnoreAccents: true}, "cafe\u0301", "café", true},
+
+		// Fuzzy
+		{"fuzzy one typo", fuzzy, "Pariss", "Paris", true},
+		{"fuzzy different word", fuzzy, "London", "Paris", false},
+		{"fuzzy custom threshold", Config{Mode: ModeFuzzy, FuzzyThreshold: 0.95}, "Pariss", "Paris", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.Match(tt.given, tt.expected); got != tt.want {
+				t.Errorf("Match(%q, %q) = %v, want %v", tt.given, tt.expected, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   error
+	}{
+		{"zero value", Config{}, nil},
+		{"fuzzy with threshold", Config{Mode: ModeFuzzy, FuzzyThreshold: 0.7}, nil},
+		{"unknown mode", Config{Mode: "loose"}, ErrUnknownMode},
+		{"threshold above one", Config{Mode: ModeFuzzy, FuzzyThreshold: 1.5}, ErrBadThreshold},
+		{"exact ignoring accents", Config{Mode: ModeExact, IgnoreAccents: true}, ErrAccentsWithExact},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.Validate(); got != tt.want {
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Error -> Typed error carrying the HTTP status and a client-safe message.
+// Err holds the internal cause, which is logged but never sent to the client
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Details any // Optional structured detail sent along with the message, e.g. per-item problems
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func New(status int, code, message string, err error) *Error {
+	return &Error{Status: status, Code: code, Message: message, Err: err}
+}
+
+// WithDetails -> Attaches structured details for the client to the error
+func (e *Error) WithDetails(details any) *Error {
+	e.Details = details
+	return e
+}
+
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, "bad_request", message, nil)
+}
+
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, "unauthorized", message, nil)
+}
+
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, "forbidden", message, nil)
+}
+
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, "not_found", message, nil)
+}
+
+func Conflict(message string) *Error {
+	return New(http.StatusConflict, "conflict", message, nil)
+}
+
+// PayloadTooLarge -> The request body went over the limit for its route
+func PayloadTooLarge(limit int64) *Error {
+	return New(http.StatusRequestEntityTooLarge, "payload_too_large", fmt.Sprintf("Request body is too large, the limit is %d bytes", limit), nil)
+}
+
+// TooManyRequests -> The caller went over a rate limit, the middleware sets Retry-After alongside
+func TooManyRequests(message string) *Error {
+	return New(http.StatusTooManyRequests, "rate_limited", message, nil)
+}
+
+// Internal -> Wraps an unexpected failure; the cause is kept for the server logs only
+func Internal(message string, err error) *Error {
+	return New(http.StatusInternalServerError, "internal_error", message, err)
+}
+
+// Validation -> Converts a request binding error into a 400 with a readable message
+// instead of echoing the raw validator output. A body cut off by the size limit is a 413
+func Validation(err error) *Error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		appErr := PayloadTooLarge(maxBytesErr.Limit)
+		appErr.Err = err
+		return appErr
+	}
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		messages := make([]string, 0, len(validationErrors))
+		for _, fe := range validationErrors {
+			messages = append(messages, fmt.Sprintf("%s failed on the '%s' rule", fe.Field(), fe.Tag()))
+		}
+		return New(http.StatusBadRequest, "validation_failed", strings.Join(messages, "; "), err)
+	}
+	return New(http.StatusBadRequest, "bad_request", "Invalid request body", err)
+}
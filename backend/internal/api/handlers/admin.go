@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/cache"
+	"FlashQuiz/internal/models"
+	"FlashQuiz/internal/stats"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AdminHandler -> Moderation endpoints, routes must be guarded by RequireRole(models.RoleAdmin)
+type AdminHandler struct {
+	db    *gorm.DB
+	cache *cache.Cache
+}
+
+func NewAdminHandler(db *gorm.DB, cache *cache.Cache) *AdminHandler {
+	return &AdminHandler{db: db, cache: cache}
+}
+
+// ListPublicDecks -> Handler to list every public deck along with its owner
+func (h *AdminHandler) ListPublicDecks(c *gin.Context) {
+	var decks []models.Deck
+	if err := h.db.Preload("User").Where("is_public = ?", true).Order("created_at DESC").Find(&decks).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve decks", err))
+		return
+	}
+
+	// Deck.User is hidden from JSON, so surface the owner explicitly
+	formattedDecks := make([]gin.H, 0, len(decks))
+	for _, deck := range decks {
+		formattedDecks = append(formattedDecks, gin.H{
+			"deck": deck,
+			"owner": gin.H{
+				"id":       deck.User.ID,
+				"username": deck.User.Username,
+			},
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"decks": formattedDecks,
+	})
+}
+
+// UnpublishDeck -> Handler to force a deck private regardless of who owns it
+func (h *AdminHandler) UnpublishDeck(c *gin.Context) {
+	deckID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid deck ID"))
+		return
+	}
+
+	var deck models.Deck
+	if err := h.db.First(&deck, deckID).Error; err != nil {
+		c.Error(apperrors.NotFound("Deck not found"))
+		return
+	}
+
+	if err := h.db.Model(&deck).Update("is_public", false).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to unpublish deck", err))
+		return
+	}
+
+	invalidateDeckCache(c.Request.Context(), h.cache, deck.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Deck unpublished successfully",
+		"deck":    deck,
+	})
+}
+
+// DeleteDeck -> Handler to take down any deck. It goes to the owner's trash like a deck they
+// deleted, but marked so they can only purge it, never restore it
+func (h *AdminHandler) DeleteDeck(c *gin.Context) {
+	deckID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid deck ID"))
+		return
+	}
+
+	var deck models.Deck
+	if err := h.db.First(&deck, deckID).Error; err != nil {
+		c.Error(apperrors.NotFound("Deck not found"))
+		return
+	}
+
+	if err := trashDeck(h.db, deck, true); err != nil {
+		c.Error(apperrors.Internal("Failed to delete deck", err))
+		return
+	}
+
+	invalidateDeckCache(c.Request.Context(), h.cache, deck.ID)
+
+	if err := stats.RecomputeDueCounts(h.db, deck.UserID); err != nil {
+		log.Printf("Failed to recompute due counts for user %d: %v", deck.UserID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Deck deleted successfully",
+	})
+}
@@ -1,8 +1,16 @@
 package handlers
 
 import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/config"
+	"FlashQuiz/internal/mailer"
 	"FlashQuiz/internal/models"
+	"FlashQuiz/internal/password"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"time"
@@ -13,18 +21,19 @@ import (
 )
 
 type AuthHandler struct {
-	db *gorm.DB
+	db     *gorm.DB
+	mailer mailer.Mailer
 }
 
-func NewAuthHandler(db *gorm.DB) *AuthHandler {
-	return &AuthHandler{db: db}
+func NewAuthHandler(db *gorm.DB, mailer mailer.Mailer) *AuthHandler {
+	return &AuthHandler{db: db, mailer: mailer}
 }
 
 // RegisterRequest -> Struct for user registration request
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=30"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=8,max=100"`
+	Password string `json:"password" binding:"required"` // Checked against password.Validate
 }
 
 // LoginRequest -> Struct for user login request
@@ -34,7 +43,10 @@ type LoginRequest struct {
 }
 
 type JWTClaims struct {
+	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
+	Role     string `json:"role"`          // Embedded so role checks don't need a DB lookup per request
+	Version  int    `json:"token_version"` // Must match User.TokenVersion, see middleware.AuthMiddleware
 	jwt.RegisteredClaims
 }
 
@@ -43,8 +55,16 @@ func generateJWT(user models.User) (string, error) {
 	secretKey := os.Getenv("JWT_SECRET")
 	fmt.Println("----JWT secret key", secretKey)
 
+	role := user.Role
+	if role == "" {
+		role = models.RoleUser
+	}
+
 	claims := JWTClaims{
+		UserID:   user.ID,
 		Username: user.Username,
+		Role:     role,
+		Version:  user.TokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24)), // Token expires in 24 hours
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -65,19 +85,24 @@ func generateJWT(user models.User) (string, error) {
 func (h *AuthHandler) RegisterUser(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperrors.Validation(err))
 		return 
 	}
 
-	var existingUser models.User
-	if err := h.db.Where("username = ?", req.Username).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
+	if err := password.Validate(req.Password); err != nil {
+		c.Error(apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	// Usernames are unique regardless of case
+	if _, err := models.FindUserByUsername(h.db, req.Username); err == nil {
+		c.Error(apperrors.Conflict("Username already exists"))
 		return 
 	}
 
 	var existingEmail models.User
 	if err := h.db.Where("email = ?", req.Email).First(&existingEmail).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
+		c.Error(apperrors.Conflict("Email already exists"))
 		return 
 	}
 
@@ -85,24 +110,25 @@ func (h *AuthHandler) RegisterUser(c *gin.Context) {
 	user := models.User{
 		Username: req.Username,
 		Email: req.Email,
+		Role: models.RoleUser,
 	}
 
 	// Hash password
 	if err := user.HashPassword(req.Password); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		c.Error(apperrors.Internal("Failed to hash password", err))
 		return 
 	}
 
 	// Save user to database
 	if err := h.db.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create a user"})
+		c.Error(apperrors.Internal("Failed to create a user", err))
 		return
 	}
 
 	// JWT Token Generation
 	token, err := generateJWT(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		c.Error(apperrors.Internal("Failed to generate token", err))
 		return
 	}
 
@@ -113,6 +139,7 @@ func (h *AuthHandler) RegisterUser(c *gin.Context) {
 			"id": user.ID,
 			"username": user.Username,
 			"email": user.Email,
+			"role": user.Role,
 		},
 	})
 }
@@ -120,25 +147,37 @@ func (h *AuthHandler) RegisterUser(c *gin.Context) {
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperrors.Validation(err))
 		return
 	}
 
-	var user models.User
-	if err := h.db.Where("username = ?", req.Username).First(&user).Error; err != nil{
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+	user, err := models.FindUserByUsername(h.db, req.Username)
+	if err != nil {
+		c.Error(apperrors.Unauthorized("Invalid username or password"))
 		return
 	}
 
 	if err := user.CheckPassword(req.Password); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		c.Error(apperrors.Unauthorized("Invalid username or password"))
 		return
 	}
 
+	// Move hashes made under an older scheme to the preferred one while the password is at hand.
+	// Failing to is no reason to refuse the login, the next one tries again
+	if user.PasswordNeedsRehash() {
+		err := user.HashPassword(req.Password)
+		if err == nil {
+			err = h.db.Model(&user).UpdateColumn("password_hash", user.PasswordHash).Error
+		}
+		if err != nil {
+			log.Printf("Failed to rehash password for user %d: %v", user.ID, err)
+		}
+	}
+
 	// Token Generation
 	token, err := generateJWT(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		c.Error(apperrors.Internal("Failed to generate token", err))
 		return
 	}
 
@@ -149,6 +188,132 @@ func (h *AuthHandler) Login(c *gin.Context) {
 			"id": user.ID,
 			"username": user.Username,
 			"email": user.Email,
+			"role": user.Role,
 		},
 	})
-}
\ No newline at end of file
+}
+
+// ForgotPasswordRequest -> Struct for requesting a password reset email
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest -> Struct for setting a new password with a reset token
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"` // Checked against password.Validate
+}
+
+// hashResetToken -> Reset tokens are stored hashed so a leaked table can't be used to reset passwords
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ForgotPassword -> Handler to email a time-limited reset token.
+// Always responds 200 so the endpoint can't be used to discover registered emails
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	response := gin.H{"message": "If an account with that email exists, a reset link has been sent"}
+
+	var user models.User
+	if err := h.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		c.Error(apperrors.Internal("Failed to generate reset token", err))
+		return
+	}
+	token := hex.EncodeToString(raw)
+
+	resetToken := models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashResetToken(token),
+		ExpiresAt: time.Now().Add(config.Duration("PASSWORD_RESET_TTL", time.Hour)),
+	}
+	if err := h.db.Create(&resetToken).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to create reset token", err))
+		return
+	}
+
+	resetURL := config.String("PASSWORD_RESET_URL", "http://localhost:3000/reset-password")
+	body := fmt.Sprintf("Hi %s,\n\nUse the link below to reset your password. It expires at %s.\n\n%s?token=%s\n\nIf you didn't request this, you can ignore this email.",
+		user.Username, resetToken.ExpiresAt.Format(time.RFC1123), resetURL, token)
+
+	// Delivery failures are logged rather than returned, the response must not reveal anything
+	if err := h.mailer.Send(c.Request.Context(), user.Email, "Reset your QuizGo password", body); err != nil {
+		log.Printf("Failed to send password reset email to user %d: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ResetPassword -> Handler to set a new password using a valid, unused reset token
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	if err := password.Validate(req.NewPassword); err != nil {
+		c.Error(apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	var resetToken models.PasswordResetToken
+	err := h.db.Preload("User").
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hashResetToken(req.Token), time.Now()).
+		First(&resetToken).Error
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid or expired reset token"))
+		return
+	}
+
+	user := resetToken.User
+	if err := user.HashPassword(req.NewPassword); err != nil {
+		c.Error(apperrors.Internal("Failed to hash password", err))
+		return
+	}
+
+	// Update the password and burn every outstanding token for the user together
+	tx := h.db.Begin()
+
+	if err := tx.Model(&user).Update("password_hash", user.PasswordHash).Error; err != nil {
+		tx.Rollback()
+		c.Error(apperrors.Internal("Failed to reset password", err))
+		return
+	}
+
+	if err := tx.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", user.ID).
+		Update("used_at", time.Now()).Error; err != nil {
+		tx.Rollback()
+		c.Error(apperrors.Internal("Failed to reset password", err))
+		return
+	}
+
+	// Whoever prompted the reset may be holding a valid token, log every session out
+	if _, err := models.RevokeTokens(tx, user.ID); err != nil {
+		tx.Rollback()
+		c.Error(apperrors.Internal("Failed to reset password", err))
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Error(apperrors.Internal("Failed to reset password", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password reset successfully",
+	})
+}
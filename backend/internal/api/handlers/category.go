@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/cache"
+	"FlashQuiz/internal/models"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CategoryHandler struct {
+	db    *gorm.DB
+	cache *cache.Cache
+}
+
+func NewCategoryHandler(db *gorm.DB, deckCache *cache.Cache) *CategoryHandler {
+	return &CategoryHandler{db: db, cache: deckCache}
+}
+
+// cleanCategory -> Trims and collapses whitespace in a category name
+func cleanCategory(raw string) string {
+	return strings.Join(strings.Fields(raw), " ")
+}
+
+// canonicalCategory -> Cleans a category and, when the user already has a deck in the same
+// category under different casing, reuses that spelling so "Math" and "math" don't fragment
+func canonicalCategory(db *gorm.DB, userID uint, raw string) (string, error) {
+	category := cleanCategory(raw)
+	if category == "" {
+		return "", nil
+	}
+
+	var existing []string
+	if err := db.Model(&models.Deck{}).
+		Where("user_id = ? AND LOWER(category) = LOWER(?)", userID, category).
+		Limit(1).
+		Pluck("category", &existing).Error; err != nil {
+		return "", err
+	}
+	if len(existing) > 0 {
+		return existing[0], nil
+	}
+	return category, nil
+}
+
+// GetCategories -> Handler to list the distinct categories across the user's decks with deck counts
+func (h *CategoryHandler) GetCategories(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	type categoryCount struct {
+		Name      string `json:"name"`
+		DeckCount int64  `json:"deck_count"`
+	}
+
+	// Grouped case-insensitively so decks stored before normalization still merge
+	var categories []categoryCount
+	if err := h.db.Model(&models.Deck{}).
+		Select("MIN(category) AS name, COUNT(*) AS deck_count").
+		Where("user_id = ? AND category <> ''", userID).
+		Group("LOWER(category)").
+		Order("LOWER(category) ASC").
+		Scan(&categories).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve categories", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"categories": categories,
+	})
+}
+
+// RenameCategoryRequest -> Struct for renaming a category across the user's decks
+type RenameCategoryRequest struct {
+	From string `json:"from" binding:"required"`
+	To   string `json:"to" binding:"required"`
+}
+
+// RenameCategory -> Handler to rename a category on every one of the user's decks at once.
+// Renaming onto another existing category merges the two
+func (h *CategoryHandler) RenameCategory(c *gin.Context) {
+	var req RenameCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	from := cleanCategory(req.From)
+	to := cleanCategory(req.To)
+	if from == "" || to == "" {
+		c.Error(apperrors.BadRequest("Category names can't be blank"))
+		return
+	}
+
+	// Merging into another category keeps that category's spelling, a case-only rename keeps the new one
+	if !strings.EqualFold(from, to) {
+		var err error
+		if to, err = canonicalCategory(h.db, userID.(uint), to); err != nil {
+			c.Error(apperrors.Internal("Failed to rename category", err))
+			return
+		}
+	}
+
+	result := h.db.Model(&models.Deck{}).
+		Where("user_id = ? AND LOWER(category) = LOWER(?)", userID, from).
+		Update("category", to)
+	if result.Error != nil {
+		c.Error(apperrors.Internal("Failed to rename category", result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(apperrors.NotFound("Category not found"))
+		return
+	}
+
+	// Public listings are cached per category
+	invalidateDeckCache(c.Request.Context(), h.cache)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Category renamed successfully",
+		"category":      to,
+		"decks_updated": result.RowsAffected,
+	})
+}
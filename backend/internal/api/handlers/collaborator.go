@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/models"
+	"FlashQuiz/internal/notify"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// loadOwnedDeck -> Loads the deck in the :id param, writing the error and returning false unless the caller owns it
+func (h *DeckHandler) loadOwnedDeck(c *gin.Context, userID uint) (models.Deck, bool) {
+	var deck models.Deck
+	deckID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid deck ID"))
+		return deck, false
+	}
+
+	if err := h.db.First(&deck, deckID).Error; err != nil {
+		c.Error(apperrors.NotFound("Deck not found"))
+		return deck, false
+	}
+
+	if deck.UserID != userID {
+		c.Error(apperrors.Forbidden("Only the owner can manage this deck's collaborators"))
+		return deck, false
+	}
+
+	return deck, true
+}
+
+// CollaboratorResponse -> A collaborator as returned by the API
+type CollaboratorResponse struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// AddCollaboratorRequest -> Struct for inviting a user to a deck by username
+type AddCollaboratorRequest struct {
+	Username string `json:"username" binding:"required"`
+	Role     string `json:"role" binding:"required,oneof=editor viewer"`
+}
+
+// AddCollaborator -> Handler to give another user access to a deck, or change the role they already have
+func (h *DeckHandler) AddCollaborator(c *gin.Context) {
+	var req AddCollaboratorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	deck, ok := h.loadOwnedDeck(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	user, err := models.FindUserByUsername(h.db, req.Username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.Error(apperrors.NotFound("User not found"))
+			return
+		}
+		c.Error(apperrors.Internal("Failed to look up user", err))
+		return
+	}
+
+	if user.ID == deck.UserID {
+		c.Error(apperrors.BadRequest("You already own this deck"))
+		return
+	}
+
+	var owner models.User
+	if err := h.db.First(&owner, userID).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to look up user", err))
+		return
+	}
+
+	// Inviting an existing collaborator again just updates their role. Only a new share is
+	// announced, through the outbox so the notification commits together with the share
+	collaborator := models.DeckCollaborator{DeckID: deck.ID, UserID: user.ID}
+	err = withRetry(h.db, func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&models.DeckCollaborator{}).Where(&collaborator).Count(&existing).Error; err != nil {
+			return err
+		}
+		if err := tx.Where(&collaborator).Assign(models.DeckCollaborator{Role: req.Role}).FirstOrCreate(&collaborator).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return nil
+		}
+		return notify.Enqueue(tx, user.ID, models.OutboxEventDeckShared, notify.Notification{
+			Subject: fmt.Sprintf("%s shared \"%s\" with you", owner.Username, deck.Title),
+			Body: fmt.Sprintf("Hi %s,\n\n%s shared the deck \"%s\" with you as %s. You'll find it with your decks.",
+				user.Username, owner.Username, deck.Title, req.Role),
+		})
+	})
+	if err != nil {
+		c.Error(txError(err, "Failed to add collaborator"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Collaborator added successfully",
+		"collaborator": CollaboratorResponse{
+			UserID:   user.ID,
+			Username: user.Username,
+			Role:     collaborator.Role,
+		},
+	})
+}
+
+// GetCollaborators -> Handler to list who a deck is shared with, visible to anyone with access to it
+func (h *DeckHandler) GetCollaborators(c *gin.Context) {
+	deckID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid deck ID"))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var deck models.Deck
+	if err := h.db.First(&deck, deckID).Error; err != nil {
+		c.Error(apperrors.NotFound("Deck not found"))
+		return
+	}
+
+	allowed, err := canViewDeck(h.db, deck, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to view this deck"))
+		return
+	}
+
+	collaborators := make([]CollaboratorResponse, 0)
+	if err := h.db.Model(&models.DeckCollaborator{}).
+		Select("deck_collaborators.user_id, users.username, deck_collaborators.role").
+		Joins("JOIN users ON users.id = deck_collaborators.user_id").
+		Where("deck_collaborators.deck_id = ?", deck.ID).
+		Order("users.username").
+		Scan(&collaborators).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve collaborators", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deck_id":       deck.ID,
+		"collaborators": collaborators,
+	})
+}
+
+// RemoveCollaborator -> Handler to revoke a user's access to a deck, done by the owner or by the
+// collaborator leaving on their own
+func (h *DeckHandler) RemoveCollaborator(c *gin.Context) {
+	deckID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid deck ID"))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var deck models.Deck
+	if err := h.db.First(&deck, deckID).Error; err != nil {
+		c.Error(apperrors.NotFound("Deck not found"))
+		return
+	}
+
+	user, err := models.FindUserByUsername(h.db, c.Param("username"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.Error(apperrors.NotFound("User not found"))
+			return
+		}
+		c.Error(apperrors.Internal("Failed to look up user", err))
+		return
+	}
+
+	if deck.UserID != userID.(uint) && user.ID != userID.(uint) {
+		c.Error(apperrors.Forbidden("Only the owner can manage this deck's collaborators"))
+		return
+	}
+
+	// Hard delete so the user can be invited again later
+	result := h.db.Unscoped().Where("deck_id = ? AND user_id = ?", deck.ID, user.ID).Delete(&models.DeckCollaborator{})
+	if result.Error != nil {
+		c.Error(apperrors.Internal("Failed to remove collaborator", result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(apperrors.NotFound("User is not a collaborator on this deck"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Collaborator removed successfully",
+	})
+}
@@ -1,174 +1,424 @@
 package handlers
 
 import (
+	"FlashQuiz/internal/answermatch"
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/cache"
 	"FlashQuiz/internal/models"
+	"FlashQuiz/internal/stats"
+	"context"
+	"fmt"
+	"log"
 	"net/http"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type DeckHandler struct {
-	db *gorm.DB
+	db    *gorm.DB
+	cache *cache.Cache
 }
 
-func NewDeckHandler(db *gorm.DB) *DeckHandler {
-	return &DeckHandler{db: db}
+func NewDeckHandler(db *gorm.DB, cache *cache.Cache) *DeckHandler {
+	return &DeckHandler{db: db, cache: cache}
+}
+
+// Cache keys for public deck reads
+const publicDecksCachePrefix = "decks:public:"
+
+func deckCacheKey(deckID uint) string {
+	return fmt.Sprintf("deck:%d", deckID)
+}
+
+// invalidateDeckCache -> Drops cached reads that include the given decks (details and the public listing)
+func invalidateDeckCache(ctx context.Context, deckCache *cache.Cache, deckIDs ...uint) {
+	keys := make([]string, 0, len(deckIDs))
+	for _, deckID := range deckIDs {
+		keys = append(keys, deckCacheKey(deckID))
+	}
+	deckCache.Delete(ctx, keys...)
+	deckCache.DeletePrefix(ctx, publicDecksCachePrefix)
 }
 
 // CreateDeckRequest -> Struct for deck creation request
 type CreateDeckRequest struct {
-	Title       string `json:"title" binding:"required"`
-	Description string `json:"description"`
-	Category    string `json:"category"`
-	IsPublic    bool   `json:"is_public"`
+	Title         string `json:"title" binding:"required"`
+	Description   string `json:"description"`
+	Category      string `json:"category"`
+	IsPublic      bool   `json:"is_public"`
+	LearningSteps []int  `json:"learning_steps" binding:"omitempty,max=10,dive,min=1,max=1440"` // Minutes, at most a day each
+
+	AnswerMatch answermatch.Config `json:"answer_match"` // Omit to grade with each user's own match mode
 }
 
 // CreateDeck -> Handler to create a new deck
 func (h *DeckHandler) CreateDeck(c *gin.Context) {
 	var req CreateDeckRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperrors.Validation(err))
 		return
 	}
 
 	// Get user ID from context (set by AuthMiddleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	if err := req.AnswerMatch.Validate(); err != nil {
+		c.Error(apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	// Reuse the spelling of an existing category that only differs in case or spacing
+	category, err := canonicalCategory(h.db, userID.(uint), req.Category)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to create deck", err))
 		return
 	}
 
 	// Create a new deck
 	deck := models.Deck{
-		Title:       req.Title,
-		Description: req.Description,
-		Category:    req.Category,
-		IsPublic:    req.IsPublic,
-		CardCount:   0,
-		UserID:      userID.(uint),
+		Title:         req.Title,
+		Description:   req.Description,
+		Category:      category,
+		IsPublic:      req.IsPublic,
+		LearningSteps: req.LearningSteps,
+		AnswerMatch:   req.AnswerMatch,
+		CardCount:     0,
+		UserID:        userID.(uint),
 	}
 
 	// Save deck to database
 	if err := h.db.Create(&deck).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create deck"})
+		c.Error(apperrors.Internal("Failed to create deck", err))
 		return
 	}
 
+	if deck.IsPublic {
+		invalidateDeckCache(c.Request.Context(), h.cache)
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Deck created successfully",
 		"deck":    deck,
 	})
 }
 
-// GetDecks -> Handler to get all decks for a user
+// GetDecksRequest -> Query parameters for a page of the user's decks
+type GetDecksRequest struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// GetDecks -> Handler to get the decks for a user in the order they were created, all of them
+// unless a page is asked for
 func (h *DeckHandler) GetDecks(c *gin.Context) {
+	var req GetDecksRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
 	// Get query parameters
 	includePublic := c.Query("include_public") == "true"
+	includeShared := c.Query("include_shared") == "true"
 	categoryFilter := c.Query("category")
 
 	var decks []models.Deck
 	query := h.db
 
 	// Apply filters
+	owned := h.db.Where("user_id = ?", userID)
 	if includePublic {
-		query = query.Where("user_id = ? OR is_public = ?", userID, true)
+		// Own private decks come from the DB, public decks (including the user's own) are merged in below
+		owned = owned.Where("is_public = ?", false)
+	}
+
+	// Decks other users have added the caller to as a collaborator. Grouped into one condition
+	// so the filters below apply to shared decks too
+	if includeShared {
+		sharedIDs := h.db.Model(&models.DeckCollaborator{}).Select("deck_id").Where("user_id = ?", userID)
+		query = query.Where(h.db.Where(owned).Or("id IN (?)", sharedIDs))
 	} else {
-		query = query.Where("user_id = ?", userID)
+		query = query.Where(owned)
 	}
 
 	if categoryFilter != "" {
-		query = query.Where("category = ?", categoryFilter)
+		query = query.Where("LOWER(category) = LOWER(?)", cleanCategory(categoryFilter))
 	}
 
-	// Execute query
-	if err := query.Find(&decks).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve decks"})
+	// Clients that don't ask for a page get the whole list, as before pagination existed
+	paged := req.Page != 0 || req.PageSize != 0
+	page := req.Page
+	if page == 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize == 0 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+
+	var total int64
+	if includePublic {
+		// Public decks come from the cache rather than the query, so the merged list is paged here
+		if err := query.Find(&decks).Error; err != nil {
+			c.Error(apperrors.Internal("Failed to retrieve decks", err))
+			return
+		}
+		publicDecks, err := h.publicDecks(c.Request.Context(), categoryFilter)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to retrieve decks", err))
+			return
+		}
+		decks = append(decks, publicDecks...)
+		sort.Slice(decks, func(i, j int) bool { return decks[i].ID < decks[j].ID })
+		// Public decks shared with the caller came back from both queries
+		decks = slices.CompactFunc(decks, func(a, b models.Deck) bool { return a.ID == b.ID })
+
+		total = int64(len(decks))
+		if paged {
+			decks = decks[min(offset, len(decks)):min(offset+pageSize, len(decks))]
+		}
+	} else {
+		if paged {
+			if err := query.Model(&models.Deck{}).Session(&gorm.Session{}).Count(&total).Error; err != nil {
+				c.Error(apperrors.Internal("Failed to retrieve decks", err))
+				return
+			}
+			query = query.Offset(offset).Limit(pageSize)
+		}
+		if err := query.Order("id ASC").Find(&decks).Error; err != nil {
+			c.Error(apperrors.Internal("Failed to retrieve decks", err))
+			return
+		}
+	}
+
+	if !paged {
+		c.JSON(http.StatusOK, gin.H{
+			"decks": decks,
+		})
 		return
 	}
 
+	setPaginationHeaders(c, page, pageSize, total)
+
 	c.JSON(http.StatusOK, gin.H{
-		"decks": decks,
+		"decks":     decks,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
 	})
 }
 
+// publicDecks -> Public deck listing, served from the cache when available
+func (h *DeckHandler) publicDecks(ctx context.Context, category string) ([]models.Deck, error) {
+	category = strings.ToLower(cleanCategory(category))
+	key := publicDecksCachePrefix + category
+
+	var decks []models.Deck
+	if h.cache.Get(ctx, key, &decks) {
+		return decks, nil
+	}
+
+	query := h.db.Where("is_public = ?", true)
+	if category != "" {
+		query = query.Where("LOWER(category) = ?", category)
+	}
+	if err := query.Find(&decks).Error; err != nil {
+		return nil, err
+	}
+
+	h.cache.Set(ctx, key, decks)
+	return decks, nil
+}
+
 // GetDeckByID -> Handler to get a specific deck
 func (h *DeckHandler) GetDeckByID(c *gin.Context) {
 	deckID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deck ID"})
+		c.Error(apperrors.BadRequest("Invalid deck ID"))
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
+	// Only public decks are cached, so a hit is viewable by anyone
 	var deck models.Deck
+	if h.cache.Get(c.Request.Context(), deckCacheKey(uint(deckID)), &deck) {
+		jsonWithETag(c, gin.H{
+			"deck": deck,
+		})
+		return
+	}
+
 	// Get the deck with its flashcards
 	if err := h.db.Preload("FlashCards").First(&deck, deckID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+		c.Error(apperrors.NotFound("Deck not found"))
 		return
 	}
 
 	// Check if user has permission to view this deck
-	if !deck.IsPublic && deck.UserID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to view this deck"})
+	allowed, err := canViewDeck(h.db, deck, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to view this deck"))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	if deck.IsPublic {
+		h.cache.Set(c.Request.Context(), deckCacheKey(deck.ID), deck)
+	}
+
+	jsonWithETag(c, gin.H{
 		"deck": deck,
 	})
 }
 
+// GetUnstudiedCardsRequest -> Query parameters for the never-studied cards of a deck
+type GetUnstudiedCardsRequest struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// GetUnstudiedCards -> Handler to get a page of the deck's cards the user has never studied,
+// i.e. that have no progress record for them
+func (h *DeckHandler) GetUnstudiedCards(c *gin.Context) {
+	var req GetUnstudiedCardsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	deckID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid deck ID"))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var deck models.Deck
+	if err := h.db.First(&deck, deckID).Error; err != nil {
+		c.Error(apperrors.NotFound("Deck not found"))
+		return
+	}
+
+	allowed, err := canViewDeck(h.db, deck, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to view this deck"))
+		return
+	}
+
+	page := req.Page
+	if page == 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize == 0 {
+		pageSize = 20
+	}
+
+	// Reset progress is soft-deleted, so only live records count as studied
+	query := h.db.Model(&models.FlashCard{}).
+		Where("deck_id = ?", deckID).
+		Where("NOT EXISTS (SELECT 1 FROM card_progresses WHERE card_progresses.card_id = flash_cards.id AND card_progresses.user_id = ? AND card_progresses.deleted_at IS NULL)", userID)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve flashcards", err))
+		return
+	}
+
+	var cards []models.FlashCard
+	if err := query.Order("id ASC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&cards).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve flashcards", err))
+		return
+	}
+
+	setPaginationHeaders(c, page, pageSize, total)
+
+	c.JSON(http.StatusOK, gin.H{
+		"cards":     cards,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
 // UpdateDeckRequest -> Struct for deck update request
 type UpdateDeckRequest struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Category    string `json:"category"`
-	IsPublic    *bool  `json:"is_public"` // Pointer to differentiate between false and not provided
+	Title         string `json:"title"`
+	Description   string `json:"description"`
+	Category      string `json:"category"`
+	IsPublic      *bool  `json:"is_public"`                                                     // Pointer to differentiate between false and not provided
+	LearningSteps *[]int `json:"learning_steps" binding:"omitempty,max=10,dive,min=1,max=1440"` // Pointer so an empty list can turn the steps off
+
+	AnswerMatch *answermatch.Config `json:"answer_match"` // An empty mode goes back to each user's own match mode
 }
 
 // UpdateDeck -> Handler to update a deck
 func (h *DeckHandler) UpdateDeck(c *gin.Context) {
 	deckID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deck ID"})
+		c.Error(apperrors.BadRequest("Invalid deck ID"))
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
 	var deck models.Deck
 	if err := h.db.First(&deck, deckID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+		c.Error(apperrors.NotFound("Deck not found"))
 		return
 	}
 
 	// Check if user owns this deck
 	if deck.UserID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to update this deck"})
+		c.Error(apperrors.Forbidden("You don't have permission to update this deck"))
 		return
 	}
 
 	var req UpdateDeckRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperrors.Validation(err))
 		return
 	}
 
@@ -180,57 +430,578 @@ func (h *DeckHandler) UpdateDeck(c *gin.Context) {
 		deck.Description = req.Description
 	}
 	if req.Category != "" {
-		deck.Category = req.Category
+		category, err := canonicalCategory(h.db, userID.(uint), req.Category)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to update deck", err))
+			return
+		}
+		deck.Category = category
 	}
 	if req.IsPublic != nil {
 		deck.IsPublic = *req.IsPublic
 	}
+	if req.LearningSteps != nil {
+		deck.LearningSteps = *req.LearningSteps
+	}
+	if req.AnswerMatch != nil {
+		if err := req.AnswerMatch.Validate(); err != nil {
+			c.Error(apperrors.BadRequest(err.Error()))
+			return
+		}
+		deck.AnswerMatch = *req.AnswerMatch
+	}
 
 	// Save updated deck
 	if err := h.db.Save(&deck).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update deck"})
+		c.Error(apperrors.Internal("Failed to update deck", err))
 		return
 	}
 
+	invalidateDeckCache(c.Request.Context(), h.cache, deck.ID)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Deck updated successfully",
 		"deck":    deck,
 	})
 }
 
+// trashDeck -> Moves the deck and its cards to the trash. They share one deletion time, which is
+// how a restore tells them apart from cards that were deleted on their own earlier. removedByAdmin
+// marks an admin's takedown, which the owner can't restore
+func trashDeck(db *gorm.DB, deck models.Deck, removedByAdmin bool) error {
+	deletedAt := time.Now()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.FlashCard{}).Where("deck_id = ?", deck.ID).UpdateColumn("deleted_at", deletedAt).Error; err != nil {
+			return err
+		}
+		return tx.Model(&deck).UpdateColumns(map[string]any{"deleted_at": deletedAt, "removed_by_admin": removedByAdmin}).Error
+	})
+}
+
 // DeleteDeck -> Handler to delete a deck
 func (h *DeckHandler) DeleteDeck(c *gin.Context) {
 	deckID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deck ID"})
+		c.Error(apperrors.BadRequest("Invalid deck ID"))
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
 	var deck models.Deck
 	if err := h.db.First(&deck, deckID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+		c.Error(apperrors.NotFound("Deck not found"))
 		return
 	}
 
 	// Check if user owns this deck
 	if deck.UserID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to delete this deck"})
+		c.Error(apperrors.Forbidden("You don't have permission to delete this deck"))
 		return
 	}
 
-	// Delete the deck (GORM will handle the cascade deletion of related cards if set up properly)
-	if err := h.db.Delete(&deck).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete deck"})
+	if err := trashDeck(h.db, deck, false); err != nil {
+		c.Error(apperrors.Internal("Failed to delete deck", err))
+		return
+	}
+
+	invalidateDeckCache(c.Request.Context(), h.cache, deck.ID)
+
+	if err := stats.RecomputeDueCounts(h.db, userID.(uint)); err != nil {
+		log.Printf("Failed to recompute due counts for user %d: %v", userID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Deck moved to trash",
+	})
+}
+
+// GetDeckTrash -> Handler to list the user's deleted decks, most recently deleted first
+func (h *DeckHandler) GetDeckTrash(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var decks []models.Deck
+	if err := h.db.Unscoped().
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at DESC").
+		Find(&decks).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve deleted decks", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"decks": decks,
+	})
+}
+
+// loadTrashedDeck -> Loads the deck from the :id param, which must be in the user's trash
+func (h *DeckHandler) loadTrashedDeck(c *gin.Context, userID uint) (models.Deck, bool) {
+	var deck models.Deck
+
+	deckID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid deck ID"))
+		return deck, false
+	}
+
+	if err := h.db.Unscoped().Where("deleted_at IS NOT NULL").First(&deck, deckID).Error; err != nil {
+		c.Error(apperrors.NotFound("Deck not found in trash"))
+		return deck, false
+	}
+
+	if deck.UserID != userID {
+		c.Error(apperrors.Forbidden("You don't have permission to modify this deck"))
+		return deck, false
+	}
+	return deck, true
+}
+
+// RestoreDeck -> Handler to bring a deck back from the trash along with the cards deleted with it
+func (h *DeckHandler) RestoreDeck(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	deck, ok := h.loadTrashedDeck(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	if deck.RemovedByAdmin {
+		c.Error(apperrors.Forbidden("This deck was removed by an admin and can't be restored"))
+		return
+	}
+
+	tx := h.db.Begin()
+
+	restored := tx.Unscoped().Model(&models.FlashCard{}).
+		Where("deck_id = ? AND deleted_at = ?", deck.ID, deck.DeletedAt.Time).
+		UpdateColumn("deleted_at", nil)
+	if restored.Error != nil {
+		tx.Rollback()
+		c.Error(apperrors.Internal("Failed to restore deck", restored.Error))
 		return
 	}
 
+	if err := tx.Unscoped().Model(&deck).UpdateColumn("deleted_at", nil).Error; err != nil {
+		tx.Rollback()
+		c.Error(apperrors.Internal("Failed to restore deck", err))
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Error(apperrors.Internal("Failed to restore deck", err))
+		return
+	}
+
+	if deck.IsPublic {
+		invalidateDeckCache(c.Request.Context(), h.cache)
+	}
+
+	if err := stats.RecomputeDueCounts(h.db, userID.(uint)); err != nil {
+		log.Printf("Failed to recompute due counts for user %d: %v", userID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Deck restored successfully",
+		"deck":           deck,
+		"cards_restored": restored.RowsAffected,
+	})
+}
+
+// purgeDeckQuizzes -> Takes a purged deck out of every quiz it contributed to. Quizzes left
+// without any of their decks are deleted with their questions, invites and idempotency keys. The
+// others, multi-deck quizzes with a deck that still exists (live or in the trash), only lose the
+// deck's questions and have their totals and score recomputed from the rest
+func purgeDeckQuizzes(tx *gorm.DB, deckID uint, cardIDs *gorm.DB) error {
+	var quizzes []models.Quiz
+	if err := tx.Unscoped().
+		Where("deck_id = ? OR id IN (?)", deckID, tx.Unscoped().Model(&models.QuizQuestion{}).Select("quiz_id").Where("card_id IN (?)", cardIDs)).
+		Find(&quizzes).Error; err != nil {
+		return err
+	}
+
+	var doomed []uint
+	for _, quiz := range quizzes {
+		deckIDs := quiz.DeckIDs
+		if len(deckIDs) == 0 {
+			deckIDs = []uint{quiz.DeckID}
+		}
+		others := slices.DeleteFunc(slices.Clone(deckIDs), func(id uint) bool { return id == deckID })
+
+		var remaining []uint
+		if len(others) > 0 {
+			if err := tx.Unscoped().Model(&models.Deck{}).Where("id IN ?", others).Pluck("id", &remaining).Error; err != nil {
+				return err
+			}
+		}
+		if len(remaining) == 0 {
+			doomed = append(doomed, quiz.ID)
+			continue
+		}
+
+		if err := tx.Unscoped().Where("quiz_id = ? AND card_id IN (?)", quiz.ID, cardIDs).Delete(&models.QuizQuestion{}).Error; err != nil {
+			return err
+		}
+
+		var total, correct int64
+		questions := tx.Model(&models.QuizQuestion{}).Where("quiz_id = ?", quiz.ID).Session(&gorm.Session{})
+		if err := questions.Count(&total).Error; err != nil {
+			return err
+		}
+		if err := questions.Where("is_correct = ?", true).Count(&correct).Error; err != nil {
+			return err
+		}
+
+		quiz.TotalQuestions = int(total)
+		quiz.CorrectAnswers = int(correct)
+		quiz.DeckIDs = others
+		if quiz.DeckID == deckID {
+			quiz.DeckID = others[0]
+		}
+		// Scored like CompleteQuiz, unfinished quizzes get theirs when they're completed
+		if quiz.CompletedAt != nil {
+			quiz.Score = 0
+			if total > 0 {
+				quiz.Score = float64(correct) / float64(total) * 100
+			}
+		}
+		if err := tx.Unscoped().Model(&quiz).
+			Select("total_questions", "correct_answers", "score", "deck_id", "deck_ids").
+			Updates(&quiz).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(doomed) == 0 {
+		return nil
+	}
+	if err := tx.Unscoped().Where("quiz_id IN ?", doomed).Delete(&models.QuizQuestion{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("quiz_id IN ?", doomed).Delete(&models.QuizInvite{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("quiz_id IN ?", doomed).Delete(&models.QuizIdempotencyKey{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("id IN ?", doomed).Delete(&models.Quiz{}).Error
+}
+
+// PurgeDeck -> Handler to permanently delete a deck that's already in the trash, with its cards,
+// everyone's progress and review history on them, and its part of the quizzes made from it (see
+// purgeDeckQuizzes)
+func (h *DeckHandler) PurgeDeck(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	deck, ok := h.loadTrashedDeck(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	cardIDs := h.db.Unscoped().Model(&models.FlashCard{}).Select("id").Where("deck_id = ?", deck.ID)
+
+	tx := h.db.Begin()
+
+	// Children first, the deck itself last
+	steps := []func() error{
+		func() error {
+			return tx.Unscoped().Where("card_id IN (?)", cardIDs).Delete(&models.CardProgress{}).Error
+		},
+		func() error { return tx.Unscoped().Where("card_id IN (?)", cardIDs).Delete(&models.ReviewLog{}).Error },
+		func() error { return purgeDeckQuizzes(tx, deck.ID, cardIDs) },
+		func() error { return tx.Unscoped().Where("deck_id = ?", deck.ID).Delete(&models.StudyGoal{}).Error },
+		func() error { return tx.Where("deck_id = ?", deck.ID).Delete(&models.DeckDueCount{}).Error },
+		func() error {
+			return tx.Unscoped().Where("deck_id = ?", deck.ID).Delete(&models.DeckCollaborator{}).Error
+		},
+		func() error { return tx.Where("card_id IN (?)", cardIDs).Delete(&models.CardTag{}).Error },
+		func() error { return tx.Unscoped().Where("card_id IN (?)", cardIDs).Delete(&models.CardNote{}).Error },
+		func() error { return tx.Unscoped().Where("deck_id = ?", deck.ID).Delete(&models.FlashCard{}).Error },
+		func() error { return tx.Unscoped().Delete(&deck).Error },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			tx.Rollback()
+			c.Error(apperrors.Internal("Failed to permanently delete deck", err))
+			return
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Error(apperrors.Internal("Failed to permanently delete deck", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Deck permanently deleted",
+	})
+}
+
+// deckExportSchemaVersion -> Bump whenever the export format changes so old documents are detectable
+const deckExportSchemaVersion = 1
+
+// DeckExport -> Self-contained JSON document for backing up and sharing a deck
+type DeckExport struct {
+	SchemaVersion int              `json:"schema_version" binding:"required"`
+	ExportedAt    time.Time        `json:"exported_at"`
+	Deck          DeckExportMeta   `json:"deck" binding:"required"`
+	Cards         []DeckExportCard `json:"cards" binding:"dive"`
+}
+
+// DeckExportMeta -> Deck metadata included in an export
+type DeckExportMeta struct {
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	IsPublic    bool   `json:"is_public"`
+
+	AnswerMatch *answermatch.Config `json:"answer_match,omitempty"`
+}
+
+// DeckExportCard -> Card content included in an export (no user progress)
+type DeckExportCard struct {
+	FrontContent      string   `json:"front_content" binding:"required"`
+	BackContent       string   `json:"back_content" binding:"required"`
+	AcceptableAnswers []string `json:"acceptable_answers,omitempty" binding:"omitempty,max=20,dive,max=500"`
+	Explanation       string   `json:"explanation,omitempty" binding:"max=5000"`
+	ContentType       string   `json:"content_type" binding:"omitempty,content_type"`
+	DifficultyLevel   float64  `json:"difficulty_level" binding:"omitempty,min=0,max=1"`
+}
+
+// buildDeckExport -> Converts a deck and its cards into the export document
+func buildDeckExport(deck models.Deck, cards []models.FlashCard) DeckExport {
+	export := DeckExport{
+		SchemaVersion: deckExportSchemaVersion,
+		ExportedAt:    time.Now(),
+		Deck: DeckExportMeta{
+			Title:       deck.Title,
+			Description: deck.Description,
+			Category:    deck.Category,
+			IsPublic:    deck.IsPublic,
+		},
+		Cards: make([]DeckExportCard, 0, len(cards)),
+	}
+	if deck.AnswerMatch.IsSet() {
+		export.Deck.AnswerMatch = &deck.AnswerMatch
+	}
+
+	for _, card := range cards {
+		export.Cards = append(export.Cards, DeckExportCard{
+			FrontContent:      card.FrontContent,
+			BackContent:       card.BackContent,
+			AcceptableAnswers: card.AcceptableAnswers,
+			Explanation:       card.Explanation,
+			ContentType:       card.ContentType,
+			DifficultyLevel:   card.DifficultyLevel,
+		})
+	}
+
+	return export
+}
+
+// ExportDeck -> Handler to export a deck and its cards as JSON
+func (h *DeckHandler) ExportDeck(c *gin.Context) {
+	deckID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid deck ID"))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var deck models.Deck
+	if err := h.db.Preload("FlashCards").First(&deck, deckID).Error; err != nil {
+		c.Error(apperrors.NotFound("Deck not found"))
+		return
+	}
+
+	// Check if user has permission to view this deck
+	allowed, err := canViewDeck(h.db, deck, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to export this deck"))
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"deck-%d.json\"", deck.ID))
+	c.JSON(http.StatusOK, buildDeckExport(deck, deck.FlashCards))
+}
+
+// ImportDeck -> Handler to recreate a deck and its cards from an export document
+func (h *DeckHandler) ImportDeck(c *gin.Context) {
+	var req DeckExport
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	// Reject documents written by a different version of the export format
+	if req.SchemaVersion != deckExportSchemaVersion {
+		c.Error(apperrors.BadRequest(fmt.Sprintf("Unsupported schema version %d, expected %d", req.SchemaVersion, deckExportSchemaVersion)))
+		return
+	}
+
+	var answerMatch answermatch.Config
+	if req.Deck.AnswerMatch != nil {
+		if err := req.Deck.AnswerMatch.Validate(); err != nil {
+			c.Error(apperrors.BadRequest(err.Error()))
+			return
+		}
+		answerMatch = *req.Deck.AnswerMatch
+	}
+
+	category, err := canonicalCategory(h.db, userID.(uint), req.Deck.Category)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to create deck", err))
+		return
+	}
+
+	// Begin a transaction so a failed import doesn't leave a partial deck
+	tx := h.db.Begin()
+
+	deck := models.Deck{
+		Title:       req.Deck.Title,
+		Description: req.Deck.Description,
+		Category:    category,
+		IsPublic:    req.Deck.IsPublic,
+		AnswerMatch: answerMatch,
+		CardCount:   len(req.Cards),
+		UserID:      userID.(uint),
+	}
+
+	if err := tx.Create(&deck).Error; err != nil {
+		tx.Rollback()
+		c.Error(apperrors.Internal("Failed to create deck", err))
+		return
+	}
+
+	for _, entry := range req.Cards {
+		contentType := entry.ContentType
+		if contentType == "" {
+			contentType = models.ContentText
+		}
+
+		difficultyLevel := entry.DifficultyLevel
+		if difficultyLevel == 0 {
+			difficultyLevel = 0.5 // default difficulty
+		}
+
+		card := models.FlashCard{
+			DeckID:            deck.ID,
+			FrontContent:      entry.FrontContent,
+			BackContent:       entry.BackContent,
+			AcceptableAnswers: cleanAcceptableAnswers(entry.AcceptableAnswers, entry.BackContent),
+			Explanation:       entry.Explanation,
+			ContentType:       contentType,
+			DifficultyLevel:   difficultyLevel,
+		}
+
+		if err := tx.Create(&card).Error; err != nil {
+			tx.Rollback()
+			c.Error(apperrors.Internal("Failed to import cards", err))
+			return
+		}
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		c.Error(apperrors.Internal("Failed to process import", err))
+		return
+	}
+
+	if deck.IsPublic {
+		invalidateDeckCache(c.Request.Context(), h.cache)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Deck imported successfully",
+		"deck":     deck,
+		"imported": len(req.Cards),
+	})
+}
+
+// ResetDeckProgress -> Handler to restart a deck from scratch for the calling user. Only their
+// progress records are removed, the cards stay and every card is new again
+func (h *DeckHandler) ResetDeckProgress(c *gin.Context) {
+	deckID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid deck ID"))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var deck models.Deck
+	if err := h.db.First(&deck, deckID).Error; err != nil {
+		c.Error(apperrors.NotFound("Deck not found"))
+		return
+	}
+
+	allowed, err := canViewDeck(h.db, deck, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to reset progress on this deck"))
+		return
+	}
+
+	cardIDs := h.db.Model(&models.FlashCard{}).Select("id").Where("deck_id = ?", deck.ID)
+
+	// Begin a transaction so the reset is all or nothing
+	tx := h.db.Begin()
+
+	result := tx.Where("user_id = ? AND card_id IN (?)", userID, cardIDs).Delete(&models.CardProgress{})
+	if result.Error != nil {
+		tx.Rollback()
+		c.Error(apperrors.Internal("Failed to reset deck progress", result.Error))
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Error(apperrors.Internal("Failed to reset deck progress", err))
+		return
+	}
+
+	if err := stats.RecomputeDueCounts(h.db, userID.(uint)); err != nil {
+		log.Printf("Failed to recompute due counts for user %d: %v", userID, err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Deck deleted successfully",
+		"message":     "Deck progress reset successfully",
+		"cards_reset": result.RowsAffected,
 	})
 }
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// Levels of access a user can have to a deck, each allowing everything the ones before it do
+const (
+	deckAccessNone  = iota
+	deckAccessView  // Read and study the cards, quiz on them
+	deckAccessEdit  // Add, edit and delete cards
+	deckAccessOwner // Change, share and delete the deck itself
+)
+
+// deckAccess -> The user's access to the deck: owners have full access, collaborators what their
+// role grants, and everyone can view public decks
+func deckAccess(db *gorm.DB, deck models.Deck, userID uint) (int, error) {
+	if deck.UserID == userID {
+		return deckAccessOwner, nil
+	}
+
+	var collaborator models.DeckCollaborator
+	err := db.Where("deck_id = ? AND user_id = ?", deck.ID, userID).First(&collaborator).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return deckAccessNone, err
+	}
+
+	switch {
+	case err == nil && collaborator.Role == models.CollaboratorEditor:
+		return deckAccessEdit, nil
+	case err == nil || deck.IsPublic:
+		return deckAccessView, nil
+	default:
+		return deckAccessNone, nil
+	}
+}
+
+// canViewDeck -> Whether the user may read and study the deck
+func canViewDeck(db *gorm.DB, deck models.Deck, userID uint) (bool, error) {
+	if deck.IsPublic || deck.UserID == userID {
+		return true, nil
+	}
+	access, err := deckAccess(db, deck, userID)
+	return access >= deckAccessView, err
+}
+
+// canEditDeck -> Whether the user may change the deck's cards
+func canEditDeck(db *gorm.DB, deck models.Deck, userID uint) (bool, error) {
+	access, err := deckAccess(db, deck, userID)
+	return access >= deckAccessEdit, err
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/models"
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ExportAllDecks -> Handler to download every deck the caller owns as a zip with one export
+// document per deck, in the same format as the single-deck export so each file can be imported
+// on its own. Progress isn't included, ExportUserData covers that. The archive is written as it's
+// built, one deck at a time, so only a single deck's cards are ever held in memory
+func (h *UserHandler) ExportAllDecks(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"user-%d-decks.zip\"", userID))
+	c.Status(http.StatusOK)
+
+	// Once writing has started errors can only be logged, and the truncated archive won't open
+	zw := zip.NewWriter(c.Writer)
+	var decks []models.Deck
+	err := h.db.Where("user_id = ?", userID).Order("id ASC").FindInBatches(&decks, exportBatchSize, func(tx *gorm.DB, _ int) error {
+		for _, deck := range decks {
+			var cards []models.FlashCard
+			if err := h.db.Where("deck_id = ?", deck.ID).Order("id ASC").Find(&cards).Error; err != nil {
+				return err
+			}
+
+			file, err := zw.Create(fmt.Sprintf("deck-%d.json", deck.ID))
+			if err != nil {
+				return err
+			}
+			if err := json.NewEncoder(file).Encode(buildDeckExport(deck, cards)); err != nil {
+				return err
+			}
+			if err := zw.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	}).Error
+	if err == nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		log.Printf("Deck archive for user %d cut short: %v", userID, err)
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/models"
+	"FlashQuiz/internal/stats"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// MergeDecksRequest -> Struct for folding one of the user's decks into another
+type MergeDecksRequest struct {
+	SourceDeckID   uint `json:"source_deck_id" binding:"required"`
+	TargetDeckID   uint `json:"target_deck_id" binding:"required"`
+	SkipDuplicates bool `json:"skip_duplicates"` // Leave behind source cards whose front the target already has
+}
+
+// MergeDecks -> Handler to move every card of one deck the user owns into another, then move the
+// emptied source deck to the trash. Cards skipped as duplicates go to the trash with it, so
+// restoring the source brings them back. Progress stays with the cards, so nothing studied is lost
+func (h *DeckHandler) MergeDecks(c *gin.Context) {
+	var req MergeDecksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	if req.SourceDeckID == req.TargetDeckID {
+		c.Error(apperrors.BadRequest("Can't merge a deck into itself"))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	// Merging deletes the source, so only the owner of both decks may do it
+	var decks []models.Deck
+	if err := h.db.Where("id IN ? AND user_id = ?", []uint{req.SourceDeckID, req.TargetDeckID}, userID).Find(&decks).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve decks", err))
+		return
+	}
+	if len(decks) != 2 {
+		c.Error(apperrors.NotFound("Deck not found or you don't own both decks"))
+		return
+	}
+
+	var moved, skipped int
+	err := withRetry(h.db, func(tx *gorm.DB) error {
+		var cards []models.FlashCard
+		if err := tx.Select("id", "front_content").Where("deck_id = ?", req.SourceDeckID).Order("id ASC").Find(&cards).Error; err != nil {
+			return apperrors.Internal("Failed to retrieve flashcards", err)
+		}
+
+		var seen map[string]bool
+		if req.SkipDuplicates {
+			var err error
+			if seen, err = deckFrontContents(tx, req.TargetDeckID); err != nil {
+				return apperrors.Internal("Failed to check for duplicate cards", err)
+			}
+		}
+
+		moveIDs := make([]uint, 0, len(cards))
+		for _, card := range cards {
+			if req.SkipDuplicates {
+				// Duplicates within the source itself are caught too
+				key := normalizeContent(card.FrontContent)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+			}
+			moveIDs = append(moveIDs, card.ID)
+		}
+		moved, skipped = len(moveIDs), len(cards)-len(moveIDs)
+
+		if moved > 0 {
+			if err := tx.Model(&models.FlashCard{}).Where("id IN ?", moveIDs).Update("deck_id", req.TargetDeckID).Error; err != nil {
+				return apperrors.Internal("Failed to move cards", err)
+			}
+		}
+		if err := tx.Model(&models.Deck{}).Where("id = ?", req.TargetDeckID).
+			Update("card_count", gorm.Expr("card_count + ?", moved)).Error; err != nil {
+			return apperrors.Internal("Failed to update deck card count", err)
+		}
+
+		// Trash the source with whatever was left in it, sharing one deletion time like DeleteDeck
+		deletedAt := time.Now()
+		if err := tx.Model(&models.FlashCard{}).Where("deck_id = ?", req.SourceDeckID).UpdateColumn("deleted_at", deletedAt).Error; err != nil {
+			return apperrors.Internal("Failed to delete source deck", err)
+		}
+		if err := tx.Model(&models.Deck{}).Where("id = ?", req.SourceDeckID).
+			UpdateColumns(map[string]any{"card_count": skipped, "deleted_at": deletedAt}).Error; err != nil {
+			return apperrors.Internal("Failed to delete source deck", err)
+		}
+		return nil
+	})
+	if err != nil {
+		c.Error(txError(err, "Failed to merge decks"))
+		return
+	}
+
+	invalidateDeckCache(c.Request.Context(), h.cache, req.SourceDeckID, req.TargetDeckID)
+
+	// Due cards now count toward the target deck
+	if err := stats.RecomputeDueCounts(h.db, userID.(uint)); err != nil {
+		log.Printf("Failed to recompute due counts for user %d: %v", userID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Decks merged successfully",
+		"moved":          moved,
+		"skipped":        skipped,
+		"source_deck_id": req.SourceDeckID,
+		"target_deck_id": req.TargetDeckID,
+	})
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPreviewCards -> Most cards an anonymous preview shows, enough to judge a deck but too few to
+// make scraping it through previews worthwhile
+const maxPreviewCards = 10
+
+// PreviewDeckRequest -> Query parameters for previewing a public deck
+type PreviewDeckRequest struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=10"` // Defaults to 5, at most maxPreviewCards
+}
+
+// PreviewPublicDeck -> Handler to show the first few cards of a public deck to anyone, signed in or
+// not. Private, missing and deleted decks all answer 404 so a preview doesn't reveal which exist.
+// Only card content is returned, never owners, progress or explanations
+func (h *DeckHandler) PreviewPublicDeck(c *gin.Context) {
+	deckID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid deck ID"))
+		return
+	}
+
+	var req PreviewDeckRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = 5
+	}
+
+	var deck models.Deck
+	if err := h.db.Where("id = ? AND is_public = ?", deckID, true).First(&deck).Error; err != nil {
+		c.Error(apperrors.NotFound("Deck not found"))
+		return
+	}
+
+	var cards []models.FlashCard
+	if err := h.db.Where("deck_id = ?", deck.ID).Order("id ASC").Limit(min(limit, maxPreviewCards)).Find(&cards).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve flashcards", err))
+		return
+	}
+
+	previewCards := make([]gin.H, 0, len(cards))
+	for _, card := range cards {
+		previewCards = append(previewCards, gin.H{
+			"front_content": card.FrontContent,
+			"back_content":  card.BackContent,
+			"content_type":  card.ContentType,
+			"front_html":    card.FrontHTML,
+			"back_html":     card.BackHTML,
+		})
+	}
+
+	// Anonymous and identical for everyone, so shared caches may keep it for a while
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{
+		"deck": gin.H{
+			"id":          deck.ID,
+			"title":       deck.Title,
+			"description": deck.Description,
+			"category":    deck.Category,
+			"card_count":  deck.CardCount,
+		},
+		"cards": previewCards,
+	})
+}
@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPurgeDeckQuizzes(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice")
+	purged := createTestDeck(t, db, user.ID, "Capitals", "France", "Spain")
+	kept := createTestDeck(t, db, user.ID, "Rivers", "Longest river")
+
+	var purgedCards, keptCards []models.FlashCard
+	db.Where("deck_id = ?", purged.ID).Order("id").Find(&purgedCards)
+	db.Where("deck_id = ?", kept.ID).Find(&keptCards)
+
+	// Only drawn from the purged deck
+	single := models.Quiz{UserID: user.ID, DeckID: purged.ID, DeckIDs: []uint{purged.ID}, Title: "Capitals", TotalQuestions: 2}
+	// Drawn from both, completed with the purged deck's question missed
+	completedAt := time.Now()
+	multi := models.Quiz{UserID: user.ID, DeckID: purged.ID, DeckIDs: []uint{purged.ID, kept.ID}, Title: "Mixed",
+		TotalQuestions: 2, CorrectAnswers: 1, Score: 50, CompletedAt: &completedAt}
+	for _, quiz := range []*models.Quiz{&single, &multi} {
+		if err := db.Create(quiz).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	questions := []models.QuizQuestion{
+		{QuizID: single.ID, CardID: purgedCards[0].ID, DeckID: purged.ID},
+		{QuizID: single.ID, CardID: purgedCards[1].ID, DeckID: purged.ID},
+		{QuizID: multi.ID, CardID: purgedCards[0].ID, DeckID: purged.ID, IsCorrect: false},
+		{QuizID: multi.ID, CardID: keptCards[0].ID, DeckID: kept.ID, IsCorrect: true},
+	}
+	if err := db.Create(&questions).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.QuizIdempotencyKey{UserID: user.ID, Key: "create-1", QuizID: single.ID}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Purging only works from the trash
+	if err := db.Delete(&purged).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestRouter(user.ID)
+	r.DELETE("/decks/:id/permanent", NewDeckHandler(db, nil).PurgeDeck)
+	if code, out := doJSON(t, r, http.MethodDelete, fmt.Sprintf("/decks/%d/permanent", purged.ID), nil); code != http.StatusOK {
+		t.Fatalf("status = %d: %v", code, out)
+	}
+
+	t.Run("quiz without any deck left is deleted", func(t *testing.T) {
+		var count int64
+		db.Unscoped().Model(&models.Quiz{}).Where("id = ?", single.ID).Count(&count)
+		if count != 0 {
+			t.Error("single-deck quiz still exists")
+		}
+		db.Unscoped().Model(&models.QuizQuestion{}).Where("quiz_id = ?", single.ID).Count(&count)
+		if count != 0 {
+			t.Errorf("%d questions of the deleted quiz are left", count)
+		}
+		db.Unscoped().Model(&models.QuizIdempotencyKey{}).Where("quiz_id = ?", single.ID).Count(&count)
+		if count != 0 {
+			t.Error("idempotency key of the deleted quiz is left")
+		}
+	})
+
+	t.Run("quiz with another deck keeps the rest", func(t *testing.T) {
+		var quiz models.Quiz
+		if err := db.First(&quiz, multi.ID).Error; err != nil {
+			t.Fatalf("multi-deck quiz was deleted: %v", err)
+		}
+		if quiz.TotalQuestions != 1 || quiz.CorrectAnswers != 1 || quiz.Score != 100 {
+			t.Errorf("totals = %d questions, %d correct, score %v; want 1, 1, 100", quiz.TotalQuestions, quiz.CorrectAnswers, quiz.Score)
+		}
+		if quiz.DeckID != kept.ID || !slices.Equal(quiz.DeckIDs, []uint{kept.ID}) {
+			t.Errorf("decks = %d %v, want only %d", quiz.DeckID, quiz.DeckIDs, kept.ID)
+		}
+
+		var cardIDs []uint
+		db.Unscoped().Model(&models.QuizQuestion{}).Where("quiz_id = ?", multi.ID).Pluck("card_id", &cardIDs)
+		if !slices.Equal(cardIDs, []uint{keptCards[0].ID}) {
+			t.Errorf("question cards = %v, want only %d", cardIDs, keptCards[0].ID)
+		}
+	})
+}
+
+func TestGetDecksSharedWithFilters(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+
+	decks := []*models.Deck{
+		{UserID: alice.ID, Title: "Own geography", Category: "Geography"},
+		{UserID: alice.ID, Title: "Own history", Category: "History"},
+		{UserID: bob.ID, Title: "Shared geography", Category: "Geography", IsPublic: true},
+		{UserID: bob.ID, Title: "Shared history", Category: "History"},
+		{UserID: bob.ID, Title: "Unshared geography", Category: "Geography"},
+	}
+	for _, deck := range decks {
+		if err := db.Create(deck).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, deck := range decks[2:4] {
+		if err := db.Create(&models.DeckCollaborator{DeckID: deck.ID, UserID: alice.ID, Role: "viewer"}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := newTestRouter(alice.ID)
+	r.GET("/decks", NewDeckHandler(db, nil).GetDecks)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"include_shared=true", []string{"Own geography", "Own history", "Shared geography", "Shared history"}},
+		{"include_shared=true&category=geography", []string{"Own geography", "Shared geography"}},
+		// The shared deck is also public, it must only be listed once
+		{"include_shared=true&include_public=true&category=geography", []string{"Own geography", "Shared geography"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			code, out := doJSON(t, r, http.MethodGet, "/decks?"+tt.query, nil)
+			if code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %v", code, http.StatusOK, out)
+			}
+			var got []string
+			for _, deck := range out["decks"].([]any) {
+				got = append(got, deck.(map[string]any)["title"].(string))
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("decks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDecksPagination(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	// More than a default page, so a list cut short would show
+	var own []string
+	for i := range 25 {
+		own = append(own, createTestDeck(t, db, alice.ID, fmt.Sprintf("Own %02d", i)).Title)
+	}
+	if err := db.Create(&models.Deck{UserID: bob.ID, Title: "Public", IsPublic: true}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestRouter(alice.ID)
+	r.GET("/decks", NewDeckHandler(db, nil).GetDecks)
+
+	tests := []struct {
+		query string
+		total string
+		want  []string
+	}{
+		{"", "", own},
+		{"include_public=true", "", append(slices.Clone(own), "Public")},
+		{"page_size=10", "25", own[:10]},
+		{"page=3&page_size=10", "25", own[20:]},
+		{"page=4&page_size=10", "25", nil},
+		{"include_public=true&page=3&page_size=10", "26", append(slices.Clone(own[20:]), "Public")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/decks?"+tt.query, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+			}
+			// Unpaged lists carry no pagination headers
+			if got := w.Header().Get("X-Total-Count"); got != tt.total {
+				t.Errorf("X-Total-Count = %q, want %q", got, tt.total)
+			}
+
+			var out struct {
+				Decks []models.Deck `json:"decks"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, deck := range out.Decks {
+				got = append(got, deck.Title)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("decks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeleteDeckKeepsItsQuizzes(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice")
+	deck := createTestDeck(t, db, user.ID, "Capitals", "France", "Spain")
+
+	decks, quizzes := NewDeckHandler(db, nil), NewQuizHandler(db)
+	r := newTestRouter(user.ID)
+	r.DELETE("/decks/:id", decks.DeleteDeck)
+	r.POST("/decks/:id/restore", decks.RestoreDeck)
+	r.POST("/quizzes", quizzes.CreateQuiz)
+	r.GET("/quizzes/:id", quizzes.GetQuiz)
+
+	code, out := doJSON(t, r, http.MethodPost, "/quizzes", map[string]any{"deck_id": deck.ID, "title": "Capitals"})
+	if code != http.StatusCreated {
+		t.Fatalf("create quiz: status = %d: %v", code, out)
+	}
+	quizPath := fmt.Sprintf("/quizzes/%v", out["quiz"].(map[string]any)["id"])
+
+	if code, out := doJSON(t, r, http.MethodDelete, fmt.Sprintf("/decks/%d", deck.ID), nil); code != http.StatusOK {
+		t.Fatalf("delete deck: status = %d: %v", code, out)
+	}
+
+	code, out = doJSON(t, r, http.MethodGet, quizPath, nil)
+	if code != http.StatusOK {
+		t.Fatalf("quiz of a trashed deck: status = %d, want %d: %v", code, http.StatusOK, out)
+	}
+	quiz := out["quiz"].(map[string]any)
+	if quiz["orphaned"] != true || fmt.Sprint(quiz["missing_deck_ids"]) != fmt.Sprintf("[%d]", deck.ID) {
+		t.Errorf("orphaned = %v, missing_deck_ids = %v, want true and [%d]", quiz["orphaned"], quiz["missing_deck_ids"], deck.ID)
+	}
+	questions := quiz["questions"].([]any)
+	if len(questions) != 2 {
+		t.Fatalf("quiz has %d questions, want 2", len(questions))
+	}
+	for _, question := range questions {
+		if question.(map[string]any)["card_deleted"] != true {
+			t.Errorf("question %v: card_deleted = false, want true", question.(map[string]any)["id"])
+		}
+	}
+
+	// No new quizzes from the trash
+	if code, out := doJSON(t, r, http.MethodPost, "/quizzes", map[string]any{"deck_id": deck.ID, "title": "Again"}); code != http.StatusNotFound {
+		t.Errorf("create quiz from a trashed deck: status = %d, want %d: %v", code, http.StatusNotFound, out)
+	}
+
+	// Restoring the deck makes the quiz whole again
+	if code, out := doJSON(t, r, http.MethodPost, fmt.Sprintf("/decks/%d/restore", deck.ID), nil); code != http.StatusOK {
+		t.Fatalf("restore deck: status = %d: %v", code, out)
+	}
+	if _, out := doJSON(t, r, http.MethodGet, quizPath, nil); out["quiz"].(map[string]any)["orphaned"] != false {
+		t.Errorf("quiz still orphaned after the deck was restored: %v", out)
+	}
+}
+
+func TestAdminRemovedDeckCannotBeRestored(t *testing.T) {
+	db := newTestDB(t)
+	admin := createTestUser(t, db, "moderator")
+	owner := createTestUser(t, db, "alice")
+	deck := createTestDeck(t, db, owner.ID, "Spam", "Buy now", "Click here")
+	if err := db.Model(&deck).Update("is_public", true).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	asAdmin := newTestRouter(admin.ID)
+	asAdmin.DELETE("/admin/decks/:id", NewAdminHandler(db, nil).DeleteDeck)
+	if code, out := doJSON(t, asAdmin, http.MethodDelete, fmt.Sprintf("/admin/decks/%d", deck.ID), nil); code != http.StatusOK {
+		t.Fatalf("admin delete: status = %d: %v", code, out)
+	}
+
+	// The cards go with the deck
+	var liveCards int64
+	if err := db.Model(&models.FlashCard{}).Where("deck_id = ?", deck.ID).Count(&liveCards).Error; err != nil {
+		t.Fatal(err)
+	}
+	if liveCards != 0 {
+		t.Errorf("%d cards still live after the deck was taken down", liveCards)
+	}
+
+	decks := NewDeckHandler(db, nil)
+	asOwner := newTestRouter(owner.ID)
+	asOwner.GET("/decks/trash", decks.GetDeckTrash)
+	asOwner.POST("/decks/:id/restore", decks.RestoreDeck)
+
+	_, out := doJSON(t, asOwner, http.MethodGet, "/decks/trash", nil)
+	trashed := out["decks"].([]any)
+	if len(trashed) != 1 || trashed[0].(map[string]any)["removed_by_admin"] != true {
+		t.Errorf("trash = %v, want the deck marked as removed by an admin", trashed)
+	}
+
+	if code, out := doJSON(t, asOwner, http.MethodPost, fmt.Sprintf("/decks/%d/restore", deck.ID), nil); code != http.StatusForbidden {
+		t.Errorf("owner restore: status = %d, want %d: %v", code, http.StatusForbidden, out)
+	}
+	if err := db.First(&models.Deck{}, deck.ID).Error; err == nil {
+		t.Error("deck is live again after the refused restore")
+	}
+}
+
+func TestDeckExportImportKeepsAcceptableAnswers(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice")
+	deck := createTestDeck(t, db, user.ID, "Capitals", "France")
+	if err := db.Model(&models.FlashCard{}).Where("deck_id = ?", deck.ID).
+		Update("acceptable_answers", `["Paris, France"]`).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewDeckHandler(db, nil)
+	r := newTestRouter(user.ID)
+	r.GET("/decks/:id/export", handler.ExportDeck)
+	r.POST("/decks/import", handler.ImportDeck)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/decks/%d/export", deck.ID), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("export status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	var export DeckExport
+	if err := json.Unmarshal(w.Body.Bytes(), &export); err != nil {
+		t.Fatal(err)
+	}
+	if len(export.Cards) != 1 || !slices.Equal(export.Cards[0].AcceptableAnswers, []string{"Paris, France"}) {
+		t.Fatalf("exported cards = %+v, want the alias carried along", export.Cards)
+	}
+
+	if status, body := doJSON(t, r, http.MethodPost, "/decks/import", export); status != http.StatusCreated {
+		t.Fatalf("import status = %d, want %d: %v", status, http.StatusCreated, body)
+	}
+	var imported models.FlashCard
+	if err := db.Where("deck_id <> ?", deck.ID).First(&imported).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(imported.AcceptableAnswers, []string{"Paris, France"}) {
+		t.Errorf("imported aliases = %q, want %q", imported.AcceptableAnswers, []string{"Paris, France"})
+	}
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jsonWithETag -> Responds with body as JSON tagged with a hash of its bytes, or with an empty 304
+// when the client's If-None-Match already names that hash. Hashing the serialized response means
+// anything that changes what the caller sees (the resource, its cards, their own note) changes the tag
+func jsonWithETag(c *gin.Context, body any) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to encode response", err))
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	// Clients must revalidate before reusing a copy, which is what makes the 304 worth it
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// etagMatches -> Whether an If-None-Match header lists the tag. Weak tags compare equal to strong
+// ones, as the spec asks for If-None-Match
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -1,57 +1,90 @@
 package handlers
 
 import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/cache"
 	"FlashQuiz/internal/models"
+	"FlashQuiz/internal/stats"
+	"fmt"
+	"log"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type CardHandler struct {
-	db *gorm.DB
+	db    *gorm.DB
+	cache *cache.Cache
 }
 
-func NewCardHandler(db *gorm.DB) *CardHandler {
-	return &CardHandler{db: db}
+func NewCardHandler(db *gorm.DB, cache *cache.Cache) *CardHandler {
+	return &CardHandler{db: db, cache: cache}
 }
 
 // CreateCardRequest -> Struct for flashcard creation request
 type CreateCardRequest struct {
-	DeckID          uint    `json:"deck_id" binding:"required"`
-	FrontContent    string  `json:"front_content" binding:"required"`
-	BackContent     string  `json:"back_content" binding:"required"`
-	ContentType     string  `json:"content_type"`
-	DifficultyLevel float64 `json:"difficulty_level"`
+	DeckID            uint     `json:"deck_id" binding:"required"`
+	FrontContent      string   `json:"front_content" binding:"required"`
+	BackContent       string   `json:"back_content" binding:"required"`
+	AcceptableAnswers []string `json:"acceptable_answers" binding:"omitempty,max=20,dive,max=500"` // Other answers to accept in quizzes
+	Explanation       string   `json:"explanation" binding:"max=5000"`
+	ContentType       string   `json:"content_type" binding:"omitempty,content_type"`
+	DifficultyLevel   float64  `json:"difficulty_level" binding:"omitempty,min=0,max=1"`
 }
 
 // CreateCard -> Handler to create a new flashcard
 func (h *CardHandler) CreateCard(c *gin.Context) {
 	var req CreateCardRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperrors.Validation(err))
 		return
 	}
 
 	// Get user ID from context (set by AuthMiddleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
-	// Verify the deck exists and belongs to the user
+	// Verify the deck exists and the user may edit its cards
 	var deck models.Deck
-	if err := h.db.Where("id = ? AND user_id = ?", req.DeckID, userID).First(&deck).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found or you don't have permission to add cards to it"})
+	if err := h.db.First(&deck, req.DeckID).Error; err != nil {
+		c.Error(apperrors.NotFound("Deck not found or you don't have permission to add cards to it"))
+		return
+	}
+	allowed, err := canEditDeck(h.db, deck, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.NotFound("Deck not found or you don't have permission to add cards to it"))
 		return
 	}
 
+	card, ok := h.insertCard(c, deck, req)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Flashcard created successfully",
+		"card":    card,
+	})
+}
+
+// insertCard -> Creates the card described by req in deck, which the caller was checked to be allowed
+// to edit. Writes the error and returns false if it fails
+func (h *CardHandler) insertCard(c *gin.Context, deck models.Deck, req CreateCardRequest) (models.FlashCard, bool) {
 	// Set default values if not provided
 	contentType := req.ContentType
 	if contentType == "" {
-		contentType = "text"
+		contentType = models.ContentText
 	}
 
 	difficultyLevel := req.DifficultyLevel
@@ -59,142 +92,384 @@ func (h *CardHandler) CreateCard(c *gin.Context) {
 		difficultyLevel = 0.5 // default difficulty
 	}
 
-	// Create a new flashcard
-	card := models.FlashCard{
-		DeckID:          req.DeckID,
-		FrontContent:    req.FrontContent,
-		BackContent:     req.BackContent,
-		ContentType:     contentType,
-		DifficultyLevel: difficultyLevel,
-	}
+	// Save the card and the deck's card count together, retrying if the database is busy
+	var card models.FlashCard
+	err := withRetry(h.db, func(tx *gorm.DB) error {
+		card = models.FlashCard{
+			DeckID:            deck.ID,
+			FrontContent:      req.FrontContent,
+			BackContent:       req.BackContent,
+			AcceptableAnswers: cleanAcceptableAnswers(req.AcceptableAnswers, req.BackContent),
+			Explanation:       req.Explanation,
+			ContentType:       contentType,
+			DifficultyLevel:   difficultyLevel,
+		}
 
-	// Save card to database
-	if err := h.db.Create(&card).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create flashcard"})
-		return
-	}
+		if err := tx.Create(&card).Error; err != nil {
+			return apperrors.Internal("Failed to create flashcard", err)
+		}
 
-	// Update card count in the deck
-	if err := h.db.Model(&deck).Update("card_count", deck.CardCount+1).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update deck card count"})
-		return
+		// Update card count in the deck, atomically so concurrent creates don't lose increments
+		if err := tx.Model(&deck).Update("card_count", gorm.Expr("card_count + ?", 1)).Error; err != nil {
+			return apperrors.Internal("Failed to update deck card count", err)
+		}
+		return nil
+	})
+	if err != nil {
+		c.Error(txError(err, "Failed to process changes"))
+		return card, false
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Flashcard created successfully",
-		"card":    card,
-	})
+	invalidateDeckCache(c.Request.Context(), h.cache, deck.ID)
+	return card, true
 }
 
 // GetCardByID -> Handler to get a specific flashcard
 func (h *CardHandler) GetCardByID(c *gin.Context) {
 	cardID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card ID"})
+		c.Error(apperrors.BadRequest("Invalid card ID"))
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
 	var card models.FlashCard
 	// Get the card and its associated deck
 	if err := h.db.Preload("Deck").First(&card, cardID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Flashcard not found"})
+		c.Error(apperrors.NotFound("Flashcard not found"))
 		return
 	}
 
 	// Check if user has permission to view this card
 	// (either the user owns the deck or the deck is public)
-	if !card.Deck.IsPublic && card.Deck.UserID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to view this flashcard"})
+	allowed, err := canViewDeck(h.db, card.Deck, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to view this flashcard"))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	// The caller's private note, if they wrote one
+	var notes []string
+	if err := h.db.Model(&models.CardNote{}).Where("user_id = ? AND card_id = ?", userID, card.ID).Pluck("note", &notes).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve note", err))
+		return
+	}
+	var note *string
+	if len(notes) > 0 {
+		note = &notes[0]
+	}
+
+	jsonWithETag(c, gin.H{
 		"card": card,
+		"note": note,
 	})
 }
 
-// GetCardsByDeck -> Handler to get all flashcards in a deck
+// GetCardsByDeckRequest -> Query parameters for a page of a deck's cards
+type GetCardsByDeckRequest struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// GetCardsByDeck -> Handler to get the flashcards in a deck, all of them unless a page is asked for
 func (h *CardHandler) GetCardsByDeck(c *gin.Context) {
+	var req GetCardsByDeckRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
 	deckID, err := strconv.ParseUint(c.Param("deck_id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deck ID"})
+		c.Error(apperrors.BadRequest("Invalid deck ID"))
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
 	// Check if the user has access to this deck
 	var deck models.Deck
 	if err := h.db.First(&deck, deckID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+		c.Error(apperrors.NotFound("Deck not found"))
 		return
 	}
 
-	if !deck.IsPublic && deck.UserID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to view cards in this deck"})
+	allowed, err := canViewDeck(h.db, deck, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to view cards in this deck"))
+		return
+	}
+
+	// Get all cards in the deck, or only the starred ones with ?starred=true
+	query := h.db.Model(&models.FlashCard{}).Where("flash_cards.deck_id = ?", deckID)
+	if c.Query("starred") == "true" {
+		query = query.Where("flash_cards.starred = ?", true)
+	}
+
+	// Clients that don't ask for a page get every card, as before pagination existed
+	paged := req.Page != 0 || req.PageSize != 0
+	page := req.Page
+	if page == 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize == 0 {
+		pageSize = 20
+	}
+
+	// Counted before sorting, which may join in the caller's progress
+	var total int64
+	if paged {
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			c.Error(apperrors.Internal("Failed to retrieve flashcards", err))
+			return
+		}
+	}
+
+	// Hardest first on request, otherwise in the order the cards were added
+	switch c.Query("sort") {
+	case "":
+		query = query.Order("flash_cards.id ASC")
+	case "difficulty_desc":
+		query = query.Order("flash_cards.difficulty_level DESC, flash_cards.id ASC")
+	case "accuracy_asc":
+		// The caller's own accuracy on each card, cards they never reviewed go last
+		query = query.Select("flash_cards.*").
+			Joins("LEFT JOIN card_progresses ON card_progresses.card_id = flash_cards.id AND card_progresses.user_id = ? AND card_progresses.deleted_at IS NULL", userID).
+			Order("COALESCE(card_progresses.review_count, 0) = 0 ASC").
+			Order("CAST(card_progresses.correct_count AS REAL) / card_progresses.review_count ASC").
+			Order("flash_cards.id ASC")
+	default:
+		c.Error(apperrors.BadRequest("sort must be difficulty_desc or accuracy_asc"))
 		return
 	}
 
-	// Get all cards in the deck
+	if paged {
+		query = query.Offset((page - 1) * pageSize).Limit(pageSize)
+	}
+
 	var cards []models.FlashCard
-	if err := h.db.Where("deck_id = ?", deckID).Find(&cards).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve flashcards"})
+	if err := query.Find(&cards).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve flashcards", err))
+		return
+	}
+
+	if !paged {
+		jsonWithETag(c, gin.H{
+			"cards": cards,
+		})
+		return
+	}
+
+	setPaginationHeaders(c, page, pageSize, total)
+
+	jsonWithETag(c, gin.H{
+		"cards":     cards,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// StarCard -> Handler to flag a card as important so study sessions show it first
+func (h *CardHandler) StarCard(c *gin.Context) {
+	h.setStarred(c, true)
+}
+
+// UnstarCard -> Handler to remove a card's star
+func (h *CardHandler) UnstarCard(c *gin.Context) {
+	h.setStarred(c, false)
+}
+
+// setStarred -> Stars or unstars the card from the :id param, only the deck owner may do either
+func (h *CardHandler) setStarred(c *gin.Context, starred bool) {
+	cardID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid card ID"))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var card models.FlashCard
+	if err := h.db.Preload("Deck").First(&card, cardID).Error; err != nil {
+		c.Error(apperrors.NotFound("Flashcard not found"))
 		return
 	}
 
+	// Owners and editors may change the deck's cards
+	allowed, err := canEditDeck(h.db, card.Deck, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to star this flashcard"))
+		return
+	}
+
+	if err := h.db.Model(&card).Update("starred", starred).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to update flashcard", err))
+		return
+	}
+
+	invalidateDeckCache(c.Request.Context(), h.cache, card.DeckID)
+
 	c.JSON(http.StatusOK, gin.H{
-		"cards": cards,
+		"card": card,
+	})
+}
+
+// DuplicateCardRequest -> Query parameters for duplicating a flashcard
+type DuplicateCardRequest struct {
+	MarkCopy *bool `form:"mark_copy"` // Append " (copy)" to the front, defaults to true
+}
+
+// DuplicateCard -> Handler to copy a flashcard into the same deck, only the deck owner may do it
+func (h *CardHandler) DuplicateCard(c *gin.Context) {
+	cardID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid card ID"))
+		return
+	}
+
+	var req DuplicateCardRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var original models.FlashCard
+	if err := h.db.Preload("Deck").First(&original, cardID).Error; err != nil {
+		c.Error(apperrors.NotFound("Flashcard not found"))
+		return
+	}
+
+	// Owners and editors may change the deck's cards
+	allowed, err := canEditDeck(h.db, original.Deck, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to duplicate this flashcard"))
+		return
+	}
+
+	frontContent := original.FrontContent
+	if req.MarkCopy == nil || *req.MarkCopy {
+		frontContent += " (copy)"
+	}
+
+	// Save the copy and the deck's card count together, retrying if the database is busy
+	var card models.FlashCard
+	err = withRetry(h.db, func(tx *gorm.DB) error {
+		card = models.FlashCard{
+			DeckID:            original.DeckID,
+			FrontContent:      frontContent,
+			BackContent:       original.BackContent,
+			AcceptableAnswers: original.AcceptableAnswers,
+			Explanation:       original.Explanation,
+			ContentType:       original.ContentType,
+			DifficultyLevel:   original.DifficultyLevel,
+			Starred:           original.Starred,
+		}
+
+		if err := tx.Create(&card).Error; err != nil {
+			return apperrors.Internal("Failed to duplicate flashcard", err)
+		}
+
+		if err := tx.Model(&models.Deck{}).Where("id = ?", original.DeckID).
+			Update("card_count", gorm.Expr("card_count + ?", 1)).Error; err != nil {
+			return apperrors.Internal("Failed to update deck card count", err)
+		}
+		return nil
+	})
+	if err != nil {
+		c.Error(txError(err, "Failed to process changes"))
+		return
+	}
+
+	invalidateDeckCache(c.Request.Context(), h.cache, card.DeckID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Flashcard duplicated successfully",
+		"card":    card,
 	})
 }
 
 // UpdateCardRequest -> Struct for flashcard update request
 type UpdateCardRequest struct {
-	FrontContent    string  `json:"front_content"`
-	BackContent     string  `json:"back_content"`
-	ContentType     string  `json:"content_type"`
-	DifficultyLevel float64 `json:"difficulty_level"`
+	FrontContent      string    `json:"front_content"`
+	BackContent       string    `json:"back_content"`
+	AcceptableAnswers *[]string `json:"acceptable_answers" binding:"omitempty,max=20,dive,max=500"` // Replaces the aliases, an empty list clears them
+	Explanation       *string   `json:"explanation" binding:"omitempty,max=5000"`                   // Empty string clears it
+	ContentType       string    `json:"content_type" binding:"omitempty,content_type"`
+	DifficultyLevel   float64   `json:"difficulty_level" binding:"omitempty,min=0,max=1"`
 }
 
 // UpdateCard -> Handler to update a flashcard
 func (h *CardHandler) UpdateCard(c *gin.Context) {
 	cardID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card ID"})
+		c.Error(apperrors.BadRequest("Invalid card ID"))
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
 	var card models.FlashCard
 	// Get the card with its deck
 	if err := h.db.Preload("Deck").First(&card, cardID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Flashcard not found"})
+		c.Error(apperrors.NotFound("Flashcard not found"))
 		return
 	}
 
-	// Check if user owns the deck that contains this card
-	if card.Deck.UserID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to update this flashcard"})
+	// Owners and editors may change the deck's cards
+	allowed, err := canEditDeck(h.db, card.Deck, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to update this flashcard"))
 		return
 	}
 
 	var req UpdateCardRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperrors.Validation(err))
 		return
 	}
 
@@ -205,6 +480,14 @@ func (h *CardHandler) UpdateCard(c *gin.Context) {
 	if req.BackContent != "" {
 		card.BackContent = req.BackContent
 	}
+	if req.AcceptableAnswers != nil {
+		card.AcceptableAnswers = *req.AcceptableAnswers
+	}
+	// Cleaned against the final back content, which may have just changed too
+	card.AcceptableAnswers = cleanAcceptableAnswers(card.AcceptableAnswers, card.BackContent)
+	if req.Explanation != nil {
+		card.Explanation = *req.Explanation
+	}
 	if req.ContentType != "" {
 		card.ContentType = req.ContentType
 	}
@@ -214,10 +497,12 @@ func (h *CardHandler) UpdateCard(c *gin.Context) {
 
 	// Save updated card
 	if err := h.db.Save(&card).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update flashcard"})
+		c.Error(apperrors.Internal("Failed to update flashcard", err))
 		return
 	}
 
+	invalidateDeckCache(c.Request.Context(), h.cache, card.DeckID)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Flashcard updated successfully",
 		"card":    card,
@@ -228,26 +513,31 @@ func (h *CardHandler) UpdateCard(c *gin.Context) {
 func (h *CardHandler) DeleteCard(c *gin.Context) {
 	cardID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card ID"})
+		c.Error(apperrors.BadRequest("Invalid card ID"))
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
 	var card models.FlashCard
 	// Get the card with its deck
 	if err := h.db.Preload("Deck").First(&card, cardID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Flashcard not found"})
+		c.Error(apperrors.NotFound("Flashcard not found"))
 		return
 	}
 
-	// Check if user owns the deck that contains this card
-	if card.Deck.UserID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to delete this flashcard"})
+	// Owners and editors may change the deck's cards
+	allowed, err := canEditDeck(h.db, card.Deck, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to delete this flashcard"))
 		return
 	}
 
@@ -257,107 +547,412 @@ func (h *CardHandler) DeleteCard(c *gin.Context) {
 	// Delete the card
 	if err := tx.Delete(&card).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete flashcard"})
+		c.Error(apperrors.Internal("Failed to delete flashcard", err))
 		return
 	}
 
-	// Update deck's card count
-	if err := tx.Model(&card.Deck).Update("card_count", card.Deck.CardCount-1).Error; err != nil {
+	// Update deck's card count, atomically like the increments
+	if err := tx.Model(&card.Deck).Update("card_count", gorm.Expr("card_count - ?", 1)).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update deck card count"})
+		c.Error(apperrors.Internal("Failed to update deck card count", err))
 		return
 	}
 
 	// Commit the transaction
 	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process changes"})
+		c.Error(apperrors.Internal("Failed to process changes", err))
 		return
 	}
 
+	invalidateDeckCache(c.Request.Context(), h.cache, card.DeckID)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Flashcard deleted successfully",
 	})
 }
 
-// BulkImportRequest -> Struct for bulk importing cards
+// BulkImportRequest -> Struct for bulk importing cards. Entries are checked by validateImportEntries
+// rather than binding tags, so every problem can be reported with the index of its entry
 type BulkImportRequest struct {
-	DeckID uint                  `json:"deck_id" binding:"required"`
-	Cards  []BulkImportCardEntry `json:"cards" binding:"required"`
+	DeckID         uint                  `json:"deck_id" binding:"required"`
+	Cards          []BulkImportCardEntry `json:"cards" binding:"required"`
+	SkipDuplicates bool                  `json:"skip_duplicates"` // Skip entries whose front content already exists in the deck
+	Partial        bool                  `json:"partial"`         // Import the valid entries and report the rest, instead of rejecting the lot
 }
 
 type BulkImportCardEntry struct {
-	FrontContent string `json:"front_content" binding:"required"`
-	BackContent  string `json:"back_content" binding:"required"`
-	ContentType  string `json:"content_type"`
+	FrontContent      string   `json:"front_content"`
+	BackContent       string   `json:"back_content"`
+	AcceptableAnswers []string `json:"acceptable_answers"`
+	Explanation       string   `json:"explanation"`
+	ContentType       string   `json:"content_type"`
+}
+
+// maxBulkImportCards -> Most entries a single bulk import may carry
+const maxBulkImportCards = 1000
+
+// ImportIssue -> A problem with one bulk import entry
+type ImportIssue struct {
+	Index int    `json:"index"`
+	Field string `json:"field"`
+	Error string `json:"error"`
+}
+
+// validateImportEntries -> Checks every entry and returns all problems found, in entry order
+func validateImportEntries(entries []BulkImportCardEntry) []ImportIssue {
+	issues := make([]ImportIssue, 0)
+	for i, entry := range entries {
+		if strings.TrimSpace(entry.FrontContent) == "" {
+			issues = append(issues, ImportIssue{Index: i, Field: "front_content", Error: "is required"})
+		}
+		if strings.TrimSpace(entry.BackContent) == "" {
+			issues = append(issues, ImportIssue{Index: i, Field: "back_content", Error: "is required"})
+		}
+		if len(entry.AcceptableAnswers) > 20 {
+			issues = append(issues, ImportIssue{Index: i, Field: "acceptable_answers", Error: "must have at most 20 entries"})
+		}
+		if slices.ContainsFunc(entry.AcceptableAnswers, func(alias string) bool { return len(alias) > 500 }) {
+			issues = append(issues, ImportIssue{Index: i, Field: "acceptable_answers", Error: "entries must be at most 500 characters"})
+		}
+		if len(entry.Explanation) > 5000 {
+			issues = append(issues, ImportIssue{Index: i, Field: "explanation", Error: "must be at most 5000 characters"})
+		}
+		if entry.ContentType != "" && !models.IsValidContentType(entry.ContentType) {
+			issues = append(issues, ImportIssue{Index: i, Field: "content_type", Error: "must be one of " + strings.Join(models.ContentTypes, ", ")})
+		}
+	}
+	return issues
 }
 
 // BulkImportCards -> Handler to import multiple cards at once
 func (h *CardHandler) BulkImportCards(c *gin.Context) {
 	var req BulkImportRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	if len(req.Cards) == 0 {
+		c.Error(apperrors.BadRequest("At least one card is required"))
+		return
+	}
+	if len(req.Cards) > maxBulkImportCards {
+		c.Error(apperrors.BadRequest(fmt.Sprintf("At most %d cards can be imported at once", maxBulkImportCards)))
+		return
+	}
+
+	// Validate the whole payload before touching the database. Without partial, any problem
+	// rejects the import, otherwise the invalid entries are left out and reported
+	issues := validateImportEntries(req.Cards)
+	if len(issues) > 0 && !req.Partial {
+		c.Error(apperrors.New(http.StatusBadRequest, "invalid_import", fmt.Sprintf("%d problem(s) found in the import, nothing was imported", len(issues)), nil).
+			WithDetails(issues))
 		return
 	}
+	invalid := make(map[int]bool, len(issues))
+	for _, issue := range issues {
+		invalid[issue.Index] = true
+	}
 
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
-	// Verify the deck exists and belongs to the user
-	var deck models.Deck
-	if err := h.db.Where("id = ? AND user_id = ?", req.DeckID, userID).First(&deck).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found or you don't have permission to add cards to it"})
+	// Check the deck, import the cards and update the count in one transaction, retried as a
+	// whole if the database is busy
+	var importedCards []models.FlashCard
+	var skipped, newCardCount int
+	err := withRetry(h.db, func(tx *gorm.DB) error {
+		// Verify the deck exists and the user may edit its cards
+		var deck models.Deck
+		if err := tx.First(&deck, req.DeckID).Error; err != nil {
+			return apperrors.NotFound("Deck not found or you don't have permission to add cards to it")
+		}
+		allowed, err := canEditDeck(tx, deck, userID.(uint))
+		if err != nil {
+			return apperrors.Internal("Failed to check deck access", err)
+		}
+		if !allowed {
+			return apperrors.NotFound("Deck not found or you don't have permission to add cards to it")
+		}
+
+		// Load existing front contents so re-imports don't bloat the deck
+		var seen map[string]bool
+		if req.SkipDuplicates {
+			if seen, err = deckFrontContents(tx, deck.ID); err != nil {
+				return apperrors.Internal("Failed to check for duplicate cards", err)
+			}
+		}
+
+		importedCards = make([]models.FlashCard, 0, len(req.Cards))
+		skipped = 0
+		for i, cardEntry := range req.Cards {
+			if invalid[i] {
+				continue
+			}
+			if req.SkipDuplicates {
+				key := normalizeContent(cardEntry.FrontContent)
+				if seen[key] {
+					skipped++
+					continue
+				}
+				// Also catch duplicates within the same payload
+				seen[key] = true
+			}
+
+			contentType := cardEntry.ContentType
+			if contentType == "" {
+				contentType = models.ContentText
+			}
+
+			card := models.FlashCard{
+				DeckID:            req.DeckID,
+				FrontContent:      cardEntry.FrontContent,
+				BackContent:       cardEntry.BackContent,
+				AcceptableAnswers: cleanAcceptableAnswers(cardEntry.AcceptableAnswers, cardEntry.BackContent),
+				Explanation:       cardEntry.Explanation,
+				ContentType:       contentType,
+				DifficultyLevel:   0.5, // default difficulty
+			}
+
+			if err := tx.Create(&card).Error; err != nil {
+				return apperrors.Internal("Failed to import cards", err)
+			}
+
+			importedCards = append(importedCards, card)
+		}
+
+		// Update card count in the deck atomically, then read back the result
+		if err := tx.Model(&deck).Update("card_count", gorm.Expr("card_count + ?", len(importedCards))).Error; err != nil {
+			return apperrors.Internal("Failed to update deck card count", err)
+		}
+		if err := tx.Model(&models.Deck{}).Where("id = ?", deck.ID).Select("card_count").Scan(&newCardCount).Error; err != nil {
+			return apperrors.Internal("Failed to update deck card count", err)
+		}
+		return nil
+	})
+	if err != nil {
+		c.Error(txError(err, "Failed to process import"))
+		return
+	}
+
+	invalidateDeckCache(c.Request.Context(), h.cache, req.DeckID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Cards imported successfully",
+		"imported":  len(importedCards),
+		"skipped":   skipped,
+		"invalid":   len(invalid),
+		"issues":    issues,
+		"cards":     importedCards,
+		"new_count": newCardCount,
+	})
+}
+
+// BulkUpdateRequest -> Struct for bulk updating cards
+type BulkUpdateRequest struct {
+	Cards []BulkUpdateCardEntry `json:"cards" binding:"required,min=1,dive"`
+}
+
+// BulkUpdateCardEntry -> Pointer fields differentiate between "leave unchanged" and "set to empty"
+type BulkUpdateCardEntry struct {
+	ID              uint     `json:"id" binding:"required"`
+	FrontContent    *string  `json:"front_content"`
+	BackContent     *string  `json:"back_content"`
+	Explanation     *string  `json:"explanation" binding:"omitempty,max=5000"`
+	ContentType     *string  `json:"content_type" binding:"omitempty,content_type"`
+	DifficultyLevel *float64 `json:"difficulty_level" binding:"omitempty,min=0,max=1"`
+}
+
+// BulkUpdateCards -> Handler to apply partial updates to multiple cards at once
+func (h *CardHandler) BulkUpdateCards(c *gin.Context) {
+	var req BulkUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.Validation(err))
 		return
 	}
 
-	// Begin a transaction for bulk import
+	// Get user ID from context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	// Begin a transaction for bulk update
 	tx := h.db.Begin()
 
-	importedCards := make([]models.FlashCard, 0, len(req.Cards))
-	for _, cardEntry := range req.Cards {
-		contentType := cardEntry.ContentType
-		if contentType == "" {
-			contentType = "text"
+	results := make([]gin.H, 0, len(req.Cards))
+	updatedCount := 0
+	var touchedDecks []uint
+	for _, entry := range req.Cards {
+		var card models.FlashCard
+		if err := tx.Preload("Deck").First(&card, entry.ID).Error; err != nil {
+			results = append(results, gin.H{"id": entry.ID, "success": false, "error": "Flashcard not found"})
+			continue
 		}
 
-		card := models.FlashCard{
-			DeckID:          req.DeckID,
-			FrontContent:    cardEntry.FrontContent,
-			BackContent:     cardEntry.BackContent,
-			ContentType:     contentType,
-			DifficultyLevel: 0.5, // default difficulty
+		// Owners and editors may change the deck's cards
+		allowed, err := canEditDeck(tx, card.Deck, userID.(uint))
+		if err != nil {
+			tx.Rollback()
+			c.Error(apperrors.Internal("Failed to check deck access", err))
+			return
+		}
+		if !allowed {
+			results = append(results, gin.H{"id": entry.ID, "success": false, "error": "You don't have permission to update this flashcard"})
+			continue
 		}
 
-		if err := tx.Create(&card).Error; err != nil {
+		// Update fields if provided
+		if entry.FrontContent != nil {
+			card.FrontContent = *entry.FrontContent
+		}
+		if entry.BackContent != nil {
+			card.BackContent = *entry.BackContent
+		}
+		if entry.Explanation != nil {
+			card.Explanation = *entry.Explanation
+		}
+		if entry.ContentType != nil {
+			card.ContentType = *entry.ContentType
+		}
+		if entry.DifficultyLevel != nil {
+			card.DifficultyLevel = *entry.DifficultyLevel
+		}
+
+		if err := tx.Save(&card).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import cards"})
+			c.Error(apperrors.Internal("Failed to update flashcards", err))
 			return
 		}
 
-		importedCards = append(importedCards, card)
+		results = append(results, gin.H{"id": entry.ID, "success": true, "card": card})
+		updatedCount++
+		touchedDecks = append(touchedDecks, card.DeckID)
 	}
 
-	// Update card count in the deck
-	newCardCount := deck.CardCount + len(req.Cards)
-	if err := tx.Model(&deck).Update("card_count", newCardCount).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update deck card count"})
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		c.Error(apperrors.Internal("Failed to process updates", err))
 		return
 	}
 
-	// Commit the transaction
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process import"})
+	if len(touchedDecks) > 0 {
+		invalidateDeckCache(c.Request.Context(), h.cache, touchedDecks...)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Bulk update processed",
+		"updated": updatedCount,
+		"failed":  len(req.Cards) - updatedCount,
+		"results": results,
+	})
+}
+
+// BulkMoveRequest -> Struct for moving cards from one of the user's decks to another
+type BulkMoveRequest struct {
+	SourceDeckID uint   `json:"source_deck_id" binding:"required"`
+	TargetDeckID uint   `json:"target_deck_id" binding:"required"`
+	CardIDs      []uint `json:"card_ids" binding:"omitempty,max=1000"`
+	All          bool   `json:"all"` // Move every card in the source deck, card_ids is ignored
+}
+
+// BulkMoveCards -> Handler to move cards between two decks the user owns, the card counts of both
+// decks change in the same transaction as the cards
+func (h *CardHandler) BulkMoveCards(c *gin.Context) {
+	var req BulkMoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.Validation(err))
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message":   "Cards imported successfully",
-		"imported":  len(importedCards),
-		"cards":     importedCards,
-		"new_count": newCardCount,
+	if req.SourceDeckID == req.TargetDeckID {
+		c.Error(apperrors.BadRequest("Source and target deck must be different"))
+		return
+	}
+	if !req.All && len(req.CardIDs) == 0 {
+		c.Error(apperrors.BadRequest("Either card_ids or all is required"))
+		return
+	}
+	slices.Sort(req.CardIDs)
+	req.CardIDs = slices.Compact(req.CardIDs)
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	// The user must be able to edit the cards of both decks
+	var decks []models.Deck
+	if err := h.db.Where("id IN ?", []uint{req.SourceDeckID, req.TargetDeckID}).Find(&decks).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve decks", err))
+		return
+	}
+	if len(decks) != 2 {
+		c.Error(apperrors.NotFound("Deck not found or you don't have permission to move cards between these decks"))
+		return
+	}
+	for _, deck := range decks {
+		allowed, err := canEditDeck(h.db, deck, userID.(uint))
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to check deck access", err))
+			return
+		}
+		if !allowed {
+			c.Error(apperrors.NotFound("Deck not found or you don't have permission to move cards between these decks"))
+			return
+		}
+	}
+
+	var moved int64
+	err := withRetry(h.db, func(tx *gorm.DB) error {
+		query := tx.Model(&models.FlashCard{}).Where("deck_id = ?", req.SourceDeckID)
+		if !req.All {
+			query = query.Where("id IN ?", req.CardIDs)
+		}
+
+		result := query.Update("deck_id", req.TargetDeckID)
+		if result.Error != nil {
+			return apperrors.Internal("Failed to move cards", result.Error)
+		}
+		moved = result.RowsAffected
+
+		if err := tx.Model(&models.Deck{}).Where("id = ?", req.SourceDeckID).
+			Update("card_count", gorm.Expr("card_count - ?", moved)).Error; err != nil {
+			return apperrors.Internal("Failed to update deck card count", err)
+		}
+		if err := tx.Model(&models.Deck{}).Where("id = ?", req.TargetDeckID).
+			Update("card_count", gorm.Expr("card_count + ?", moved)).Error; err != nil {
+			return apperrors.Internal("Failed to update deck card count", err)
+		}
+		return nil
 	})
+	if err != nil {
+		c.Error(txError(err, "Failed to move cards"))
+		return
+	}
+
+	invalidateDeckCache(c.Request.Context(), h.cache, req.SourceDeckID, req.TargetDeckID)
+
+	// Due cards now count toward the target deck
+	if err := stats.RecomputeDueCounts(h.db, userID.(uint)); err != nil {
+		log.Printf("Failed to recompute due counts for user %d: %v", userID, err)
+	}
+
+	response := gin.H{
+		"message":        "Cards moved successfully",
+		"moved":          moved,
+		"source_deck_id": req.SourceDeckID,
+		"target_deck_id": req.TargetDeckID,
+	}
+	// Requested cards that weren't in the source deck are left where they are
+	if !req.All {
+		response["not_moved"] = int64(len(req.CardIDs)) - moved
+	}
+	c.JSON(http.StatusOK, response)
 }
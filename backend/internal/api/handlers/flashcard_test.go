@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestCreateCardCountUpdateFailureKeepsNoCard(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice")
+	deck := createTestDeck(t, db, user.ID, "Capitals")
+
+	// Fail every update of a deck, which CreateCard only does to bump the card count
+	if err := db.Callback().Update().Before("gorm:update").Register("test:fail_deck_update", func(tx *gorm.DB) {
+		if tx.Statement.Table == "decks" {
+			tx.AddError(errors.New("simulated count update failure"))
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestRouter(user.ID)
+	r.POST("/cards", NewCardHandler(db, nil).CreateCard)
+
+	code, out := doJSON(t, r, http.MethodPost, "/cards", map[string]any{
+		"deck_id":       deck.ID,
+		"front_content": "France",
+		"back_content":  "Paris",
+	})
+	if code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d: %v", code, http.StatusInternalServerError, out)
+	}
+
+	var cards int64
+	if err := db.Model(&models.FlashCard{}).Where("deck_id = ?", deck.ID).Count(&cards).Error; err != nil {
+		t.Fatal(err)
+	}
+	if cards != 0 {
+		t.Errorf("deck has %d cards after the failed create, want 0", cards)
+	}
+
+	var stored models.Deck
+	if err := db.First(&stored, deck.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.CardCount != 0 {
+		t.Errorf("card_count = %d, want 0", stored.CardCount)
+	}
+}
+
+func TestCreateCardConcurrentCount(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice")
+	deck := createTestDeck(t, db, user.ID, "Capitals")
+
+	r := newTestRouter(user.ID)
+	r.POST("/cards", NewCardHandler(db, nil).CreateCard)
+
+	const n = 25
+	codes := make([]int, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		req := newJSONRequest(t, http.MethodPost, "/cards", map[string]any{
+			"deck_id":       deck.ID,
+			"front_content": fmt.Sprintf("Country %d", i),
+			"back_content":  fmt.Sprintf("Capital %d", i),
+		})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			codes[i] = w.Code
+		}()
+	}
+	wg.Wait()
+
+	for i, code := range codes {
+		if code != http.StatusCreated {
+			t.Errorf("request %d: status = %d, want %d", i, code, http.StatusCreated)
+		}
+	}
+
+	var stored models.Deck
+	if err := db.First(&stored, deck.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.CardCount != n {
+		t.Errorf("card_count = %d, want %d", stored.CardCount, n)
+	}
+}
+
+func TestGetCardsByDeckPagination(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice")
+	deck := createTestDeck(t, db, user.ID, "Capitals", "France", "Spain", "Italy")
+
+	r := newTestRouter(user.ID)
+	r.GET("/cards/deck/:deck_id", NewCardHandler(db, nil).GetCardsByDeck)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/cards/deck/%d?page=2&page_size=2", deck.ID), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	if got := w.Header().Get("X-Total-Count"); got != "3" {
+		t.Errorf("X-Total-Count = %q, want %q", got, "3")
+	}
+	if link := w.Header().Get("Link"); !strings.Contains(link, `page=1&page_size=2>; rel="prev"`) || strings.Contains(link, `rel="next"`) {
+		t.Errorf("Link = %q, want a prev link to page 1 and no next link", link)
+	}
+
+	var out struct {
+		Cards []models.FlashCard `json:"cards"`
+		Total int64              `json:"total"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Cards) != 1 || out.Cards[0].FrontContent != "Italy" || out.Total != 3 {
+		t.Errorf("page 2 = %d cards, total %d, want only Italy of 3", len(out.Cards), out.Total)
+	}
+
+	// Without page parameters every card comes back, even past a default page
+	var fronts []string
+	for i := range 25 {
+		fronts = append(fronts, fmt.Sprintf("Question %d", i))
+	}
+	big := createTestDeck(t, db, user.ID, "Big", fronts...)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/cards/deck/%d", big.ID), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unpaged: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	if got := w.Header().Get("X-Total-Count"); got != "" {
+		t.Errorf("unpaged: X-Total-Count = %q, want none", got)
+	}
+	out.Cards = nil
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Cards) != len(fronts) {
+		t.Errorf("unpaged: got %d cards, want all %d", len(out.Cards), len(fronts))
+	}
+}
+
+func TestBulkImportCardsAcceptableAnswers(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice")
+	deck := createTestDeck(t, db, user.ID, "Capitals")
+
+	r := newTestRouter(user.ID)
+	r.POST("/cards/bulk-import", NewCardHandler(db, nil).BulkImportCards)
+
+	// Blank aliases and ones repeating the back are dropped, like on create
+	body := map[string]any{"deck_id": deck.ID, "cards": []map[string]any{
+		{"front_content": "France", "back_content": "Paris", "acceptable_answers": []string{" Paris, France ", "", "Paris"}},
+	}}
+	if status, resp := doJSON(t, r, http.MethodPost, "/cards/bulk-import", body); status != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %v", status, http.StatusCreated, resp)
+	}
+	var card models.FlashCard
+	if err := db.Where("deck_id = ?", deck.ID).First(&card).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(card.AcceptableAnswers, []string{"Paris, France"}) {
+		t.Errorf("aliases = %q, want %q", card.AcceptableAnswers, []string{"Paris, France"})
+	}
+
+	// Too many aliases is reported against the entry
+	tooMany := make([]string, 21)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprint("alias ", i)
+	}
+	body["cards"] = []map[string]any{{"front_content": "Spain", "back_content": "Madrid", "acceptable_answers": tooMany}}
+	if status, resp := doJSON(t, r, http.MethodPost, "/cards/bulk-import", body); status != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d: %v", status, http.StatusBadRequest, resp)
+	}
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/models"
+	"FlashQuiz/internal/stats"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SetStudyGoalRequest -> Struct for setting a deck's study goal, omitting both clears it
+type SetStudyGoalRequest struct {
+	DailyReviews int    `json:"daily_reviews" binding:"omitempty,min=1,max=10000"`
+	TargetDate   string `json:"target_date" binding:"omitempty,datetime=2006-01-02"` // The day the deck should be mastered by
+}
+
+// loadStudyDeck -> Loads the deck from the :id param, which the user must be able to study
+func (h *StudyHandler) loadStudyDeck(c *gin.Context, userID uint) (models.Deck, bool) {
+	var deck models.Deck
+
+	deckID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid deck ID"))
+		return deck, false
+	}
+
+	if err := h.db.First(&deck, deckID).Error; err != nil {
+		c.Error(apperrors.NotFound("Deck not found"))
+		return deck, false
+	}
+
+	allowed, err := canViewDeck(h.db, deck, userID)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return deck, false
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to study this deck"))
+		return deck, false
+	}
+	return deck, true
+}
+
+// SetStudyGoal -> Handler to set or clear the user's goal for a deck
+func (h *StudyHandler) SetStudyGoal(c *gin.Context) {
+	var req SetStudyGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	deck, ok := h.loadStudyDeck(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	if req.DailyReviews == 0 && req.TargetDate == "" {
+		if err := h.db.Unscoped().Where("user_id = ? AND deck_id = ?", userID, deck.ID).Delete(&models.StudyGoal{}).Error; err != nil {
+			c.Error(apperrors.Internal("Failed to clear study goal", err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Study goal cleared",
+			"goal":    nil,
+		})
+		return
+	}
+
+	settings, err := loadUserSettings(h.db, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve settings", err))
+		return
+	}
+
+	var goal models.StudyGoal
+	if err := h.db.Where("user_id = ? AND deck_id = ?", userID, deck.ID).First(&goal).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.Error(apperrors.Internal("Failed to retrieve study goal", err))
+		return
+	}
+	goal.UserID = userID.(uint)
+	goal.DeckID = deck.ID
+	goal.DailyReviews = req.DailyReviews
+	goal.TargetDate = nil
+	if req.TargetDate != "" {
+		// The deadline is the end of that day in the user's timezone
+		target, _ := time.ParseInLocation("2006-01-02", req.TargetDate, settings.Location())
+		target = target.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		goal.TargetDate = &target
+	}
+
+	if err := h.db.Save(&goal).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to save study goal", err))
+		return
+	}
+
+	progress, err := studyGoalProgress(h.db, goal, settings, time.Now())
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve study goal progress", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Study goal saved",
+		"goal":    progress,
+	})
+}
+
+// GetDeckMastery -> Handler to get how much of a deck the user has mastered, see stats.DeckMastery
+func (h *StudyHandler) GetDeckMastery(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	deck, ok := h.loadStudyDeck(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	settings, err := loadUserSettings(h.db, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve settings", err))
+		return
+	}
+
+	mastery, err := stats.DeckMastery(h.db, userID.(uint), deck.ID, settings.MasteryIntervalDays)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to compute deck mastery", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deck_id": deck.ID,
+		"mastery": mastery,
+	})
+}
+
+// GetStudyGoal -> Handler to get the user's goal for a deck and today's progress toward it
+func (h *StudyHandler) GetStudyGoal(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	deck, ok := h.loadStudyDeck(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	progress, err := h.deckGoalProgress(userID.(uint), deck.ID)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve study goal", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"goal": progress,
+	})
+}
+
+// deckGoalProgress -> The user's goal for the deck with its progress, nil when there's no goal
+func (h *StudyHandler) deckGoalProgress(userID, deckID uint) (gin.H, error) {
+	var goal models.StudyGoal
+	err := h.db.Where("user_id = ? AND deck_id = ?", userID, deckID).First(&goal).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := loadUserSettings(h.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	return studyGoalProgress(h.db, goal, settings, time.Now())
+}
+
+// studyGoalProgress -> The goal alongside how far along it is. Reviews count from the user's
+// local midnight, so the daily goal starts over every day. Cards count as mastered by the same
+// rule as deck mastery (see stats.Mastered), with the user's own mastery interval
+func studyGoalProgress(db *gorm.DB, goal models.StudyGoal, settings models.UserSettings, now time.Time) (gin.H, error) {
+	var reviewsToday int64
+	if err := db.Model(&models.ReviewLog{}).
+		Joins("JOIN flash_cards ON flash_cards.id = review_logs.card_id").
+		Where("review_logs.user_id = ? AND flash_cards.deck_id = ? AND review_logs.reviewed_at >= ?",
+			goal.UserID, goal.DeckID, startOfDay(now, settings.Location()).UTC()).
+		Count(&reviewsToday).Error; err != nil {
+		return nil, err
+	}
+
+	var totalCards, masteredCards int64
+	if err := db.Model(&models.FlashCard{}).Where("deck_id = ?", goal.DeckID).Count(&totalCards).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&models.CardProgress{}).
+		Joins("JOIN flash_cards ON flash_cards.id = card_progresses.card_id AND flash_cards.deleted_at IS NULL").
+		Where("card_progresses.user_id = ? AND flash_cards.deck_id = ?", goal.UserID, goal.DeckID).
+		Scopes(stats.Mastered(settings.MasteryIntervalDays)).
+		Count(&masteredCards).Error; err != nil {
+		return nil, err
+	}
+
+	masteredPercent := 0.0
+	if totalCards > 0 {
+		masteredPercent = float64(masteredCards) / float64(totalCards) * 100
+	}
+
+	progress := gin.H{
+		"deck_id":          goal.DeckID,
+		"daily_reviews":    goal.DailyReviews,
+		"reviews_today":    reviewsToday,
+		"daily_goal_met":   goal.DailyReviews > 0 && reviewsToday >= int64(goal.DailyReviews),
+		"target_date":      nil,
+		"mastered_cards":   masteredCards,
+		"total_cards":      totalCards,
+		"mastered_percent": masteredPercent,
+		"deck_goal_met":    totalCards > 0 && masteredCards >= totalCards,
+	}
+	if goal.TargetDate != nil {
+		progress["target_date"] = goal.TargetDate.In(settings.Location()).Format("2006-01-02")
+		progress["days_remaining"] = max(0, int(math.Ceil(goal.TargetDate.Sub(now).Hours()/24)))
+	}
+	return progress, nil
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/models"
+	"testing"
+	"time"
+)
+
+func TestStudyGoalProgressMastery(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice")
+	deck := createTestDeck(t, db, user.ID, "Capitals", "France", "Spain", "Italy")
+
+	var cards []models.FlashCard
+	if err := db.Where("deck_id = ?", deck.ID).Order("id").Find(&cards).Error; err != nil {
+		t.Fatal(err)
+	}
+	// Graduated long ago, graduated recently, still learning
+	for i, progress := range []models.CardProgress{
+		{Status: "review", Interval: 30, EaseFactor: 2.5},
+		{Status: "review", Interval: 5, EaseFactor: 2.5},
+		{Status: "learning", Interval: 1, EaseFactor: 2.5},
+	} {
+		progress.UserID, progress.CardID = user.ID, cards[i].ID
+		if err := db.Create(&progress).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	goal := models.StudyGoal{UserID: user.ID, DeckID: deck.ID}
+	settings, err := loadUserSettings(db, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		intervalDays int
+		want         int64
+	}{
+		{"default mastery interval", settings.MasteryIntervalDays, 1},
+		{"user's shorter mastery interval", 3, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings.MasteryIntervalDays = tt.intervalDays
+			progress, err := studyGoalProgress(db, goal, settings, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if progress["mastered_cards"] != tt.want {
+				t.Errorf("mastered_cards = %v, want %d", progress["mastered_cards"], tt.want)
+			}
+		})
+	}
+}
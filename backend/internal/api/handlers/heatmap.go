@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetStudyHeatmapRequest -> Query parameters for a year of study activity
+type GetStudyHeatmapRequest struct {
+	Year int `form:"year" binding:"omitempty,min=1970,max=9999"` // Defaults to the current year in the user's timezone
+}
+
+// GetStudyHeatmap -> Handler to get how many reviews the user made on each day of a year, for a
+// contribution-graph style calendar. Days are the user's local days, so reviews are bucketed one
+// by one in Go where daylight saving changes are handled, rather than with a fixed SQL offset.
+// Only days with reviews appear in the map
+func (h *StudyHandler) GetStudyHeatmap(c *gin.Context) {
+	var req GetStudyHeatmapRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	settings, err := loadUserSettings(h.db, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve settings", err))
+		return
+	}
+	loc := settings.Location()
+
+	year := req.Year
+	if year == 0 {
+		year = time.Now().In(loc).Year()
+	}
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	end := start.AddDate(1, 0, 0)
+
+	rows, err := h.db.Model(&models.ReviewLog{}).
+		Select("reviewed_at").
+		Where("user_id = ? AND reviewed_at >= ? AND reviewed_at < ?", userID, start.UTC(), end.UTC()).
+		Rows()
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve study activity", err))
+		return
+	}
+	defer rows.Close()
+
+	days := make(map[string]int)
+	total := 0
+	for rows.Next() {
+		var reviewedAt time.Time
+		if err := rows.Scan(&reviewedAt); err != nil {
+			c.Error(apperrors.Internal("Failed to parse study activity", err))
+			return
+		}
+		days[reviewedAt.In(loc).Format("2006-01-02")]++
+		total++
+	}
+	if err := rows.Err(); err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve study activity", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"year":          year,
+		"timezone":      loc.String(),
+		"days":          days, // "YYYY-MM-DD" -> reviews that day
+		"active_days":   len(days),
+		"total_reviews": total,
+	})
+}
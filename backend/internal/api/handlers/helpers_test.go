@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/middleware"
+	"FlashQuiz/internal/models"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testModels -> Every model, migrated into each test database like main does
+var testModels = []any{
+	&models.User{}, &models.Deck{}, &models.DeckCollaborator{}, &models.FlashCard{}, &models.Tag{},
+	&models.CardTag{}, &models.CardNote{}, &models.CardProgress{}, &models.ReviewLog{},
+	&models.StudySession{}, &models.DeckDueCount{}, &models.StudyGoal{}, &models.Quiz{},
+	&models.QuizQuestion{}, &models.QuizInvite{}, &models.QuizIdempotencyKey{},
+	&models.PasswordResetToken{}, &models.UserSettings{}, &models.OutboxMessage{},
+}
+
+// newTestDB -> A fresh migrated SQLite database in the test's temp dir. A file rather than
+// :memory: so concurrent requests each get their own connection to the same data
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_busy_timeout=5000&_journal_mode=WAL"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(testModels...); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// newTestRouter -> A router rendering errors like the real one, with every request made as userID
+func newTestRouter(userID uint) *gin.Engine {
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+	})
+	return r
+}
+
+// doJSON -> Sends body as JSON to the router and decodes the JSON response
+func doJSON(t *testing.T, r *gin.Engine, method, path string, body any) (int, map[string]any) {
+	t.Helper()
+	return serveJSON(t, r, newJSONRequest(t, method, path, body))
+}
+
+// newJSONRequest -> A request carrying body as JSON, for tests that need to add headers
+func newJSONRequest(t *testing.T, method, path string, body any) *http.Request {
+	t.Helper()
+
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+// serveJSON -> Runs the request through the router and decodes the JSON response
+func serveJSON(t *testing.T, r *gin.Engine, req *http.Request) (int, map[string]any) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var out map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("%s %s: response isn't JSON: %q", req.Method, req.URL, w.Body.String())
+	}
+	return w.Code, out
+}
+
+// createTestUser -> Inserts a user, the password is never checked by handler tests
+func createTestUser(t *testing.T, db *gorm.DB, username string) models.User {
+	t.Helper()
+
+	user := models.User{Username: username, Email: username + "@example.com", PasswordHash: "unused", Role: models.RoleUser}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// createTestDeck -> Inserts a deck owned by userID with one card per front, backs are "A<n>"
+func createTestDeck(t *testing.T, db *gorm.DB, userID uint, title string, fronts ...string) models.Deck {
+	t.Helper()
+
+	deck := models.Deck{UserID: userID, Title: title, CardCount: len(fronts)}
+	if err := db.Create(&deck).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i, front := range fronts {
+		card := models.FlashCard{DeckID: deck.ID, FrontContent: front, BackContent: fmt.Sprintf("A%d", i)}
+		if err := db.Create(&card).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return deck
+}
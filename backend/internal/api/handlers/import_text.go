@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/models"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxTextImportBytes -> Largest text a single text import may carry
+const maxTextImportBytes = 1 << 20
+
+// ImportTextRequest -> Struct for importing cards from pasted text, one "front<delimiter>back" card per line
+type ImportTextRequest struct {
+	DeckID    uint   `json:"deck_id" binding:"required"`
+	Text      string `json:"text" binding:"required"`
+	Delimiter string `json:"delimiter" binding:"omitempty,max=10"` // Separates front from back, a tab by default
+}
+
+// TextImportIssue -> A line of a text import that couldn't be turned into a card
+type TextImportIssue struct {
+	Line  int    `json:"line"` // 1-based, counting blank lines
+	Error string `json:"error"`
+}
+
+// parseTextCards -> Splits each non-empty line at the first delimiter into a front and back,
+// returning the cards along with the lines that had no delimiter or an empty side
+func parseTextCards(text, delimiter string) ([]BulkImportCardEntry, []TextImportIssue) {
+	entries := make([]BulkImportCardEntry, 0)
+	issues := make([]TextImportIssue, 0)
+
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		front, back, found := strings.Cut(line, delimiter)
+		front, back = strings.TrimSpace(front), strings.TrimSpace(back)
+		switch {
+		case !found:
+			issues = append(issues, TextImportIssue{Line: i + 1, Error: "missing delimiter"})
+		case front == "":
+			issues = append(issues, TextImportIssue{Line: i + 1, Error: "front is empty"})
+		case back == "":
+			issues = append(issues, TextImportIssue{Line: i + 1, Error: "back is empty"})
+		default:
+			entries = append(entries, BulkImportCardEntry{FrontContent: front, BackContent: back})
+		}
+	}
+	return entries, issues
+}
+
+// ImportTextCards -> Handler to import cards from plain text, skipping and reporting malformed lines
+func (h *CardHandler) ImportTextCards(c *gin.Context) {
+	var req ImportTextRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	if len(req.Text) > maxTextImportBytes {
+		c.Error(apperrors.BadRequest(fmt.Sprintf("Text can be at most %d bytes", maxTextImportBytes)))
+		return
+	}
+
+	delimiter := req.Delimiter
+	if delimiter == "" {
+		delimiter = "\t"
+	}
+	if strings.Contains(delimiter, "\n") {
+		c.Error(apperrors.BadRequest("Delimiter can't contain a line break"))
+		return
+	}
+
+	entries, issues := parseTextCards(req.Text, delimiter)
+	if len(entries)+len(issues) > maxBulkImportCards {
+		c.Error(apperrors.BadRequest(fmt.Sprintf("At most %d lines can be imported at once", maxBulkImportCards)))
+		return
+	}
+	if len(entries) == 0 {
+		c.Error(apperrors.New(http.StatusBadRequest, "invalid_import", "No line could be turned into a card, nothing was imported", nil).
+			WithDetails(issues))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	// Verify the deck exists and the user may edit its cards
+	var deck models.Deck
+	if err := h.db.First(&deck, req.DeckID).Error; err != nil {
+		c.Error(apperrors.NotFound("Deck not found or you don't have permission to add cards to it"))
+		return
+	}
+	allowed, err := canEditDeck(h.db, deck, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.NotFound("Deck not found or you don't have permission to add cards to it"))
+		return
+	}
+
+	// Import in one transaction, retried as a whole if the database is busy
+	var importedCards []models.FlashCard
+	var newCardCount int
+	err = withRetry(h.db, func(tx *gorm.DB) error {
+		importedCards = make([]models.FlashCard, 0, len(entries))
+		for _, entry := range entries {
+			importedCards = append(importedCards, models.FlashCard{
+				DeckID:          deck.ID,
+				FrontContent:    entry.FrontContent,
+				BackContent:     entry.BackContent,
+				ContentType:     models.ContentText,
+				DifficultyLevel: 0.5, // default difficulty
+			})
+		}
+		if err := tx.Create(&importedCards).Error; err != nil {
+			return apperrors.Internal("Failed to import cards", err)
+		}
+
+		if err := tx.Model(&deck).Update("card_count", gorm.Expr("card_count + ?", len(importedCards))).Error; err != nil {
+			return apperrors.Internal("Failed to update deck card count", err)
+		}
+		if err := tx.Model(&models.Deck{}).Where("id = ?", deck.ID).Select("card_count").Scan(&newCardCount).Error; err != nil {
+			return apperrors.Internal("Failed to update deck card count", err)
+		}
+		return nil
+	})
+	if err != nil {
+		c.Error(txError(err, "Failed to process import"))
+		return
+	}
+
+	invalidateDeckCache(c.Request.Context(), h.cache, deck.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Cards imported successfully",
+		"imported":  len(importedCards),
+		"malformed": issues,
+		"cards":     importedCards,
+		"new_count": newCardCount,
+	})
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/models"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// A shared quiz works as a template: the original quiz defines the question set, and
+// every invited user gets their own attempt (a Quiz with TemplateID set) so results
+// are stored separately and can be ranked against each other.
+
+// canAccessTemplate -> The template owner and invited users may attempt it and see its leaderboard
+func canAccessTemplate(db *gorm.DB, template models.Quiz, userID uint) (bool, error) {
+	if template.UserID == userID {
+		return true, nil
+	}
+
+	var count int64
+	if err := db.Model(&models.QuizInvite{}).Where("quiz_id = ? AND user_id = ?", template.ID, userID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// loadTemplate -> Loads the quiz from the :id param, rejecting attempts (only originals can be shared)
+func (h *QuizHandler) loadTemplate(c *gin.Context) (models.Quiz, bool) {
+	var quiz models.Quiz
+
+	quizID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid quiz ID"))
+		return quiz, false
+	}
+
+	if err := h.db.First(&quiz, quizID).Error; err != nil {
+		c.Error(apperrors.NotFound("Quiz not found"))
+		return quiz, false
+	}
+
+	if quiz.TemplateID != nil {
+		c.Error(apperrors.BadRequest("This quiz is an attempt of another quiz, use the original quiz instead"))
+		return quiz, false
+	}
+
+	return quiz, true
+}
+
+// InviteToQuizRequest -> Struct for inviting a user to a shared quiz
+type InviteToQuizRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+// InviteToQuiz -> Handler to let another user attempt a quiz and see its leaderboard
+func (h *QuizHandler) InviteToQuiz(c *gin.Context) {
+	var req InviteToQuizRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	template, ok := h.loadTemplate(c)
+	if !ok {
+		return
+	}
+
+	// Only the owner can invite others
+	if template.UserID != userID.(uint) {
+		c.Error(apperrors.Forbidden("You don't have permission to share this quiz"))
+		return
+	}
+
+	invitee, err := models.FindUserByUsername(h.db, req.Username)
+	if err != nil {
+		c.Error(apperrors.NotFound("User not found"))
+		return
+	}
+
+	if invitee.ID == template.UserID {
+		c.Error(apperrors.BadRequest("You can't invite yourself"))
+		return
+	}
+
+	invite := models.QuizInvite{QuizID: template.ID, UserID: invitee.ID}
+	if err := h.db.Where(invite).FirstOrCreate(&invite).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to invite user", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "User invited successfully",
+		"invite":  invite,
+	})
+}
+
+// StartQuizAttempt -> Handler to create the caller's own attempt of a shared quiz
+func (h *QuizHandler) StartQuizAttempt(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	template, ok := h.loadTemplate(c)
+	if !ok {
+		return
+	}
+
+	allowed, err := canAccessTemplate(h.db, template, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check quiz access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to attempt this quiz"))
+		return
+	}
+
+	// Every attempt uses the same question set as the template, a shuffled one in its own order
+	var templateQuestions []models.QuizQuestion
+	if err := h.db.Where("quiz_id = ?", template.ID).Order(questionOrder).Find(&templateQuestions).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve quiz questions", err))
+		return
+	}
+
+	// Begin transaction to create the attempt and its questions
+	tx := h.db.Begin()
+
+	attempt := models.Quiz{
+		UserID:             userID.(uint),
+		DeckID:             template.DeckID,
+		DeckIDs:            template.DeckIDs,
+		Title:              template.Title,
+		Description:        template.Description,
+		TotalQuestions:     len(templateQuestions),
+		TimeLimitSeconds:   template.TimeLimitSeconds,
+		PerQuestionSeconds: template.PerQuestionSeconds,
+		TemplateID:         &template.ID,
+		Shuffle:            template.Shuffle,
+	}
+
+	if err := tx.Create(&attempt).Error; err != nil {
+		tx.Rollback()
+		c.Error(apperrors.Internal("Failed to create quiz attempt", err))
+		return
+	}
+
+	questions := make([]models.QuizQuestion, 0, len(templateQuestions))
+	for _, templateQuestion := range templateQuestions {
+		questions = append(questions, models.QuizQuestion{
+			QuizID:          attempt.ID,
+			CardID:          templateQuestion.CardID,
+			DeckID:          templateQuestion.DeckID,
+			QuestionType:    templateQuestion.QuestionType,
+			Prompt:          templateQuestion.Prompt,
+			ExpectedAnswer:  templateQuestion.ExpectedAnswer,
+			Statement:       templateQuestion.Statement,
+			StatementIsTrue: templateQuestion.StatementIsTrue,
+		})
+	}
+	assignPositions(questions, attempt.Shuffle)
+
+	for _, question := range questions {
+		if err := tx.Create(&question).Error; err != nil {
+			tx.Rollback()
+			c.Error(apperrors.Internal("Failed to create quiz questions", err))
+			return
+		}
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.Error(apperrors.Internal("Failed to finalize quiz attempt", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Quiz attempt created successfully",
+		"quiz": gin.H{
+			"id":                   attempt.ID,
+			"template_id":          template.ID,
+			"title":                attempt.Title,
+			"description":          attempt.Description,
+			"total_questions":      attempt.TotalQuestions,
+			"time_limit_seconds":   attempt.TimeLimitSeconds,
+			"per_question_seconds": attempt.PerQuestionSeconds,
+			"shuffle":              attempt.Shuffle,
+		},
+	})
+}
+
+// GetQuizLeaderboard -> Handler to rank the completed attempts of a shared quiz by score, then time
+// taken. Only each user's first completed attempt is ranked, later ones are retakes with the
+// answers already seen
+func (h *QuizHandler) GetQuizLeaderboard(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	template, ok := h.loadTemplate(c)
+	if !ok {
+		return
+	}
+
+	allowed, err := canAccessTemplate(h.db, template, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check quiz access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to view this leaderboard"))
+		return
+	}
+
+	// The owner's own run of the template counts as an attempt too
+	var attempts []models.Quiz
+	if err := h.db.Preload("User").
+		Where("(id = ? OR template_id = ?) AND completed_at IS NOT NULL", template.ID, template.ID).
+		Find(&attempts).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve leaderboard", err))
+		return
+	}
+
+	sort.Slice(attempts, func(i, j int) bool {
+		if !attempts[i].CompletedAt.Equal(*attempts[j].CompletedAt) {
+			return attempts[i].CompletedAt.Before(*attempts[j].CompletedAt)
+		}
+		return attempts[i].ID < attempts[j].ID
+	})
+	ranked := make(map[uint]bool, len(attempts))
+	attempts = slices.DeleteFunc(attempts, func(q models.Quiz) bool {
+		if ranked[q.UserID] {
+			return true
+		}
+		ranked[q.UserID] = true
+		return false
+	})
+
+	// Time taken runs from the first answer (or creation for untimed attempts) to completion
+	durationOf := func(q models.Quiz) time.Duration {
+		start := q.CreatedAt
+		if q.StartedAt != nil {
+			start = *q.StartedAt
+		}
+		return q.CompletedAt.Sub(start)
+	}
+
+	sort.SliceStable(attempts, func(i, j int) bool {
+		if attempts[i].Score != attempts[j].Score {
+			return attempts[i].Score > attempts[j].Score
+		}
+		return durationOf(attempts[i]) < durationOf(attempts[j])
+	})
+
+	entries := make([]gin.H, 0, len(attempts))
+	for i, attempt := range attempts {
+		entries = append(entries, gin.H{
+			"rank":               i + 1,
+			"quiz_id":            attempt.ID,
+			"user_id":            attempt.UserID,
+			"username":           attempt.User.Username,
+			"score":              attempt.Score,
+			"correct_answers":    attempt.CorrectAnswers,
+			"total_questions":    attempt.TotalQuestions,
+			"time_taken_seconds": int(durationOf(attempt).Seconds()),
+			"completed_at":       attempt.CompletedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"quiz_id":     template.ID,
+		"title":       template.Title,
+		"leaderboard": entries,
+	})
+}
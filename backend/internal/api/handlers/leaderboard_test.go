@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/models"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestQuizAttemptsRetakesNotRanked(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	deck := createTestDeck(t, db, alice.ID, "Capitals", "France", "Spain")
+
+	routers := make(map[uint]*gin.Engine)
+	for _, user := range []models.User{alice, bob} {
+		r := newTestRouter(user.ID)
+		quizzes := NewQuizHandler(db)
+		r.POST("/quizzes", quizzes.CreateQuiz)
+		r.GET("/quizzes/:id", quizzes.GetQuiz)
+		r.POST("/quizzes/:id/invite", quizzes.InviteToQuiz)
+		r.POST("/quizzes/:id/attempt", quizzes.StartQuizAttempt)
+		r.GET("/quizzes/:id/leaderboard", quizzes.GetQuizLeaderboard)
+		routers[user.ID] = r
+	}
+	// Fails the test unless the request as the given user gets the wanted status
+	expect := func(user models.User, method, path string, body any, want int) map[string]any {
+		t.Helper()
+		code, out := doJSON(t, routers[user.ID], method, path, body)
+		if code != want {
+			t.Fatalf("%s %s as %s: status = %d, want %d: %v", method, path, user.Username, code, want, out)
+		}
+		return out
+	}
+
+	out := expect(alice, http.MethodPost, "/quizzes", map[string]any{"deck_id": deck.ID, "title": "Capitals"}, http.StatusCreated)
+	templatePath := fmt.Sprintf("/quizzes/%v", out["quiz"].(map[string]any)["id"])
+	expect(alice, http.MethodPost, templatePath+"/invite", map[string]any{"username": "bob"}, http.StatusCreated)
+
+	// Two attempts by bob, the retake made with the answers known scores better
+	completedAt := time.Now().Add(-time.Hour)
+	for i, score := range []float64{50, 100} {
+		out := expect(bob, http.MethodPost, templatePath+"/attempt", nil, http.StatusCreated)
+		attemptPath := fmt.Sprintf("/quizzes/%v", out["quiz"].(map[string]any)["id"])
+
+		// Even the default full view keeps an unfinished attempt's answers hidden
+		out = expect(bob, http.MethodGet, attemptPath, nil, http.StatusOK)
+		quiz := out["quiz"].(map[string]any)
+		if quiz["answers_hidden"] != true {
+			t.Errorf("attempt %d: answers_hidden = %v, want true", i, quiz["answers_hidden"])
+		}
+		for _, question := range quiz["questions"].([]any) {
+			if answer, ok := question.(map[string]any)["answer"]; ok {
+				t.Errorf("attempt %d: answer %q served before completion", i, answer)
+			}
+		}
+
+		at := completedAt.Add(time.Duration(i) * time.Minute)
+		if err := db.Model(&models.Quiz{}).Where("id = ?", quiz["id"]).
+			Updates(map[string]any{"completed_at": at, "score": score}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out = expect(bob, http.MethodGet, templatePath+"/leaderboard", nil, http.StatusOK)
+	entries := out["leaderboard"].([]any)
+	if len(entries) != 1 {
+		t.Fatalf("leaderboard has %d entries, want bob's first attempt only: %v", len(entries), entries)
+	}
+	if entry := entries[0].(map[string]any); entry["username"] != "bob" || entry["score"] != float64(50) {
+		t.Errorf("ranked %v with score %v, want bob's first attempt with 50", entry["username"], entry["score"])
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/answermatch"
+	"FlashQuiz/internal/models"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// normalizeContent -> Trims, collapses internal whitespace and lowercases card content
+// so duplicate checks aren't fooled by formatting differences
+func normalizeContent(content string) string {
+	return strings.ToLower(strings.Join(strings.Fields(content), " "))
+}
+
+// cleanAcceptableAnswers -> Trims the aliases and drops blank ones and those repeating the back
+// content or each other. Spellings differing only in case are kept, exact matching tells them apart
+func cleanAcceptableAnswers(aliases []string, backContent string) []string {
+	seen := map[string]bool{strings.TrimSpace(backContent): true}
+	cleaned := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		if alias == "" || seen[alias] {
+			continue
+		}
+		seen[alias] = true
+		cleaned = append(cleaned, alias)
+	}
+	return cleaned
+}
+
+// answerMatchConfig -> The config a question's answers are graded with: its deck's own, or the
+// user's preferred match mode when the deck doesn't set one
+func answerMatchConfig(db *gorm.DB, deckID uint, settings models.UserSettings) (answermatch.Config, error) {
+	var deck models.Deck
+	// Unscoped so questions from a trashed deck are still graded the way the deck says
+	if err := db.Unscoped().Select("id", "answer_match").First(&deck, deckID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return answermatch.Config{}, err
+	}
+	if deck.AnswerMatch.IsSet() {
+		return deck.AnswerMatch, nil
+	}
+	return answermatch.Config{Mode: settings.QuizMatchMode}, nil
+}
+
+// deckFrontContents -> Returns the set of normalized front contents already present in a deck
+func deckFrontContents(db *gorm.DB, deckID uint) (map[string]bool, error) {
+	var fronts []string
+	if err := db.Model(&models.FlashCard{}).Where("deck_id = ?", deckID).Pluck("front_content", &fronts).Error; err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(fronts))
+	for _, front := range fronts {
+		seen[normalizeContent(front)] = true
+	}
+	return seen, nil
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/models"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// cardNoteParam -> Parses the :id param as a card ID, writing the error and returning false if it's invalid
+func cardNoteParam(c *gin.Context) (uint, bool) {
+	cardID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid card ID"))
+		return 0, false
+	}
+	return uint(cardID), true
+}
+
+// GetCardNote -> Handler to get the caller's private note on a card
+func (h *CardHandler) GetCardNote(c *gin.Context) {
+	cardID, ok := cardNoteParam(c)
+	if !ok {
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var note models.CardNote
+	if err := h.db.Where("user_id = ? AND card_id = ?", userID, cardID).First(&note).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.Error(apperrors.NotFound("You haven't written a note on this card"))
+			return
+		}
+		c.Error(apperrors.Internal("Failed to retrieve note", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"note": note,
+	})
+}
+
+// SaveCardNoteRequest -> Struct for writing a note on a card
+type SaveCardNoteRequest struct {
+	Note string `json:"note" binding:"required,max=10000"`
+}
+
+// SaveCardNote -> Handler to write or replace the caller's private note on a card they can study
+func (h *CardHandler) SaveCardNote(c *gin.Context) {
+	cardID, ok := cardNoteParam(c)
+	if !ok {
+		return
+	}
+
+	var req SaveCardNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	text := strings.TrimSpace(req.Note)
+	if text == "" {
+		c.Error(apperrors.BadRequest("Note can't be blank, delete it instead"))
+		return
+	}
+
+	var card models.FlashCard
+	if err := h.db.Preload("Deck").First(&card, cardID).Error; err != nil {
+		c.Error(apperrors.NotFound("Flashcard not found"))
+		return
+	}
+
+	allowed, err := canViewDeck(h.db, card.Deck, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to view this flashcard"))
+		return
+	}
+
+	note := models.CardNote{UserID: userID.(uint), CardID: card.ID}
+	if err := h.db.Where(&note).Assign(models.CardNote{Note: text}).FirstOrCreate(&note).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to save note", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Note saved successfully",
+		"note":    note,
+	})
+}
+
+// DeleteCardNote -> Handler to delete the caller's private note on a card
+func (h *CardHandler) DeleteCardNote(c *gin.Context) {
+	cardID, ok := cardNoteParam(c)
+	if !ok {
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	// Hard delete so a new note can be written later
+	result := h.db.Unscoped().Where("user_id = ? AND card_id = ?", userID, cardID).Delete(&models.CardNote{})
+	if result.Error != nil {
+		c.Error(apperrors.Internal("Failed to delete note", result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(apperrors.NotFound("You haven't written a note on this card"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Note deleted successfully",
+	})
+}
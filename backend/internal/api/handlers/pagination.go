@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setPaginationHeaders -> Sets X-Total-Count and a Link header pointing at the first, previous, next
+// and last pages, so clients can page through a list without reading the body. The links repeat
+// the request's own path and query with only page and page_size changed
+func setPaginationHeaders(c *gin.Context, page, pageSize int, total int64) {
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+
+	lastPage := int((total + int64(pageSize) - 1) / int64(pageSize))
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	link := func(target int, rel string) string {
+		u := *c.Request.URL
+		query := u.Query()
+		query.Set("page", strconv.Itoa(target))
+		query.Set("page_size", strconv.Itoa(pageSize))
+		u.RawQuery = query.Encode()
+		return fmt.Sprintf(`<%s>; rel="%s"`, u.RequestURI(), rel)
+	}
+
+	links := []string{link(1, "first")}
+	if page > 1 {
+		links = append(links, link(min(page-1, lastPage), "prev"))
+	}
+	if page < lastPage {
+		links = append(links, link(page+1, "next"))
+	}
+	links = append(links, link(lastPage, "last"))
+	c.Header("Link", strings.Join(links, ", "))
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/answermatch"
+	"FlashQuiz/internal/models"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// blankMarker -> Three or more underscores in a card's front mark the word to fill in
+var blankMarker = regexp.MustCompile(`_{3,}`)
+
+// supportsQuestionType -> Whether a card can be asked as the given question type
+func supportsQuestionType(card models.FlashCard, questionType string) bool {
+	switch questionType {
+	case models.QuestionFillBlank:
+		return blankMarker.MatchString(card.FrontContent) && strings.TrimSpace(card.BackContent) != ""
+	default:
+		return true
+	}
+}
+
+// fitsQuestionTypes -> Whether a card can be asked as at least one of the question types
+func fitsQuestionTypes(card models.FlashCard, questionTypes []string) bool {
+	for _, questionType := range questionTypes {
+		if supportsQuestionType(card, questionType) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectQuizCards -> Picks up to count random cards (all when count is 0) that can be asked as
+// at least one of the requested question types
+func selectQuizCards(cards []models.FlashCard, questionTypes []string, count int) []models.FlashCard {
+	selected := make([]models.FlashCard, 0, len(cards))
+	for _, card := range cards {
+		if fitsQuestionTypes(card, questionTypes) {
+			selected = append(selected, card)
+		}
+	}
+
+	rand.Shuffle(len(selected), func(i, j int) {
+		selected[i], selected[j] = selected[j], selected[i]
+	})
+	if count > 0 && len(selected) > count {
+		selected = selected[:count]
+	}
+	return selected
+}
+
+// buildQuizQuestions -> Turns the selected cards into questions, rotating through the requested
+// question types so the quiz gets the mix the client asked for. A card that can't be asked as
+// the next type in the rotation gets the next type it does support. deckCards are the siblings
+// true/false statements draw their wrong answers from
+func buildQuizQuestions(quizID uint, cards, deckCards []models.FlashCard, questionTypes []string) []models.QuizQuestion {
+	questions := make([]models.QuizQuestion, 0, len(cards))
+	next := 0
+	var trueFalse []int
+	for _, card := range cards {
+		questionType := models.QuestionRecall
+		for i := range questionTypes {
+			candidate := questionTypes[(next+i)%len(questionTypes)]
+			if supportsQuestionType(card, candidate) {
+				questionType = candidate
+				next = (next + i + 1) % len(questionTypes)
+				break
+			}
+		}
+
+		question := models.QuizQuestion{
+			QuizID:       quizID,
+			CardID:       card.ID,
+			DeckID:       card.DeckID,
+			QuestionType: questionType,
+		}
+		switch questionType {
+		case models.QuestionFillBlank:
+			question.Prompt = card.FrontContent
+			question.ExpectedAnswer = card.BackContent
+		case models.QuestionTrueFalse:
+			trueFalse = append(trueFalse, len(questions))
+		}
+		questions = append(questions, question)
+	}
+
+	// Half the true/false statements (rounded at random) are true, the rest use a sibling's back.
+	// A card without a usable distractor falls back to a true statement
+	truths := make([]bool, len(trueFalse))
+	for i := range truths {
+		truths[i] = i < len(truths)/2 || (i == len(truths)/2 && len(truths)%2 == 1 && rand.Intn(2) == 0)
+	}
+	rand.Shuffle(len(truths), func(i, j int) {
+		truths[i], truths[j] = truths[j], truths[i]
+	})
+
+	for i, index := range trueFalse {
+		card := cards[index]
+		question := &questions[index]
+		question.Prompt = card.FrontContent
+		question.Statement = card.BackContent
+		question.StatementIsTrue = true
+
+		if !truths[i] {
+			if distractor, ok := pickDistractor(card, deckCards); ok {
+				question.Statement = distractor
+				question.StatementIsTrue = false
+			}
+		}
+		question.ExpectedAnswer = strconv.FormatBool(question.StatementIsTrue)
+	}
+
+	return questions
+}
+
+// assignPositions -> Numbers the questions in the order they're served: as given, or in a random
+// order when shuffling. Stored so the order stays the same on every fetch of one attempt
+func assignPositions(questions []models.QuizQuestion, shuffle bool) {
+	order := make([]int, len(questions))
+	for i := range order {
+		order[i] = i
+	}
+	if shuffle {
+		order = rand.Perm(len(questions))
+	}
+	for i := range questions {
+		questions[i].Position = order[i]
+	}
+}
+
+// questionOrder -> Ordering for loading a quiz's questions in the order they're served
+const questionOrder = "position ASC, id ASC"
+
+// pickDistractor -> A random sibling back that differs from the card's own, preferring siblings
+// from the same deck and then of the same content type so the wrong statement stays plausible
+func pickDistractor(card models.FlashCard, deckCards []models.FlashCard) (string, bool) {
+	own := normalizeContent(card.BackContent)
+	// Candidates by preference: same deck and type, same deck, same type, anything
+	var candidates [4][]string
+	for _, sibling := range deckCards {
+		if sibling.ID == card.ID || normalizeContent(sibling.BackContent) == own || strings.TrimSpace(sibling.BackContent) == "" {
+			continue
+		}
+		rank := 0
+		if sibling.DeckID != card.DeckID {
+			rank += 2
+		}
+		if sibling.ContentType != card.ContentType {
+			rank++
+		}
+		candidates[rank] = append(candidates[rank], sibling.BackContent)
+	}
+
+	for _, backs := range candidates {
+		if len(backs) > 0 {
+			return backs[rand.Intn(len(backs))], true
+		}
+	}
+	return "", false
+}
+
+// questionPrompt -> The text shown to the user for a question
+func questionPrompt(q models.QuizQuestion) string {
+	if q.Prompt != "" {
+		return q.Prompt
+	}
+	return q.FlashCard.FrontContent
+}
+
+// expectedAnswer -> The answer a question is graded against
+func expectedAnswer(q models.QuizQuestion) string {
+	if q.ExpectedAnswer != "" {
+		return q.ExpectedAnswer
+	}
+	return q.FlashCard.BackContent
+}
+
+// gradeAnswer -> Whether the given answer is correct for the question, and the accepted answer it
+// matched: the expected answer or one of the card's acceptable answers, tried in that order.
+// The acceptable answers are alternatives for recalling the card's back, so they only apply to
+// recall questions, never to the word filling a fill-in-the-blank front. Fill-in-the-blank
+// answers are always at least compared normalized, since a typed word shouldn't fail on casing or
+// spacing. True/false answers must be "true" or "false" and match the stored boolean
+func gradeAnswer(q models.QuizQuestion, given string, match answermatch.Config) (bool, string) {
+	if q.QuestionType == models.QuestionTrueFalse {
+		correct := isTrueFalseAnswer(given) && strings.EqualFold(strings.TrimSpace(given), strconv.FormatBool(q.StatementIsTrue))
+		return correct, ""
+	}
+
+	accepted := []string{expectedAnswer(q)}
+	if q.QuestionType != models.QuestionFillBlank {
+		accepted = append(accepted, q.FlashCard.AcceptableAnswers...)
+	}
+	for _, answer := range accepted {
+		if match.Match(given, answer) ||
+			(q.QuestionType == models.QuestionFillBlank && normalizeContent(given) == normalizeContent(answer)) {
+			return true, answer
+		}
+	}
+	return false, ""
+}
+
+// isTrueFalseAnswer -> Whether an answer is "true" or "false", ignoring case and surrounding space
+func isTrueFalseAnswer(given string) bool {
+	answer := strings.ToLower(strings.TrimSpace(given))
+	return answer == "true" || answer == "false"
+}
+
+// filledSentence -> A fill-in-the-blank prompt with the expected answer written into the blank
+func filledSentence(q models.QuizQuestion) string {
+	return blankMarker.ReplaceAllLiteralString(questionPrompt(q), expectedAnswer(q))
+}
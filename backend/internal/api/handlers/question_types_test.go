@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/answermatch"
+	"FlashQuiz/internal/models"
+	"testing"
+)
+
+func TestGradeAnswerAcceptableAnswers(t *testing.T) {
+	card := models.FlashCard{
+		FrontContent:      "The capital of the United States is ___",
+		BackContent:       "Washington, D.C.",
+		AcceptableAnswers: []string{"Washington", "DC"},
+	}
+	recall := models.QuizQuestion{QuestionType: models.QuestionRecall, FlashCard: card}
+	fillBlank := models.QuizQuestion{QuestionType: models.QuestionFillBlank, Prompt: card.FrontContent, ExpectedAnswer: card.BackContent, FlashCard: card}
+	exact := answermatch.Config{Mode: answermatch.ModeExact}
+
+	tests := []struct {
+		name        string
+		question    models.QuizQuestion
+		given       string
+		wantCorrect bool
+		wantMatched string
+	}{
+		{"recall back", recall, "Washington, D.C.", true, "Washington, D.C."},
+		{"recall alias", recall, "DC", true, "DC"},
+		{"recall wrong", recall, "New York", false, ""},
+		{"fill blank back", fillBlank, "washington, d.c.", true, "Washington, D.C."},
+		{"fill blank ignores aliases", fillBlank, "DC", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			correct, matched := gradeAnswer(tt.question, tt.given, exact)
+			if correct != tt.wantCorrect || matched != tt.wantMatched {
+				t.Errorf("gradeAnswer(%q) = %v, %q, want %v, %q", tt.given, correct, matched, tt.wantCorrect, tt.wantMatched)
+			}
+		})
+	}
+}
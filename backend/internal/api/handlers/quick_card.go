@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/models"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// inboxDeckTitle -> Title of the deck quick captures go to when the user hasn't picked a default deck
+const inboxDeckTitle = "Inbox"
+
+// QuickCardRequest -> Struct for capturing a card without choosing a deck
+type QuickCardRequest struct {
+	FrontContent string `json:"front_content" binding:"required"`
+	BackContent  string `json:"back_content" binding:"required"`
+	Explanation  string `json:"explanation" binding:"max=5000"`
+	ContentType  string `json:"content_type" binding:"omitempty,content_type"`
+}
+
+// ownedDeck -> Loads a live deck by ID if the user owns it, nil when it's gone or someone else's
+func ownedDeck(db *gorm.DB, deckID, userID uint) (*models.Deck, error) {
+	var deck models.Deck
+	err := db.Where("id = ? AND user_id = ?", deckID, userID).First(&deck).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &deck, nil
+}
+
+// defaultDeck -> The deck quick captures go to: the user's default deck while it still exists,
+// otherwise their Inbox deck, created on first use and saved as the new default
+func defaultDeck(db *gorm.DB, userID uint) (models.Deck, error) {
+	settings, err := loadUserSettings(db, userID)
+	if err != nil {
+		return models.Deck{}, err
+	}
+
+	if settings.DefaultDeckID != nil {
+		deck, err := ownedDeck(db, *settings.DefaultDeckID, userID)
+		if err != nil {
+			return models.Deck{}, err
+		}
+		if deck != nil {
+			return *deck, nil
+		}
+	}
+
+	inbox := models.Deck{Title: inboxDeckTitle, UserID: userID}
+	if err := db.Where(&inbox).Attrs(models.Deck{Description: "Cards captured on the go, waiting to be filed"}).FirstOrCreate(&inbox).Error; err != nil {
+		return models.Deck{}, err
+	}
+	if err := db.Model(&settings).Update("default_deck_id", inbox.ID).Error; err != nil {
+		return models.Deck{}, err
+	}
+	return inbox, nil
+}
+
+// QuickCreateCard -> Handler to add a card to the caller's default deck, so capturing a card
+// doesn't start with picking a deck
+func (h *CardHandler) QuickCreateCard(c *gin.Context) {
+	var req QuickCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	deck, err := defaultDeck(h.db, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to find default deck", err))
+		return
+	}
+
+	card, ok := h.insertCard(c, deck, CreateCardRequest{
+		DeckID:       deck.ID,
+		FrontContent: req.FrontContent,
+		BackContent:  req.BackContent,
+		Explanation:  req.Explanation,
+		ContentType:  req.ContentType,
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Flashcard created successfully",
+		"card":    card,
+		"deck": gin.H{
+			"id":    deck.ID,
+			"title": deck.Title,
+		},
+	})
+}
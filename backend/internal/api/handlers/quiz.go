@@ -1,9 +1,15 @@
 package handlers
 
 import (
+	"FlashQuiz/internal/api/apperrors"
 	"FlashQuiz/internal/models"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -20,243 +26,829 @@ func NewQuizHandler(db *gorm.DB) *QuizHandler {
 
 // CreateQuizRequest -> Struct for quiz creation request
 type CreateQuizRequest struct {
-	DeckID      uint   `json:"deck_id" binding:"required"`
-	Title       string `json:"title" binding:"required"`
-	Description string `json:"description"`
-	CardCount   int    `json:"card_count"` // Number of cards to include in quiz, 0 means all
+	DeckID             uint   `json:"deck_id"`                                           // A single deck, or
+	DeckIDs            []uint `json:"deck_ids" binding:"omitempty,max=20,dive,required"` // several decks to draw cards from
+	Title              string `json:"title" binding:"required"`
+	Description        string `json:"description"`
+	CardCount          *int   `json:"card_count" binding:"omitempty,min=1"`           // Number of cards to include in quiz, omitted means all
+	ExactCount         bool   `json:"exact_count"`                                    // Fail rather than make a smaller quiz when too few cards fit
+	Source             string `json:"source" binding:"omitempty,oneof=random weak"`   // How cards are picked, defaults to random
+	TimeLimitSeconds   int    `json:"time_limit_seconds" binding:"omitempty,min=1"`   // Overall time limit, omitted means untimed
+	PerQuestionSeconds int    `json:"per_question_seconds" binding:"omitempty,min=1"` // Limit for each question, omitted means none
+	Shuffle            bool   `json:"shuffle"`                                        // Serve questions in a random order, each attempt its own
+	// Question types to mix, assigned to cards in rotation. Defaults to recall only
+	QuestionTypes []string `json:"question_types" binding:"omitempty,dive,oneof=recall fill_blank true_false"`
 }
 
-// CreateQuiz -> Handler to create a new quiz
+// Quiz card sources: random cards, or the caller's weakest cards for targeted practice
+const (
+	QuizSourceRandom = "random"
+	QuizSourceWeak   = "weak"
+)
+
+// weakCardMinReviews -> Cards reviewed fewer times than this say too little to be judged weak
+const weakCardMinReviews = 3
+
+// selectWeakCards -> Picks the user's weakest cards among cards that fit one of the question types,
+// lowest accuracy first with more lapses breaking ties, up to count (all when count is 0).
+// Cards without enough reviews, and cards that were never missed, are left out
+func selectWeakCards(db *gorm.DB, userID uint, cards []models.FlashCard, questionTypes []string, count int) ([]models.FlashCard, error) {
+	byID := make(map[uint]models.FlashCard, len(cards))
+	deckIDs := make([]uint, 0)
+	for _, card := range cards {
+		if fitsQuestionTypes(card, questionTypes) {
+			byID[card.ID] = card
+			if !slices.Contains(deckIDs, card.DeckID) {
+				deckIDs = append(deckIDs, card.DeckID)
+			}
+		}
+	}
+	if len(byID) == 0 {
+		return nil, nil
+	}
+
+	var progresses []models.CardProgress
+	if err := db.Where("user_id = ? AND review_count >= ? AND (correct_count < review_count OR lapses > 0)", userID, weakCardMinReviews).
+		Where("card_id IN (?)", db.Model(&models.FlashCard{}).Select("id").Where("deck_id IN ?", deckIDs)).
+		Find(&progresses).Error; err != nil {
+		return nil, err
+	}
+
+	accuracy := func(p models.CardProgress) float64 {
+		return float64(p.CorrectCount) / float64(p.ReviewCount)
+	}
+	sort.SliceStable(progresses, func(i, j int) bool {
+		if a, b := accuracy(progresses[i]), accuracy(progresses[j]); a != b {
+			return a < b
+		}
+		return progresses[i].Lapses > progresses[j].Lapses
+	})
+
+	selected := make([]models.FlashCard, 0, len(progresses))
+	for _, progress := range progresses {
+		if card, ok := byID[progress.CardID]; ok {
+			selected = append(selected, card)
+		}
+	}
+	if count > 0 && len(selected) > count {
+		selected = selected[:count]
+	}
+	return selected, nil
+}
+
+// idempotencyWindow -> How long an Idempotency-Key keeps pointing at the quiz it created
+const idempotencyWindow = 24 * time.Hour
+
+// createdQuizResponse -> The body CreateQuiz responds with, also used when replaying a request
+func createdQuizResponse(quiz models.Quiz) gin.H {
+	return gin.H{
+		"message": "Quiz created successfully",
+		"quiz": gin.H{
+			"id":                   quiz.ID,
+			"deck_ids":             quiz.DeckIDs,
+			"title":                quiz.Title,
+			"description":          quiz.Description,
+			"total_questions":      quiz.TotalQuestions,
+			"time_limit_seconds":   quiz.TimeLimitSeconds,
+			"per_question_seconds": quiz.PerQuestionSeconds,
+			"shuffle":              quiz.Shuffle,
+		},
+	}
+}
+
+// replayQuizCreation -> Responds with the quiz an earlier request with the same key created, if
+// there is one within the window. Reports whether a response was written. A key whose quiz has
+// since been deleted is dropped, so the request goes on to create a new quiz
+func (h *QuizHandler) replayQuizCreation(c *gin.Context, userID uint, key string) (bool, error) {
+	var record models.QuizIdempotencyKey
+	err := h.db.Where("user_id = ? AND key = ? AND created_at >= ?", userID, key, time.Now().Add(-idempotencyWindow).UTC()).
+		First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var quiz models.Quiz
+	err = h.db.First(&quiz, record.QuizID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, h.db.Unscoped().Delete(&record).Error
+	}
+	if err != nil {
+		return false, err
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	c.JSON(http.StatusCreated, createdQuizResponse(quiz))
+	return true, nil
+}
+
+// CreateQuiz -> Handler to create a new quiz. Requests carrying an Idempotency-Key header are
+// only carried out once per key, repeats get the quiz the first one created
 func (h *QuizHandler) CreateQuiz(c *gin.Context) {
 	var req CreateQuizRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperrors.Validation(err))
 		return
 	}
 
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
-	// Verify the deck exists and user has access to it
-	var deck models.Deck
-	if err := h.db.First(&deck, req.DeckID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+	idempotencyKey := strings.TrimSpace(c.GetHeader("Idempotency-Key"))
+	if len(idempotencyKey) > 255 {
+		c.Error(apperrors.BadRequest("Idempotency-Key must be at most 255 characters"))
 		return
 	}
+	if idempotencyKey != "" {
+		replayed, err := h.replayQuizCreation(c, userID.(uint), idempotencyKey)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to create quiz", err))
+			return
+		}
+		if replayed {
+			return
+		}
+	}
 
-	if !deck.IsPublic && deck.UserID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to create a quiz for this deck"})
+	deckIDs := req.DeckIDs
+	if req.DeckID > 0 {
+		if len(deckIDs) > 0 {
+			c.Error(apperrors.BadRequest("Send either deck_id or deck_ids, not both"))
+			return
+		}
+		deckIDs = []uint{req.DeckID}
+	}
+	if len(deckIDs) == 0 {
+		c.Error(apperrors.BadRequest("Either deck_id or deck_ids is required"))
 		return
 	}
 
-	// Get cards from the deck
-	var cards []models.FlashCard
-	query := h.db.Where("deck_id = ?", req.DeckID)
+	// Verify every deck exists and the user has access to it
+	seen := make(map[uint]bool, len(deckIDs))
+	uniqueDeckIDs := make([]uint, 0, len(deckIDs))
+	for _, deckID := range deckIDs {
+		if seen[deckID] {
+			continue
+		}
+		seen[deckID] = true
+
+		var deck models.Deck
+		if err := h.db.First(&deck, deckID).Error; err != nil {
+			c.Error(apperrors.NotFound(fmt.Sprintf("Deck %d not found", deckID)))
+			return
+		}
+
+		allowed, err := canViewDeck(h.db, deck, userID.(uint))
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to check deck access", err))
+			return
+		}
+		if !allowed {
+			c.Error(apperrors.Forbidden(fmt.Sprintf("You don't have permission to create a quiz for deck %d", deckID)))
+			return
+		}
+		uniqueDeckIDs = append(uniqueDeckIDs, deckID)
+	}
+	deckIDs = uniqueDeckIDs
 
-	// If card count is specified, limit the number of cards
-	cardCount := req.CardCount
-	if cardCount > 0 {
-		query = query.Order("RANDOM()").Limit(cardCount)
+	questionTypes := req.QuestionTypes
+	if len(questionTypes) == 0 {
+		questionTypes = []string{models.QuestionRecall}
 	}
 
-	if err := query.Find(&cards).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve flashcards"})
+	// Get cards from the decks, sampled together so every deck has the same chance per card
+	var deckCards []models.FlashCard
+	if err := h.db.Where("deck_id IN ?", deckIDs).Find(&deckCards).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve flashcards", err))
 		return
 	}
 
+	if len(deckCards) == 0 {
+		c.Error(apperrors.BadRequest("No cards available in the selected decks"))
+		return
+	}
+
+	cardCount := 0
+	if req.CardCount != nil {
+		cardCount = *req.CardCount
+	}
+
+	// Only cards that fit one of the requested question types are picked, limited to card_count if given
+	var cards []models.FlashCard
+	if req.Source == QuizSourceWeak {
+		var err error
+		cards, err = selectWeakCards(h.db, userID.(uint), deckCards, questionTypes, cardCount)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to retrieve weak cards", err))
+			return
+		}
+		if len(cards) == 0 {
+			c.Error(apperrors.BadRequest(fmt.Sprintf("No weak cards in the selected decks yet, cards need at least %d reviews with a miss to count", weakCardMinReviews)))
+			return
+		}
+	} else {
+		cards = selectQuizCards(deckCards, questionTypes, cardCount)
+	}
 	if len(cards) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No cards available in this deck"})
+		c.Error(apperrors.BadRequest("No cards in the selected decks suit the requested question types, fill_blank cards need a blank (___) in their front"))
 		return
 	}
 
+	// A smaller quiz than asked for is made anyway, with a warning, unless the client insists
+	var shortfall string
+	if cardCount > len(cards) {
+		if req.ExactCount {
+			c.Error(apperrors.BadRequest(fmt.Sprintf("Only %d of the %d requested cards are available", len(cards), cardCount)))
+			return
+		}
+		shortfall = fmt.Sprintf("Only %d of the %d requested cards are available, the quiz has %d questions", len(cards), cardCount, len(cards))
+	}
+
 	// Begin transaction to create quiz and questions
 	tx := h.db.Begin()
 
 	// Create the quiz
 	quiz := models.Quiz{
-		UserID:         userID.(uint),
-		DeckID:         req.DeckID,
-		Title:          req.Title,
-		Description:    req.Description,
-		TotalQuestions: len(cards),
+		UserID:             userID.(uint),
+		DeckID:             deckIDs[0],
+		DeckIDs:            deckIDs,
+		Title:              req.Title,
+		Description:        req.Description,
+		TotalQuestions:     len(cards),
+		TimeLimitSeconds:   req.TimeLimitSeconds,
+		PerQuestionSeconds: req.PerQuestionSeconds,
+		Shuffle:            req.Shuffle,
 	}
 
 	if err := tx.Create(&quiz).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create quiz"})
+		c.Error(apperrors.Internal("Failed to create quiz", err))
 		return
 	}
 
 	// Create quiz questions for each card
-	for _, card := range cards {
-		question := models.QuizQuestion{
-			QuizID:       quiz.ID,
-			CardID:       card.ID,
-			QuestionType: "recall", // Default question type
+	questions := buildQuizQuestions(quiz.ID, cards, deckCards, questionTypes)
+	assignPositions(questions, quiz.Shuffle)
+	for _, question := range questions {
+		if err := tx.Create(&question).Error; err != nil {
+			tx.Rollback()
+			c.Error(apperrors.Internal("Failed to create quiz questions", err))
+			return
 		}
+	}
 
-		if err := tx.Create(&question).Error; err != nil {
+	if idempotencyKey != "" {
+		// An expired record for the key is replaced, then the unique index stops a concurrent duplicate
+		if err := tx.Unscoped().Where("user_id = ? AND key = ? AND created_at < ?", userID, idempotencyKey, time.Now().Add(-idempotencyWindow).UTC()).Delete(&models.QuizIdempotencyKey{}).Error; err != nil {
+			tx.Rollback()
+			c.Error(apperrors.Internal("Failed to create quiz", err))
+			return
+		}
+		record := models.QuizIdempotencyKey{UserID: userID.(uint), Key: idempotencyKey, QuizID: quiz.ID}
+		if err := tx.Create(&record).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create quiz questions"})
+			// The same request won the race, answer with its quiz
+			if replayed, replayErr := h.replayQuizCreation(c, userID.(uint), idempotencyKey); replayErr == nil && replayed {
+				return
+			}
+			c.Error(apperrors.Internal("Failed to create quiz", err))
 			return
 		}
 	}
 
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to finalize quiz creation"})
+		c.Error(apperrors.Internal("Failed to finalize quiz creation", err))
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Quiz created successfully",
-		"quiz": gin.H{
-			"id":              quiz.ID,
-			"title":           quiz.Title,
-			"description":     quiz.Description,
-			"total_questions": quiz.TotalQuestions,
-		},
-	})
+	response := createdQuizResponse(quiz)
+	if shortfall != "" {
+		response["warning"] = shortfall
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// Quiz views: "full" includes answers and grading, "taking" hides both until the quiz is completed
+const (
+	QuizViewFull   = "full"
+	QuizViewTaking = "taking"
+)
+
+// GetQuizRequest -> Query parameters for fetching a quiz
+type GetQuizRequest struct {
+	View string `form:"view" binding:"omitempty,oneof=full taking"`
 }
 
-// GetQuiz -> Handler to get a quiz with its questions
+// GetQuiz -> Handler to get a quiz with its questions. With ?view=taking the answers and
+// correctness stay hidden until the quiz is completed, so a client can render it to the taker.
+// Attempts of a shared quiz always use the taking view, they're ranked against each other
 func (h *QuizHandler) GetQuiz(c *gin.Context) {
 	quizID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID"})
+		c.Error(apperrors.BadRequest("Invalid quiz ID"))
+		return
+	}
+
+	var req GetQuizRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperrors.Validation(err))
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
 	var quiz models.Quiz
 	if err := h.db.First(&quiz, quizID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Quiz not found"})
+		c.Error(apperrors.NotFound("Quiz not found"))
 		return
 	}
 
 	// Only the quiz creator can access it
 	if quiz.UserID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to access this quiz"})
+		c.Error(apperrors.Forbidden("You don't have permission to access this quiz"))
 		return
 	}
 
-	// Get all questions with their associated cards
+	// The first fetch starts the server-side clock of every question not seen before
+	if quiz.CompletedAt == nil {
+		if err := h.db.Model(&models.QuizQuestion{}).
+			Where("quiz_id = ? AND served_at IS NULL", quizID).
+			Update("served_at", time.Now()).Error; err != nil {
+			c.Error(apperrors.Internal("Failed to retrieve quiz questions", err))
+			return
+		}
+	}
+
+	// Get all questions with their associated cards, in the order they're served.
+	// Answers are graded by question ID, so the order never affects grading
 	var questions []models.QuizQuestion
-	if err := h.db.Where("quiz_id = ?", quizID).Preload("FlashCard").Find(&questions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve quiz questions"})
+	if err := h.db.Where("quiz_id = ?", quizID).Preload("FlashCard", withDeletedCards).Order(questionOrder).Find(&questions).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve quiz questions", err))
 		return
 	}
 
+	missingDecks, err := missingQuizDecks(h.db, quiz)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve quiz decks", err))
+		return
+	}
+
+	hideAnswers := (req.View == QuizViewTaking || quiz.TemplateID != nil) && quiz.CompletedAt == nil
+
 	// Format the response
 	formattedQuestions := make([]gin.H, 0, len(questions))
 	for _, q := range questions {
-		formattedQuestions = append(formattedQuestions, gin.H{
-			"id":           q.ID,
-			"question":     q.FlashCard.FrontContent,
-			"answer":       q.FlashCard.BackContent,
-			"content_type": q.FlashCard.ContentType,
-			"user_answer":  q.UserAnswer,
-			"is_correct":   q.IsCorrect,
-			"time_spent":   q.TimeSpent,
-		})
+		formattedQuestions = append(formattedQuestions, formatQuizQuestion(q, hideAnswers))
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"quiz": gin.H{
-			"id":              quiz.ID,
-			"title":           quiz.Title,
-			"description":     quiz.Description,
-			"created_at":      quiz.CreatedAt,
-			"completed_at":    quiz.CompletedAt,
-			"score":           quiz.Score,
-			"correct_answers": quiz.CorrectAnswers,
-			"total_questions": quiz.TotalQuestions,
-			"questions":       formattedQuestions,
+			"id":                   quiz.ID,
+			"deck_ids":             quiz.DeckIDs,
+			"title":                quiz.Title,
+			"description":          quiz.Description,
+			"created_at":           quiz.CreatedAt,
+			"started_at":           quiz.StartedAt,
+			"completed_at":         quiz.CompletedAt,
+			"score":                quiz.Score,
+			"correct_answers":      quiz.CorrectAnswers,
+			"total_questions":      quiz.TotalQuestions,
+			"time_limit_seconds":   quiz.TimeLimitSeconds,
+			"remaining_seconds":    quizRemainingSeconds(quiz),
+			"per_question_seconds": quiz.PerQuestionSeconds,
+			"shuffle":              quiz.Shuffle,
+			"answers_hidden":       hideAnswers,
+			"share_token":          quiz.ShareToken,
+			"orphaned":             len(missingDecks) > 0, // Some of its decks were deleted
+			"missing_deck_ids":     missingDecks,
+			"questions":            formattedQuestions,
 		},
 	})
 }
 
-// GetUserQuizzes -> Handler to get all quizzes for a user
+// formatQuizQuestion -> A question as quiz views return it, hideAnswers leaves out the expected
+// answer, the grading and the explanation. The card must be preloaded
+func formatQuizQuestion(q models.QuizQuestion, hideAnswers bool) gin.H {
+	question := gin.H{
+		"id":            q.ID,
+		"position":      q.Position,
+		"deck_id":       q.DeckID,
+		"question_type": q.QuestionType,
+		"question":      questionPrompt(q),
+		"statement":     q.Statement,
+		"content_type":  q.FlashCard.ContentType,
+		"user_answer":   q.UserAnswer,
+		"answered":      q.AnsweredAt != nil,
+		"time_spent":    q.TimeSpent,
+		"card_deleted":  q.FlashCard.DeletedAt.Valid,
+	}
+	if !hideAnswers {
+		question["answer"] = expectedAnswer(q)
+		question["is_correct"] = q.IsCorrect
+		question["explanation"] = q.FlashCard.Explanation
+	}
+	return question
+}
+
+// withDeletedCards -> Preload scope that still loads cards in the trash, so questions on a deleted
+// deck keep showing what was asked
+func withDeletedCards(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// missingQuizDecks -> The quiz's decks that were deleted after it was made. Quizzes outlive a deck
+// while it's in the trash, since restoring it makes them whole again, and go away when it's purged
+func missingQuizDecks(db *gorm.DB, quiz models.Quiz) ([]uint, error) {
+	deckIDs := quiz.DeckIDs
+	if len(deckIDs) == 0 {
+		deckIDs = []uint{quiz.DeckID}
+	}
+
+	var live []uint
+	if err := db.Model(&models.Deck{}).Where("id IN ?", deckIDs).Pluck("id", &live).Error; err != nil {
+		return nil, err
+	}
+
+	missing := make([]uint, 0)
+	for _, deckID := range deckIDs {
+		if !slices.Contains(live, deckID) {
+			missing = append(missing, deckID)
+		}
+	}
+	return missing, nil
+}
+
+// quizProgress -> How far along a quiz is, and the question to show next
+type quizProgress struct {
+	Total    int
+	Answered int
+	Position int                  // 1-based position of Question in serving order
+	Question *models.QuizQuestion // First unanswered question, nil when every question is answered
+}
+
+// nextQuizQuestion -> Counts a quiz's answered questions and loads the first unanswered one in
+// serving order, starting its server-side clock if it's shown for the first time. Completed
+// quizzes only get their counts
+func (h *QuizHandler) nextQuizQuestion(quiz models.Quiz) (quizProgress, error) {
+	// Which questions are answered, in order, to find the next one and its position
+	var order []models.QuizQuestion
+	if err := h.db.Select("id", "answered_at").Where("quiz_id = ?", quiz.ID).Order(questionOrder).Find(&order).Error; err != nil {
+		return quizProgress{}, err
+	}
+
+	progress := quizProgress{Total: len(order)}
+	next := -1
+	for i, q := range order {
+		if q.AnsweredAt != nil {
+			progress.Answered++
+		} else if next < 0 {
+			next = i
+		}
+	}
+	if quiz.CompletedAt != nil || next < 0 {
+		return progress, nil
+	}
+
+	var question models.QuizQuestion
+	if err := h.db.Preload("FlashCard", withDeletedCards).First(&question, order[next].ID).Error; err != nil {
+		return quizProgress{}, err
+	}
+
+	// Start the question's server-side clock the first time it's shown
+	if question.ServedAt == nil {
+		now := time.Now()
+		question.ServedAt = &now
+		if err := h.db.Model(&question).Update("served_at", now).Error; err != nil {
+			return quizProgress{}, err
+		}
+	}
+
+	progress.Position = next + 1
+	progress.Question = &question
+	return progress, nil
+}
+
+// quizRemainingSeconds -> Time left on a timed quiz, nil when untimed, the full limit before the
+// first answer starts the clock
+func quizRemainingSeconds(quiz models.Quiz) *int {
+	if quiz.TimeLimitSeconds <= 0 {
+		return nil
+	}
+	remaining := quiz.TimeLimitSeconds
+	if deadline, ok := quiz.Deadline(); ok {
+		remaining = max(0, int(time.Until(deadline).Seconds()))
+	}
+	return &remaining
+}
+
+// GetNextQuizQuestion -> Handler to get the first unanswered question of a quiz, in the order
+// questions are served, for clients that show one question at a time. Answers stay hidden, and
+// only this question's clock is started
+func (h *QuizHandler) GetNextQuizQuestion(c *gin.Context) {
+	quizID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid quiz ID"))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var quiz models.Quiz
+	if err := h.db.First(&quiz, quizID).Error; err != nil {
+		c.Error(apperrors.NotFound("Quiz not found"))
+		return
+	}
+
+	// Only the quiz creator can access it
+	if quiz.UserID != userID.(uint) {
+		c.Error(apperrors.Forbidden("You don't have permission to access this quiz"))
+		return
+	}
+
+	progress, err := h.nextQuizQuestion(quiz)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve quiz questions", err))
+		return
+	}
+
+	response := gin.H{
+		"quiz_id":   quiz.ID,
+		"total":     progress.Total,
+		"answered":  progress.Answered,
+		"completed": quiz.CompletedAt != nil,
+		"position":  nil,
+		"question":  nil,
+	}
+
+	switch {
+	case quiz.CompletedAt != nil:
+		response["message"] = "This quiz is already completed"
+	case progress.Question == nil:
+		response["message"] = "Every question is answered, complete the quiz to see your score"
+	default:
+		response["position"] = progress.Position
+		response["question"] = formatQuizQuestion(*progress.Question, true)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetUserQuizzesRequest -> Query parameters for listing a user's quizzes
+type GetUserQuizzesRequest struct {
+	DeckID    uint  `form:"deck_id"`
+	Completed *bool `form:"completed"` // true for completed quizzes, false for in-progress, omitted for both
+	Page      int   `form:"page" binding:"omitempty,min=1"`
+	PageSize  int   `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// GetUserQuizzes -> Handler to get a page of the user's quizzes, newest first
 func (h *QuizHandler) GetUserQuizzes(c *gin.Context) {
+	var req GetUserQuizzesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	page := req.Page
+	if page == 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize == 0 {
+		pageSize = 20
+	}
+
+	query := h.db.Model(&models.Quiz{}).Where("user_id = ?", userID)
+	if req.DeckID > 0 {
+		// Multi-deck quizzes match on any of their decks
+		query = query.Where("deck_id = ? OR id IN (?)", req.DeckID,
+			h.db.Model(&models.QuizQuestion{}).Select("quiz_id").Where("deck_id = ?", req.DeckID))
+	}
+	if req.Completed != nil {
+		if *req.Completed {
+			query = query.Where("completed_at IS NOT NULL")
+		} else {
+			query = query.Where("completed_at IS NULL")
+		}
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve quizzes", err))
 		return
 	}
 
 	var quizzes []models.Quiz
-	if err := h.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&quizzes).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve quizzes"})
+	if err := query.Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&quizzes).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve quizzes", err))
 		return
 	}
 
+	setPaginationHeaders(c, page, pageSize, total)
+
 	c.JSON(http.StatusOK, gin.H{
-		"quizzes": quizzes,
+		"quizzes":   quizzes,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
 	})
 }
 
 // SubmitAnswerRequest -> Struct for quiz answer submission
 type SubmitAnswerRequest struct {
+	QuizID     uint   `json:"quiz_id"` // Optional, rejects the answer if the question is from another quiz
 	QuestionID uint   `json:"question_id" binding:"required"`
 	Answer     string `json:"answer" binding:"required"`
-	TimeSpent  int    `json:"time_spent"`
+	TimeSpent  int    `json:"time_spent" binding:"omitempty,min=0,max=3600"` // Seconds, at most maxTimeSpentSeconds
 }
 
 // SubmitQuizAnswer -> Handler to submit an answer to a quiz question
 func (h *QuizHandler) SubmitQuizAnswer(c *gin.Context) {
 	var req SubmitAnswerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperrors.Validation(err))
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
 	// Find the question
 	var question models.QuizQuestion
 	if err := h.db.Preload("Quiz").Preload("FlashCard").First(&question, req.QuestionID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
+		c.Error(apperrors.NotFound("Question not found"))
+		return
+	}
+
+	// Preloads come back empty when the quiz or card has since been deleted
+	if question.Quiz.ID == 0 {
+		c.Error(apperrors.NotFound("Quiz not found"))
 		return
 	}
 
 	// Check that this question belongs to a quiz owned by the user
 	if question.Quiz.UserID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to answer this question"})
+		c.Error(apperrors.Forbidden("You don't have permission to answer this question"))
 		return
 	}
 
-	// Simple string comparison to check if the answer is correct
-	// In a real app, you might want more sophisticated answer checking
-	isCorrect := req.Answer == question.FlashCard.BackContent
+	if req.QuizID != 0 && req.QuizID != question.QuizID {
+		c.Error(apperrors.BadRequest("Question doesn't belong to this quiz"))
+		return
+	}
+
+	if question.FlashCard.ID == 0 {
+		c.Error(apperrors.NotFound("The card behind this question was deleted"))
+		return
+	}
+
+	if question.Quiz.CompletedAt != nil {
+		c.Error(apperrors.BadRequest("This quiz is already completed"))
+		return
+	}
+
+	if question.QuestionType == models.QuestionTrueFalse && !isTrueFalseAnswer(req.Answer) {
+		c.Error(apperrors.BadRequest("True/false questions must be answered with \"true\" or \"false\""))
+		return
+	}
+
+	// The clock of a timed quiz starts with its first answer
+	now := time.Now()
+	if question.Quiz.StartedAt == nil {
+		question.Quiz.StartedAt = &now
+		if err := h.db.Model(&question.Quiz).Update("started_at", now).Error; err != nil {
+			c.Error(apperrors.Internal("Failed to start quiz", err))
+			return
+		}
+	}
+
+	settings, err := loadUserSettings(h.db, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve settings", err))
+		return
+	}
+
+	// Compare using the deck's answer matching, or the user's preferred mode (exact by default)
+	deckID := question.DeckID
+	if deckID == 0 {
+		deckID = question.FlashCard.DeckID // Questions from before the deck was recorded
+	}
+	match, err := answerMatchConfig(h.db, deckID, settings)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve deck", err))
+		return
+	}
+	isCorrect, matchedAnswer := gradeAnswer(question, req.Answer, match)
+
+	// Answers submitted after the time limit are recorded but never count as correct
+	timeExpired := false
+	if deadline, ok := question.Quiz.Deadline(); ok && now.After(deadline) {
+		timeExpired = true
+		isCorrect = false
+	}
+
+	// Same for answers that took longer than the per-question limit
+	questionTimeExceeded := false
+	if question.Quiz.PerQuestionSeconds > 0 {
+		exceeded, err := exceedsQuestionLimit(h.db, question, req.TimeSpent, now)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to apply question time limit", err))
+			return
+		}
+		if exceeded {
+			questionTimeExceeded = true
+			isCorrect = false
+		}
+	}
 
 	// Update the question with the user's answer
 	question.UserAnswer = req.Answer
 	question.IsCorrect = isCorrect
 	question.TimeSpent = req.TimeSpent
+	question.AnsweredAt = &now
 
-	if err := h.db.Save(&question).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save answer"})
+	if err := h.db.Omit("Quiz", "FlashCard").Save(&question).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to save answer", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message":        "Answer submitted successfully",
-		"is_correct":     isCorrect,
-		"correct_answer": question.FlashCard.BackContent,
-	})
+	response := gin.H{
+		"message":                "Answer submitted successfully",
+		"is_correct":             isCorrect,
+		"correct_answer":         expectedAnswer(question),
+		"explanation":            question.FlashCard.Explanation,
+		"time_expired":           timeExpired,
+		"question_time_exceeded": questionTimeExceeded,
+	}
+	if question.QuestionType == models.QuestionFillBlank {
+		response["full_sentence"] = filledSentence(question)
+	}
+	if isCorrect {
+		// Which accepted answer it was, the expected one or one of the card's aliases
+		response["matched_answer"] = matchedAnswer
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// questionTimeGrace -> Allowance for network latency when timing questions server-side
+const questionTimeGrace = 2 * time.Second
+
+// exceedsQuestionLimit -> Whether an answer took longer than the quiz's per-question limit.
+// The client-reported time spent is checked as-is, and because clients can under-report it the
+// server also measures from the later of when the question was first fetched and when the
+// previous answer in the quiz came in (questions are answered one after another)
+func exceedsQuestionLimit(db *gorm.DB, question models.QuizQuestion, timeSpent int, now time.Time) (bool, error) {
+	limit := time.Duration(question.Quiz.PerQuestionSeconds) * time.Second
+	if time.Duration(timeSpent)*time.Second > limit {
+		return true, nil
+	}
+
+	var start time.Time
+	if question.ServedAt != nil {
+		start = *question.ServedAt
+	}
+
+	var previous models.QuizQuestion
+	err := db.Where("quiz_id = ? AND id <> ? AND answered_at IS NOT NULL", question.QuizID, question.ID).
+		Order("answered_at DESC").
+		Limit(1).
+		Find(&previous).Error
+	if err != nil {
+		return false, err
+	}
+	if previous.AnsweredAt != nil && previous.AnsweredAt.After(start) {
+		start = *previous.AnsweredAt
+	}
+
+	return !start.IsZero() && now.Sub(start) > limit+questionTimeGrace, nil
 }
 
 // CompleteQuizRequest -> Struct for completing a quiz
@@ -268,71 +860,237 @@ type CompleteQuizRequest struct {
 func (h *QuizHandler) CompleteQuiz(c *gin.Context) {
 	var req CompleteQuizRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperrors.Validation(err))
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
-	// Find the quiz
+	// Read the quiz and save its result in one transaction, so a quiz is only ever completed once,
+	// retrying if the database is busy
 	var quiz models.Quiz
-	if err := h.db.First(&quiz, req.QuizID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Quiz not found"})
+	var correctCount int
+	err := withRetry(h.db, func(tx *gorm.DB) error {
+		// Find the quiz
+		quiz = models.Quiz{}
+		if err := tx.First(&quiz, req.QuizID).Error; err != nil {
+			return apperrors.NotFound("Quiz not found")
+		}
+
+		// Check that the quiz belongs to the user
+		if quiz.UserID != userID.(uint) {
+			return apperrors.Forbidden("You don't have permission to complete this quiz")
+		}
+
+		// Don't allow completing an already completed quiz
+		if quiz.CompletedAt != nil {
+			return apperrors.BadRequest("This quiz is already completed")
+		}
+
+		// Get all questions for the quiz
+		var questions []models.QuizQuestion
+		if err := tx.Where("quiz_id = ?", req.QuizID).Find(&questions).Error; err != nil {
+			return apperrors.Internal("Failed to retrieve quiz questions", err)
+		}
+
+		// Enforce the time limit server-side: anything answered after the deadline is incorrect
+		var late []uint
+		if deadline, ok := quiz.Deadline(); ok {
+			for i := range questions {
+				q := &questions[i]
+				if q.IsCorrect && q.AnsweredAt != nil && q.AnsweredAt.After(deadline) {
+					q.IsCorrect = false
+					late = append(late, q.ID)
+				}
+			}
+		}
+
+		// Calculate score
+		correctCount = 0
+		for _, q := range questions {
+			if q.IsCorrect {
+				correctCount++
+			}
+		}
+
+		// Update quiz with results
+		now := time.Now()
+		quiz.CompletedAt = &now
+		quiz.CorrectAnswers = correctCount
+		quiz.Score = 0
+		if quiz.TotalQuestions > 0 {
+			quiz.Score = float64(correctCount) / float64(quiz.TotalQuestions) * 100
+		}
+
+		// Save the late answers and the result together
+		if len(late) > 0 {
+			if err := tx.Model(&models.QuizQuestion{}).Where("id IN ?", late).Update("is_correct", false).Error; err != nil {
+				return apperrors.Internal("Failed to apply quiz time limit", err)
+			}
+		}
+		if err := tx.Save(&quiz).Error; err != nil {
+			return apperrors.Internal("Failed to complete quiz", err)
+		}
+		return nil
+	})
+	if err != nil {
+		c.Error(txError(err, "Failed to complete quiz"))
 		return
 	}
 
-	// Check that the quiz belongs to the user
-	if quiz.UserID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to complete this quiz"})
+	// TODO: Could also update card progress here based on quiz results
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Quiz completed successfully",
+		"score":           quiz.Score,
+		"correct_answers": correctCount,
+		"total_questions": quiz.TotalQuestions,
+	})
+}
+
+// QuizAnalyticsRequest -> Query parameters for quiz analytics
+type QuizAnalyticsRequest struct {
+	From   string `form:"from"` // Optional start date, YYYY-MM-DD
+	To     string `form:"to"`   // Optional end date (inclusive), YYYY-MM-DD
+	Bucket string `form:"bucket" binding:"omitempty,oneof=day week month"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"` // Number of most-missed cards to return
+}
+
+// GetQuizAnalytics -> Handler to aggregate trends across the user's completed quizzes
+func (h *QuizHandler) GetQuizAnalytics(c *gin.Context) {
+	var req QuizAnalyticsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperrors.Validation(err))
 		return
 	}
 
-	// Don't allow completing an already completed quiz
-	if quiz.CompletedAt != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "This quiz is already completed"})
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
-	// Get all questions for the quiz
-	var questions []models.QuizQuestion
-	if err := h.db.Where("quiz_id = ?", req.QuizID).Find(&questions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve quiz questions"})
-		return
+	limit := req.Limit
+	if limit == 0 {
+		limit = 10
 	}
 
-	// Calculate score
-	correctCount := 0
-	for _, q := range questions {
-		if q.IsCorrect {
-			correctCount++
+	// For simplicity, we're using SQLite's strftime() for bucketing like the study stats do
+	bucketFormat := "%Y-%W"
+	switch req.Bucket {
+	case "day":
+		bucketFormat = "%Y-%m-%d"
+	case "month":
+		bucketFormat = "%Y-%m"
+	}
+
+	// Optional date range, "to" is inclusive of the whole day
+	var from, to time.Time
+	if req.From != "" {
+		parsed, err := time.Parse("2006-01-02", req.From)
+		if err != nil {
+			c.Error(apperrors.BadRequest("Invalid from date, expected YYYY-MM-DD"))
+			return
 		}
+		from = parsed
+	}
+	if req.To != "" {
+		parsed, err := time.Parse("2006-01-02", req.To)
+		if err != nil {
+			c.Error(apperrors.BadRequest("Invalid to date, expected YYYY-MM-DD"))
+			return
+		}
+		to = parsed.AddDate(0, 0, 1)
 	}
 
-	// Update quiz with results
-	now := time.Now()
-	quiz.CompletedAt = &now
-	quiz.CorrectAnswers = correctCount
-	score := 0.0
-	if quiz.TotalQuestions > 0 {
-		score = float64(correctCount) / float64(quiz.TotalQuestions) * 100
+	// Only completed quizzes count towards analytics
+	completed := func() *gorm.DB {
+		query := h.db.Model(&models.Quiz{}).
+			Where("quizzes.user_id = ? AND quizzes.completed_at IS NOT NULL", userID)
+		if !from.IsZero() {
+			query = query.Where("quizzes.completed_at >= ?", from)
+		}
+		if !to.IsZero() {
+			query = query.Where("quizzes.completed_at < ?", to)
+		}
+		return query
 	}
-	quiz.Score = score
 
-	if err := h.db.Save(&quiz).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete quiz"})
+	type ScoreBucket struct {
+		Period       string  `json:"period"`
+		AverageScore float64 `json:"average_score"`
+		Quizzes      int64   `json:"quizzes"`
+	}
+	var scoreOverTime []ScoreBucket
+	if err := completed().
+		Select("strftime(?, quizzes.completed_at) AS period, AVG(quizzes.score) AS average_score, COUNT(*) AS quizzes", bucketFormat).
+		Group("period").
+		Order("period ASC").
+		Scan(&scoreOverTime).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve quiz analytics", err))
 		return
 	}
 
-	// TODO: Could also update card progress here based on quiz results
+	type DeckPerformance struct {
+		DeckID       uint    `json:"deck_id"`
+		DeckTitle    string  `json:"deck_title"`
+		AverageScore float64 `json:"average_score"`
+		Quizzes      int64   `json:"quizzes"`
+	}
+	var perDeck []DeckPerformance
+	if err := completed().
+		Select("quizzes.deck_id, decks.title AS deck_title, AVG(quizzes.score) AS average_score, COUNT(*) AS quizzes").
+		Joins("LEFT JOIN decks ON decks.id = quizzes.deck_id").
+		Group("quizzes.deck_id, decks.title").
+		Order("average_score ASC").
+		Scan(&perDeck).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve quiz analytics", err))
+		return
+	}
+
+	type MissedCard struct {
+		CardID       uint   `json:"card_id"`
+		DeckID       uint   `json:"deck_id"`
+		FrontContent string `json:"front_content"`
+		Misses       int64  `json:"misses"`
+		Attempts     int64  `json:"attempts"`
+	}
+	var mostMissed []MissedCard
+	if err := completed().
+		Select(`quiz_questions.card_id, flash_cards.deck_id, flash_cards.front_content,
+			SUM(CASE WHEN quiz_questions.is_correct THEN 0 ELSE 1 END) AS misses,
+			COUNT(*) AS attempts`).
+		Joins("JOIN quiz_questions ON quiz_questions.quiz_id = quizzes.id AND quiz_questions.deleted_at IS NULL").
+		Joins("JOIN flash_cards ON flash_cards.id = quiz_questions.card_id").
+		Group("quiz_questions.card_id, flash_cards.deck_id, flash_cards.front_content").
+		Having("misses > 0").
+		Order("misses DESC, attempts DESC").
+		Limit(limit).
+		Scan(&mostMissed).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve quiz analytics", err))
+		return
+	}
+
+	// Time reported on the answers of the completed quizzes
+	var totalTimeSpent int64
+	if err := completed().
+		Select("COALESCE(SUM(quiz_questions.time_spent), 0)").
+		Joins("JOIN quiz_questions ON quiz_questions.quiz_id = quizzes.id AND quiz_questions.deleted_at IS NULL").
+		Scan(&totalTimeSpent).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve quiz analytics", err))
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":         "Quiz completed successfully",
-		"score":           score,
-		"correct_answers": correctCount,
-		"total_questions": quiz.TotalQuestions,
+		"analytics": gin.H{
+			"score_over_time":   scoreOverTime,
+			"per_deck":          perDeck,
+			"most_missed_cards": mostMissed,
+			"total_time_spent":  totalTimeSpent, // Seconds
+		},
 	})
 }
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/models"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RegenerateQuizRequest -> Optional overrides for regenerating a quiz, both default to what the quiz has now
+type RegenerateQuizRequest struct {
+	CardCount     int      `json:"card_count" binding:"omitempty,min=0"` // 0 keeps the current number of questions
+	QuestionTypes []string `json:"question_types" binding:"omitempty,dive,oneof=recall fill_blank true_false"`
+}
+
+// RegenerateQuiz -> Handler to redraw the questions of a quiz nobody has answered yet from the current
+// cards of its decks, e.g. after the decks were edited
+func (h *QuizHandler) RegenerateQuiz(c *gin.Context) {
+	var req RegenerateQuizRequest
+	// The body is optional
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(apperrors.Validation(err))
+			return
+		}
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	quiz, ok := h.loadOwnedQuiz(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	if quiz.CompletedAt != nil {
+		c.Error(apperrors.BadRequest("This quiz is already completed"))
+		return
+	}
+	if quiz.TemplateID != nil {
+		c.Error(apperrors.BadRequest("Attempts of a shared quiz use the questions of the original"))
+		return
+	}
+
+	var existing []models.QuizQuestion
+	if err := h.db.Where("quiz_id = ?", quiz.ID).Order(questionOrder).Find(&existing).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve quiz questions", err))
+		return
+	}
+	for _, q := range existing {
+		if q.AnsweredAt != nil {
+			c.Error(apperrors.Conflict("This quiz has already been partially answered"))
+			return
+		}
+	}
+
+	// Other users' attempts are compared on the leaderboard, so their question set can't change
+	var attempts int64
+	if err := h.db.Model(&models.Quiz{}).Where("template_id = ?", quiz.ID).Count(&attempts).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to check quiz attempts", err))
+		return
+	}
+	if attempts > 0 {
+		c.Error(apperrors.Conflict("This quiz has already been attempted by others"))
+		return
+	}
+
+	// Question types the quiz was created with, in the order they first appear
+	questionTypes := req.QuestionTypes
+	if len(questionTypes) == 0 {
+		for _, q := range existing {
+			if !slices.Contains(questionTypes, q.QuestionType) {
+				questionTypes = append(questionTypes, q.QuestionType)
+			}
+		}
+	}
+	if len(questionTypes) == 0 {
+		questionTypes = []string{models.QuestionRecall}
+	}
+
+	count := req.CardCount
+	if count == 0 {
+		count = quiz.TotalQuestions
+	}
+
+	deckIDs := quiz.DeckIDs
+	if len(deckIDs) == 0 {
+		deckIDs = []uint{quiz.DeckID}
+	}
+	for _, deckID := range deckIDs {
+		var deck models.Deck
+		if err := h.db.First(&deck, deckID).Error; err != nil {
+			c.Error(apperrors.NotFound(fmt.Sprintf("Deck %d not found", deckID)))
+			return
+		}
+
+		allowed, err := canViewDeck(h.db, deck, userID.(uint))
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to check deck access", err))
+			return
+		}
+		if !allowed {
+			c.Error(apperrors.Forbidden(fmt.Sprintf("You no longer have access to deck %d", deckID)))
+			return
+		}
+	}
+
+	var deckCards []models.FlashCard
+	if err := h.db.Where("deck_id IN ?", deckIDs).Find(&deckCards).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve flashcards", err))
+		return
+	}
+
+	cards := selectQuizCards(deckCards, questionTypes, count)
+	if len(cards) == 0 {
+		c.Error(apperrors.BadRequest("No cards in the quiz's decks suit its question types anymore"))
+		return
+	}
+
+	questions := buildQuizQuestions(quiz.ID, cards, deckCards, questionTypes)
+	assignPositions(questions, quiz.Shuffle)
+
+	// Replace the question set in one transaction, retried as a whole if the database is busy
+	err := withRetry(h.db, func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("quiz_id = ?", quiz.ID).Delete(&models.QuizQuestion{}).Error; err != nil {
+			return apperrors.Internal("Failed to remove old quiz questions", err)
+		}
+		for i := range questions {
+			questions[i].ID = 0
+			if err := tx.Create(&questions[i]).Error; err != nil {
+				return apperrors.Internal("Failed to create quiz questions", err)
+			}
+		}
+		if err := tx.Model(&quiz).Update("total_questions", len(questions)).Error; err != nil {
+			return apperrors.Internal("Failed to update quiz", err)
+		}
+		return nil
+	})
+	if err != nil {
+		c.Error(txError(err, "Failed to regenerate quiz"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Quiz regenerated successfully",
+		"quiz_id":         quiz.ID,
+		"total_questions": len(questions),
+		"question_types":  questionTypes,
+	})
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/models"
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-pdf/fpdf"
+)
+
+// reportAnswer -> The correct answer as shown in a report, true/false questions show the verdict
+func reportAnswer(q models.QuizQuestion) string {
+	if q.QuestionType == models.QuestionTrueFalse {
+		return strconv.FormatBool(q.StatementIsTrue)
+	}
+	return expectedAnswer(q)
+}
+
+// renderQuizReport -> Lays out the quiz as a printable PDF. Long content wraps and flows onto new
+// pages on its own, every page gets a numbered footer
+func renderQuizReport(quiz models.Quiz, questions []models.QuizQuestion) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.AliasNbPages("")
+
+	// The core fonts only cover cp1252, this maps UTF-8 text onto it
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 10, fmt.Sprintf("Page %d/{nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.MultiCell(0, 8, tr(quiz.Title), "", "L", false)
+	if quiz.Description != "" {
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.MultiCell(0, 5, tr(quiz.Description), "", "L", false)
+	}
+	pdf.Ln(2)
+
+	pdf.SetFont("Helvetica", "", 11)
+	status := "In progress"
+	if quiz.CompletedAt != nil {
+		status = "Completed " + quiz.CompletedAt.Format("2006-01-02 15:04")
+	}
+	pdf.MultiCell(0, 6, fmt.Sprintf("Score: %d/%d (%.1f%%)    %s", quiz.CorrectAnswers, quiz.TotalQuestions, quiz.Score, status), "", "L", false)
+	pdf.Ln(4)
+
+	for i, q := range questions {
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.MultiCell(0, 6, tr(fmt.Sprintf("%d. %s", i+1, questionPrompt(q))), "", "L", false)
+		if q.Statement != "" {
+			pdf.SetFont("Helvetica", "I", 10)
+			pdf.MultiCell(0, 5, tr("Statement: "+q.Statement), "", "L", false)
+		}
+
+		answer := "(not answered)"
+		if q.AnsweredAt != nil {
+			answer = q.UserAnswer
+		}
+		verdict, r, g, b := "Incorrect", 180, 30, 30
+		if q.IsCorrect {
+			verdict, r, g, b = "Correct", 30, 130, 30
+		}
+
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.MultiCell(0, 5, tr("Your answer: "+answer), "", "L", false)
+		pdf.MultiCell(0, 5, tr("Correct answer: "+reportAnswer(q)), "", "L", false)
+		if q.FlashCard.Explanation != "" {
+			pdf.MultiCell(0, 5, tr("Explanation: "+q.FlashCard.Explanation), "", "L", false)
+		}
+		pdf.SetTextColor(r, g, b)
+		pdf.MultiCell(0, 5, verdict, "", "L", false)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.Ln(3)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GetQuizReport -> Handler to download a quiz's questions, answers and score as a PDF
+func (h *QuizHandler) GetQuizReport(c *gin.Context) {
+	quizID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid quiz ID"))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var quiz models.Quiz
+	if err := h.db.First(&quiz, quizID).Error; err != nil {
+		c.Error(apperrors.NotFound("Quiz not found"))
+		return
+	}
+
+	// Only the quiz creator can download its report
+	if quiz.UserID != userID.(uint) {
+		c.Error(apperrors.Forbidden("You don't have permission to access this quiz"))
+		return
+	}
+
+	var questions []models.QuizQuestion
+	if err := h.db.Where("quiz_id = ?", quizID).Preload("FlashCard", withDeletedCards).Order(questionOrder).Find(&questions).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve quiz questions", err))
+		return
+	}
+
+	report, err := renderQuizReport(quiz, questions)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to render quiz report", err))
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"quiz-%d-report.pdf\"", quiz.ID))
+	c.Data(http.StatusOK, "application/pdf", report)
+}
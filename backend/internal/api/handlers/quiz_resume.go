@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Where a quiz attempt stands, as reported when resuming it
+const (
+	QuizStateNotStarted = "not_started"
+	QuizStateInProgress = "in_progress"
+	QuizStateCompleted  = "completed"
+)
+
+// GetResumeQuiz -> Handler to pick a quiz back up where it was left: its state, how many questions
+// are answered and the first unanswered one. A timed quiz's clock runs from its first answer
+// (StartedAt), so the remaining time reflects the time spent away as well
+func (h *QuizHandler) GetResumeQuiz(c *gin.Context) {
+	quizID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid quiz ID"))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var quiz models.Quiz
+	if err := h.db.First(&quiz, quizID).Error; err != nil {
+		c.Error(apperrors.NotFound("Quiz not found"))
+		return
+	}
+
+	// Only the quiz creator can access it
+	if quiz.UserID != userID.(uint) {
+		c.Error(apperrors.Forbidden("You don't have permission to access this quiz"))
+		return
+	}
+
+	progress, err := h.nextQuizQuestion(quiz)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve quiz questions", err))
+		return
+	}
+
+	state := QuizStateInProgress
+	switch {
+	case quiz.CompletedAt != nil:
+		state = QuizStateCompleted
+	case quiz.StartedAt == nil && progress.Answered == 0:
+		state = QuizStateNotStarted
+	}
+
+	deadline, timed := quiz.Deadline()
+	response := gin.H{
+		"quiz_id":            quiz.ID,
+		"title":              quiz.Title,
+		"state":              state,
+		"total":              progress.Total,
+		"answered":           progress.Answered,
+		"started_at":         quiz.StartedAt,
+		"completed_at":       quiz.CompletedAt,
+		"time_limit_seconds": quiz.TimeLimitSeconds,
+		"remaining_seconds":  quizRemainingSeconds(quiz),
+		"time_expired":       state == QuizStateInProgress && timed && time.Now().After(deadline),
+		"position":           nil,
+		"question":           nil,
+	}
+
+	if state == QuizStateCompleted {
+		response["score"] = quiz.Score
+		response["correct_answers"] = quiz.CorrectAnswers
+	} else if progress.Question != nil {
+		response["position"] = progress.Position
+		response["question"] = formatQuizQuestion(*progress.Question, true)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
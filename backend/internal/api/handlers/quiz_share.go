@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/models"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// A quiz shared by link can be viewed read-only by any signed-in user holding its token.
+// Unlike an invite it doesn't allow attempting the quiz, and the viewer never needs to
+// have access to the decks the questions came from.
+
+// loadOwnedQuiz -> Loads the quiz from the :id param, writing the error and returning false unless the caller owns it
+func (h *QuizHandler) loadOwnedQuiz(c *gin.Context, userID uint) (models.Quiz, bool) {
+	var quiz models.Quiz
+
+	quizID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid quiz ID"))
+		return quiz, false
+	}
+
+	if err := h.db.First(&quiz, quizID).Error; err != nil {
+		c.Error(apperrors.NotFound("Quiz not found"))
+		return quiz, false
+	}
+
+	if quiz.UserID != userID {
+		c.Error(apperrors.Forbidden("You don't have permission to change this quiz"))
+		return quiz, false
+	}
+
+	return quiz, true
+}
+
+// ShareQuiz -> Handler to create a share link for a quiz. Sharing again replaces the token, so
+// anyone holding the old link loses access
+func (h *QuizHandler) ShareQuiz(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	quiz, ok := h.loadOwnedQuiz(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		c.Error(apperrors.Internal("Failed to generate share token", err))
+		return
+	}
+	token := hex.EncodeToString(raw)
+
+	if err := h.db.Model(&quiz).Update("share_token", token).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to share quiz", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Quiz shared successfully",
+		"share_token": token,
+		"share_path":  "/api/quizzes/shared/" + token,
+	})
+}
+
+// UnshareQuiz -> Handler to revoke a quiz's share link
+func (h *QuizHandler) UnshareQuiz(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	quiz, ok := h.loadOwnedQuiz(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	if err := h.db.Model(&quiz).Update("share_token", nil).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to unshare quiz", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Quiz is no longer shared",
+	})
+}
+
+// GetSharedQuiz -> Handler to view a quiz through its share link. A completed quiz shows its
+// results, one still in progress only its questions, without answers or the owner's responses
+func (h *QuizHandler) GetSharedQuiz(c *gin.Context) {
+	token := c.Param("token")
+
+	var quiz models.Quiz
+	if token == "" || h.db.Where("share_token = ?", token).First(&quiz).Error != nil {
+		c.Error(apperrors.NotFound("Shared quiz not found"))
+		return
+	}
+
+	var questions []models.QuizQuestion
+	if err := h.db.Where("quiz_id = ?", quiz.ID).Preload("FlashCard", withDeletedCards).Order(questionOrder).Find(&questions).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve quiz questions", err))
+		return
+	}
+
+	completed := quiz.CompletedAt != nil
+	formattedQuestions := make([]gin.H, 0, len(questions))
+	for _, q := range questions {
+		question := formatQuizQuestion(q, !completed)
+		if !completed {
+			delete(question, "user_answer")
+			delete(question, "answered")
+			delete(question, "time_spent")
+		}
+		formattedQuestions = append(formattedQuestions, question)
+	}
+
+	var owner models.User
+	if err := h.db.Select("id", "username").First(&owner, quiz.UserID).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve quiz owner", err))
+		return
+	}
+
+	view := gin.H{
+		"id":              quiz.ID,
+		"owner":           owner.Username,
+		"title":           quiz.Title,
+		"description":     quiz.Description,
+		"total_questions": quiz.TotalQuestions,
+		"completed":       completed,
+		"questions":       formattedQuestions,
+	}
+	if completed {
+		view["completed_at"] = quiz.CompletedAt
+		view["score"] = quiz.Score
+		view["correct_answers"] = quiz.CorrectAnswers
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"quiz": view,
+	})
+}
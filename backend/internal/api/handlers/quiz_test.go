@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/models"
+	"errors"
+	"net/http"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestCreateQuizIdempotencyKeyOfDeletedQuiz(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice")
+	deck := createTestDeck(t, db, user.ID, "Capitals", "France", "Spain")
+
+	r := newTestRouter(user.ID)
+	r.POST("/quizzes", NewQuizHandler(db).CreateQuiz)
+	create := func() (int, map[string]any) {
+		req := newJSONRequest(t, http.MethodPost, "/quizzes", map[string]any{"deck_id": deck.ID, "title": "Capitals"})
+		req.Header.Set("Idempotency-Key", "create-1")
+		return serveJSON(t, r, req)
+	}
+
+	code, out := create()
+	if code != http.StatusCreated {
+		t.Fatalf("first create: status = %d: %v", code, out)
+	}
+	firstID := out["quiz"].(map[string]any)["id"]
+
+	// Gone the way a purged deck takes its quizzes
+	if err := db.Unscoped().Where("id = ?", firstID).Delete(&models.Quiz{}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	code, out = create()
+	if code != http.StatusCreated {
+		t.Fatalf("create after the quiz was deleted: status = %d, want %d: %v", code, http.StatusCreated, out)
+	}
+	if secondID := out["quiz"].(map[string]any)["id"]; secondID == firstID {
+		t.Errorf("got the deleted quiz %v back instead of a new one", firstID)
+	}
+
+	// The key now points at the new quiz, so it's replayed again
+	code, replayed := create()
+	if code != http.StatusCreated || replayed["quiz"].(map[string]any)["id"] != out["quiz"].(map[string]any)["id"] {
+		t.Errorf("repeat with the same key: status = %d, quiz = %v, want the new quiz %v", code, replayed["quiz"], out["quiz"])
+	}
+}
+
+func TestSubmitQuizAnswerRejections(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice")
+	deck := createTestDeck(t, db, user.ID, "Capitals", "France", "Spain")
+
+	var cards []models.FlashCard
+	if err := db.Where("deck_id = ?", deck.ID).Order("id").Find(&cards).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// One quiz per card, each with a single recall question
+	questions := make([]models.QuizQuestion, len(cards))
+	for i, card := range cards {
+		quiz := models.Quiz{UserID: user.ID, DeckID: deck.ID, DeckIDs: []uint{deck.ID}, Title: card.FrontContent, TotalQuestions: 1}
+		if err := db.Create(&quiz).Error; err != nil {
+			t.Fatal(err)
+		}
+		questions[i] = models.QuizQuestion{QuizID: quiz.ID, CardID: card.ID, DeckID: deck.ID, QuestionType: models.QuestionRecall}
+		if err := db.Create(&questions[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The card behind the second quiz's question goes away after the quiz was made
+	if err := db.Delete(&cards[1]).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestRouter(user.ID)
+	r.POST("/quizzes/answer", NewQuizHandler(db).SubmitQuizAnswer)
+
+	tests := []struct {
+		name     string
+		quizID   uint
+		question models.QuizQuestion
+		want     int
+	}{
+		{"matching quiz", questions[0].QuizID, questions[0], http.StatusOK},
+		{"question from another quiz", questions[1].QuizID, questions[0], http.StatusBadRequest},
+		{"card deleted", questions[1].QuizID, questions[1], http.StatusNotFound},
+		{"card deleted without quiz_id", 0, questions[1], http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, out := doJSON(t, r, http.MethodPost, "/quizzes/answer", map[string]any{
+				"quiz_id":     tt.quizID,
+				"question_id": tt.question.ID,
+				"answer":      "A0",
+			})
+			if code != tt.want {
+				t.Errorf("status = %d, want %d: %v", code, tt.want, out)
+			}
+		})
+	}
+
+	// Neither rejected answer was recorded
+	var stored models.QuizQuestion
+	if err := db.First(&stored, questions[1].ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.AnsweredAt != nil {
+		t.Errorf("question of the deleted card was answered at %v", stored.AnsweredAt)
+	}
+}
+
+func TestCreateQuizMoreCardsThanAvailable(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice")
+	deck := createTestDeck(t, db, user.ID, "Capitals", "France", "Spain", "Italy")
+
+	r := newTestRouter(user.ID)
+	r.POST("/quizzes", NewQuizHandler(db).CreateQuiz)
+
+	tests := []struct {
+		name       string
+		body       map[string]any
+		want       int
+		warning    bool
+		totalCards float64
+	}{
+		{"fits", map[string]any{"card_count": 2}, http.StatusCreated, false, 2},
+		{"smaller quiz with a warning", map[string]any{"card_count": 10}, http.StatusCreated, true, 3},
+		{"exact count refused", map[string]any{"card_count": 10, "exact_count": true}, http.StatusBadRequest, false, 0},
+		{"zero is invalid", map[string]any{"card_count": 0}, http.StatusBadRequest, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var before int64
+			db.Model(&models.Quiz{}).Count(&before)
+
+			tt.body["deck_id"] = deck.ID
+			tt.body["title"] = tt.name
+			code, out := doJSON(t, r, http.MethodPost, "/quizzes", tt.body)
+			if code != tt.want {
+				t.Fatalf("status = %d, want %d: %v", code, tt.want, out)
+			}
+
+			var after int64
+			db.Model(&models.Quiz{}).Count(&after)
+			if code != http.StatusCreated {
+				if after != before {
+					t.Errorf("a rejected request created %d quizzes", after-before)
+				}
+				return
+			}
+
+			if _, ok := out["warning"]; ok != tt.warning {
+				t.Errorf("warning = %v, want a warning = %v", out["warning"], tt.warning)
+			}
+			if total := out["quiz"].(map[string]any)["total_questions"]; total != tt.totalCards {
+				t.Errorf("total_questions = %v, want %v", total, tt.totalCards)
+			}
+		})
+	}
+}
+
+func TestCompleteQuizRetriesLockedDatabase(t *testing.T) {
+	t.Setenv("DB_RETRY_BACKOFF", "1ms")
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice")
+	deck := createTestDeck(t, db, user.ID, "Capitals", "France")
+
+	quiz := models.Quiz{UserID: user.ID, DeckID: deck.ID, DeckIDs: []uint{deck.ID}, Title: "Capitals", TotalQuestions: 1}
+	if err := db.Create(&quiz).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// The first save of the result runs into another writer's lock
+	failures := 1
+	if err := db.Callback().Update().Before("gorm:update").Register("test:lock_quiz_once", func(tx *gorm.DB) {
+		if tx.Statement.Table == "quizzes" && failures > 0 {
+			failures--
+			tx.AddError(errors.New("database is locked"))
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestRouter(user.ID)
+	r.POST("/quizzes/complete", NewQuizHandler(db).CompleteQuiz)
+
+	code, out := doJSON(t, r, http.MethodPost, "/quizzes/complete", map[string]any{"quiz_id": quiz.ID})
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want %d after a retry: %v", code, http.StatusOK, out)
+	}
+
+	var stored models.Quiz
+	if err := db.First(&stored, quiz.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.CompletedAt == nil {
+		t.Error("quiz isn't completed")
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/models"
+	"math/rand"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetRandomCardRequest -> Query parameters for picking a random card, exclude may repeat
+type GetRandomCardRequest struct {
+	Exclude []uint `form:"exclude" binding:"max=100"` // Recently shown card IDs to skip
+}
+
+// randomCard -> Picks one card matching the query uniformly at random. Counting and then reading a
+// single row at a random offset avoids loading the deck or sorting it by RANDOM()
+func randomCard(query *gorm.DB) (*models.FlashCard, error) {
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, err
+	}
+	if total == 0 {
+		return nil, nil
+	}
+
+	var card models.FlashCard
+	if err := query.Order("id ASC").Offset(rand.Intn(int(total))).Limit(1).Take(&card).Error; err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+// GetRandomCard -> Handler to get one random card of a deck the caller can view, for "card of the
+// moment" widgets. It doesn't touch the study schedule
+func (h *DeckHandler) GetRandomCard(c *gin.Context) {
+	deckID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid deck ID"))
+		return
+	}
+
+	var req GetRandomCardRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var deck models.Deck
+	if err := h.db.First(&deck, deckID).Error; err != nil {
+		c.Error(apperrors.NotFound("Deck not found"))
+		return
+	}
+
+	allowed, err := canViewDeck(h.db, deck, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to view this deck"))
+		return
+	}
+
+	query := h.db.Model(&models.FlashCard{}).Where("deck_id = ?", deck.ID)
+	card, err := randomCard(query.Session(&gorm.Session{}).Not(map[string]any{"id": req.Exclude}))
+	if err == nil && card == nil && len(req.Exclude) > 0 {
+		// Every card was shown recently, start over rather than come up empty
+		card, err = randomCard(query)
+	}
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve flashcard", err))
+		return
+	}
+	if card == nil {
+		c.Error(apperrors.NotFound("This deck has no cards"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"card": card,
+	})
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/api/middleware"
+	"FlashQuiz/internal/models"
+	"FlashQuiz/internal/stats"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// loggedCounts -> A progress record's counters next to what its review log says they should be
+type loggedCounts struct {
+	ID            uint
+	CardID        uint
+	ReviewCount   int
+	CorrectCount  int
+	LoggedReviews int
+	LoggedCorrect int
+}
+
+// StatsCorrection -> One progress record whose counters didn't match the review log
+type StatsCorrection struct {
+	CardID             uint `json:"card_id"`
+	ReviewCountBefore  int  `json:"review_count_before"`
+	ReviewCountAfter   int  `json:"review_count_after"`
+	CorrectCountBefore int  `json:"correct_count_before"`
+	CorrectCountAfter  int  `json:"correct_count_after"`
+}
+
+// RecomputeStats -> Handler to rebuild the review and correct counts of the caller's progress
+// records from their review log, fixing any drift. A card's log is only counted from its latest
+// reset on, since resetting keeps the history but starts the counts over, and cards marked as
+// known without reviews go back to zero. Runs in the request's transaction, so it's all or nothing
+func (h *UserHandler) RecomputeStats(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	db := middleware.DB(c, h.db)
+
+	// Reset progress is soft-deleted, the newest deleted record of a card marks where its counts restart
+	var rows []loggedCounts
+	if err := db.Raw(`
+		SELECT card_progresses.id, card_progresses.card_id, card_progresses.review_count, card_progresses.correct_count,
+			COUNT(review_logs.id) AS logged_reviews,
+			COALESCE(SUM(CASE WHEN review_logs.performance >= ? THEN 1 ELSE 0 END), 0) AS logged_correct
+		FROM card_progresses
+		LEFT JOIN review_logs ON review_logs.user_id = card_progresses.user_id
+			AND review_logs.card_id = card_progresses.card_id
+			AND review_logs.deleted_at IS NULL
+			AND review_logs.reviewed_at > COALESCE((
+				SELECT MAX(reset.deleted_at) FROM card_progresses AS reset
+				WHERE reset.user_id = card_progresses.user_id AND reset.card_id = card_progresses.card_id
+					AND reset.deleted_at IS NOT NULL), '')
+		WHERE card_progresses.user_id = ? AND card_progresses.deleted_at IS NULL
+		GROUP BY card_progresses.id
+		ORDER BY card_progresses.card_id ASC`, models.PassingPerformance, userID).
+		Scan(&rows).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to read review history", err))
+		return
+	}
+
+	corrections := make([]StatsCorrection, 0)
+	for _, row := range rows {
+		if row.ReviewCount == row.LoggedReviews && row.CorrectCount == row.LoggedCorrect {
+			continue
+		}
+		if err := db.Model(&models.CardProgress{}).Where("id = ?", row.ID).
+			UpdateColumns(map[string]any{"review_count": row.LoggedReviews, "correct_count": row.LoggedCorrect}).Error; err != nil {
+			c.Error(apperrors.Internal("Failed to update card progress", err))
+			return
+		}
+		corrections = append(corrections, StatsCorrection{
+			CardID:             row.CardID,
+			ReviewCountBefore:  row.ReviewCount,
+			ReviewCountAfter:   row.LoggedReviews,
+			CorrectCountBefore: row.CorrectCount,
+			CorrectCountAfter:  row.LoggedCorrect,
+		})
+	}
+
+	// Due counts are derived too, refresh them while at it
+	middleware.AfterCommit(c, func() {
+		if err := stats.RecomputeDueCounts(h.db, userID.(uint)); err != nil {
+			log.Printf("Failed to recompute due counts for user %d: %v", userID, err)
+		}
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Stats recomputed successfully",
+		"cards_checked": len(rows),
+		"cards_fixed":   len(corrections),
+		"corrections":   corrections,
+	})
+}
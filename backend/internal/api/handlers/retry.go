@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/config"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// transientErrors -> Fragments of driver errors that mean "try again", SQLite's single writer lock
+// surfaces as the first two
+var transientErrors = []string{
+	"database is locked",
+	"database table is locked",
+	"deadlock",
+	"could not serialize access",
+}
+
+// isTransient -> Whether err comes from lock contention rather than bad input or a missing row
+func isTransient(err error) bool {
+	if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	for _, fragment := range transientErrors {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// withRetry -> Runs fn in a transaction, retrying it with exponential backoff while it fails with
+// transient errors. fn may run more than once, so it must rebuild its results on every call.
+// Attempts and the first delay come from DB_RETRY_ATTEMPTS and DB_RETRY_BACKOFF
+func withRetry(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	attempts := max(config.Int("DB_RETRY_ATTEMPTS", 3), 1)
+	backoff := config.Duration("DB_RETRY_BACKOFF", 50*time.Millisecond)
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = db.Transaction(fn); !isTransient(err) {
+			return err
+		}
+		if attempt < attempts {
+			log.Printf("Transient database error (attempt %d/%d), retrying in %s: %v", attempt, attempts, backoff, err)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	return err
+}
+
+// txError -> Passes errors fn already turned into an apperrors.Error through, and wraps anything
+// else (such as a failed commit) as an internal error with message
+func txError(err error, message string) error {
+	var appErr *apperrors.Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return apperrors.Internal(message, err)
+}
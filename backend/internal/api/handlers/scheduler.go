@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/models"
+	"FlashQuiz/internal/stats"
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Named grades, an alternative to the numeric performance. They map onto the 0-5 scale as
+// again=1 (a fail, and a lapse for cards in review), hard=3, good=4 and easy=5. Easy also
+// multiplies the new interval by easyBonus and skips any remaining learning steps
+const (
+	GradeAgain = "again"
+	GradeHard  = "hard"
+	GradeGood  = "good"
+	GradeEasy  = "easy"
+)
+
+// grades -> The named grades in button order
+var grades = []string{GradeAgain, GradeHard, GradeGood, GradeEasy}
+
+var gradePerformance = map[string]int{
+	GradeAgain: 1,
+	GradeHard:  3,
+	GradeGood:  4,
+	GradeEasy:  5,
+}
+
+// easyBonus -> Extra interval multiplier for cards graded easy
+const easyBonus = 1.3
+
+// resolvePerformance -> The numeric performance of a review given as either a 0-5 performance
+// or a named grade, exactly one of which must be set. A pointer since 0 is a valid performance
+func resolvePerformance(performance *int, grade string) (int, error) {
+	if grade == "" {
+		if performance == nil || *performance < models.MinPerformance || *performance > models.MaxPerformance {
+			return 0, errors.New("performance must be between 0 and 5, or a grade given instead")
+		}
+		return *performance, nil
+	}
+	if performance != nil {
+		return 0, errors.New("send either performance or grade, not both")
+	}
+	mapped, ok := gradePerformance[grade]
+	if !ok {
+		return 0, errors.New("grade must be one of again, hard, good, easy")
+	}
+	return mapped, nil
+}
+
+// newCardProgress -> The progress a card starts from on its first review
+func newCardProgress(userID, cardID uint) models.CardProgress {
+	return models.CardProgress{
+		UserID:       userID,
+		CardID:       cardID,
+		EaseFactor:   2.5, // Default value
+		Interval:     0,
+		ReviewCount:  0,
+		CorrectCount: 0,
+		Status:       "new",
+	}
+}
+
+// applyReview -> Updates the progress using the SuperMemo SM-2 algorithm
+// This is a simplified version of the algorithm. New and failed cards first walk through the
+// deck's learning steps (in minutes), if it has any, before getting day intervals
+func applyReview(progress *models.CardProgress, performance int, grade string, now time.Time, steps []int) {
+	// Update last reviewed time
+	progress.LastReviewedAt = now
+	progress.ReviewCount++
+
+	// Update ease factor and interval based on performance, on the SM-2 0-5 scale
+	// (see models.MinPerformance) where 3 and up is a pass
+
+	isCorrect := performance >= models.PassingPerformance
+	if isCorrect {
+		progress.CorrectCount++
+	}
+
+	// Calculate new ease factor (EF), from +0.1 at 5 down to -0.8 at 0
+	q := float64(models.MaxPerformance - performance)
+	easeFactor := progress.EaseFactor + (0.1 - q*(0.08+q*0.02))
+	if easeFactor < 1.3 {
+		easeFactor = 1.3 // Minimum ease factor
+	}
+	progress.EaseFactor = easeFactor
+
+	// Failing a card that had reached review is a lapse
+	if !isCorrect && progress.Status == "review" {
+		progress.Lapses++
+	}
+
+	if len(steps) > 0 {
+		if !isCorrect {
+			// Failing starts the steps over
+			progress.LearningStep = 0
+			progress.Interval = 0
+			progress.Status = "learning"
+			progress.NextReviewDate = now.Add(time.Duration(steps[0]) * time.Minute)
+			return
+		}
+
+		// A card that hasn't graduated (no day interval yet) moves on to its next step, unless it was easy
+		if progress.Interval == 0 && grade != GradeEasy && progress.LearningStep+1 < len(steps) {
+			progress.LearningStep++
+			progress.Status = "learning"
+			progress.NextReviewDate = now.Add(time.Duration(steps[progress.LearningStep]) * time.Minute)
+			return
+		}
+		progress.LearningStep = 0
+	}
+
+	// Calculate new interval
+	var newInterval int
+	if !isCorrect {
+		// If response was incorrect, start over
+		newInterval = 1
+		progress.Status = "learning"
+	} else {
+		// If response was correct, increase interval
+		if progress.Interval == 0 {
+			newInterval = 1
+		} else if progress.Interval == 1 {
+			newInterval = 6
+		} else {
+			newInterval = int(float64(progress.Interval) * progress.EaseFactor)
+		}
+		if grade == GradeEasy {
+			newInterval = int(math.Ceil(float64(newInterval) * easyBonus))
+		}
+
+		if progress.Status == "new" {
+			progress.Status = "learning"
+		} else if newInterval > 21 {
+			// After 3 weeks interval, consider it "review" status
+			progress.Status = "review"
+		}
+	}
+
+	progress.Interval = newInterval
+	progress.NextReviewDate = progress.LastReviewedAt.AddDate(0, 0, newInterval)
+}
+
+// difficultyDrift -> How far a card's difficulty moves toward the one observed in a single review
+const difficultyDrift = 0.1
+
+// observedDifficulty -> The difficulty a review suggests, 0 for a perfect answer and 1 for a blackout
+func observedDifficulty(performance int) float64 {
+	return float64(models.MaxPerformance-performance) / models.MaxPerformance
+}
+
+// maxTimeSpentSeconds -> Longest time a single review or quiz answer may report, anything above is
+// a client error rather than a real answer. Mirrored in the binding tags of the request structs
+const maxTimeSpentSeconds = 3600
+
+// recordReview -> Schedules a review of the card for the user, creating its progress record on
+// the first review, and logs it along with the seconds spent. Progress and log are written in one
+// transaction. grade is the named grade the performance came from, if any
+func recordReview(db *gorm.DB, userID, cardID uint, performance int, grade string, timeSpent int) (models.CardProgress, error) {
+	// Get or create progress record
+	var progress models.CardProgress
+	err := db.Where("user_id = ? AND card_id = ?", userID, cardID).First(&progress).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return progress, err
+	}
+
+	isNew := err != nil
+	if isNew {
+		progress = newCardProgress(userID, cardID)
+	}
+
+	settings, err := loadUserSettings(db, userID)
+	if err != nil {
+		return progress, err
+	}
+
+	var deck models.Deck
+	if err := db.Joins("JOIN flash_cards ON flash_cards.deck_id = decks.id").
+		Where("flash_cards.id = ?", cardID).
+		First(&deck).Error; err != nil {
+		return progress, err
+	}
+
+	// Reviews made while a study session is open count toward it
+	session, err := openStudySession(db, userID)
+	if err != nil {
+		return progress, err
+	}
+	var sessionID *uint
+	if session != nil {
+		sessionID = &session.ID
+	}
+
+	intervalBefore := progress.Interval
+	applyReview(&progress, performance, grade, time.Now(), deck.LearningSteps)
+
+	// Cards failed too often become leeches, and are taken out of rotation if the user wants that
+	if !progress.IsLeech && progress.Lapses > settings.LeechThreshold {
+		progress.IsLeech = true
+		if settings.LeechAction == models.LeechActionSuspend {
+			progress.Suspended = true
+		}
+	}
+
+	// Save the progress and log the review together, retrying if the database is busy. Each
+	// attempt starts from the same pending progress so a rolled back create leaves no ID behind
+	pending := progress
+	err = withRetry(db, func(tx *gorm.DB) error {
+		progress = pending
+		if isNew {
+			if err := tx.Create(&progress).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Save(&progress).Error; err != nil {
+			return err
+		}
+
+		reviewLog := models.ReviewLog{
+			UserID:         progress.UserID,
+			CardID:         progress.CardID,
+			Performance:    performance,
+			IntervalBefore: intervalBefore,
+			EaseAfter:      progress.EaseFactor,
+			IntervalAfter:  progress.Interval,
+			ReviewedAt:     progress.LastReviewedAt,
+			SessionID:      sessionID,
+			TimeSpent:      timeSpent,
+		}
+		if err := tx.Create(&reviewLog).Error; err != nil {
+			return err
+		}
+
+		// Difficulty is global to the card, so on public decks it reflects every user's reviews. It moves
+		// a fraction of the way toward each observation, which keeps it within [0,1]
+		return tx.Model(&models.FlashCard{}).Where("id = ?", cardID).
+			UpdateColumn("difficulty_level", gorm.Expr("difficulty_level + ? * (? - difficulty_level)", difficultyDrift, observedDifficulty(performance))).Error
+	})
+	if err != nil {
+		return progress, err
+	}
+
+	// Keep the precomputed due counts fresh, the review itself already succeeded either way
+	if err := stats.RecomputeDueCounts(db, userID); err != nil {
+		log.Printf("Failed to recompute due counts for user %d: %v", userID, err)
+	}
+
+	return progress, nil
+}
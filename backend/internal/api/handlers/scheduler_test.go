@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/models"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestResolvePerformance(t *testing.T) {
+	intPtr := func(n int) *int { return &n }
+
+	tests := []struct {
+		name        string
+		performance *int
+		grade       string
+		want        int
+		wantErr     bool
+	}{
+		{"again", nil, GradeAgain, 1, false},
+		{"hard", nil, GradeHard, 3, false},
+		{"good", nil, GradeGood, 4, false},
+		{"easy", nil, GradeEasy, 5, false},
+		{"blackout is on the scale", intPtr(0), "", 0, false},
+		{"perfect is on the scale", intPtr(5), "", 5, false},
+		{"above the scale", intPtr(6), "", 0, true},
+		{"below the scale", intPtr(-1), "", 0, true},
+		{"neither given", nil, "", 0, true},
+		{"both given", intPtr(4), GradeGood, 0, true},
+		{"unknown grade", nil, "perfect", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolvePerformance(tt.performance, tt.grade)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolvePerformance() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolvePerformance() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyReviewGrades(t *testing.T) {
+	now := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	// A mature card: ten days, default ease, in review
+	mature := func() models.CardProgress {
+		return models.CardProgress{EaseFactor: 2.5, Interval: 10, Status: "review"}
+	}
+	// A card seen for the first time
+	fresh := func() models.CardProgress {
+		return newCardProgress(1, 1)
+	}
+
+	tests := []struct {
+		name         string
+		progress     func() models.CardProgress
+		grade        string
+		performance  int // Used when no grade is given
+		wantInterval int
+		wantEase     float64
+		wantStatus   string
+		wantLapses   int
+	}{
+		{"again on a mature card lapses it", mature, GradeAgain, 0, 1, 1.96, "learning", 1},
+		{"hard on a mature card", mature, GradeHard, 0, 23, 2.36, "review", 0},
+		{"good on a mature card", mature, GradeGood, 0, 25, 2.5, "review", 0},
+		{"easy on a mature card gets the bonus", mature, GradeEasy, 0, 34, 2.6, "review", 0},
+		{"blackout on a mature card lapses it", mature, "", 0, 1, 1.7, "learning", 1},
+		{"close miss on a mature card lapses it", mature, "", 2, 1, 2.18, "learning", 1},
+		{"again on a new card", fresh, GradeAgain, 0, 1, 1.96, "learning", 0},
+		{"hard on a new card", fresh, GradeHard, 0, 1, 2.36, "learning", 0},
+		{"good on a new card", fresh, GradeGood, 0, 1, 2.5, "learning", 0},
+		{"easy on a new card gets the bonus", fresh, GradeEasy, 0, 2, 2.6, "learning", 0},
+		{"blackout on a new card", fresh, "", 0, 1, 1.7, "learning", 0},
+		{"close miss on a new card", fresh, "", 2, 1, 2.18, "learning", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			performance := tt.performance
+			if tt.grade != "" {
+				var err error
+				if performance, err = resolvePerformance(nil, tt.grade); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			progress := tt.progress()
+			applyReview(&progress, performance, tt.grade, now, nil)
+
+			if progress.Interval != tt.wantInterval {
+				t.Errorf("interval = %d, want %d", progress.Interval, tt.wantInterval)
+			}
+			if math.Abs(progress.EaseFactor-tt.wantEase) > 1e-9 {
+				t.Errorf("ease factor = %v, want %v", progress.EaseFactor, tt.wantEase)
+			}
+			if progress.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", progress.Status, tt.wantStatus)
+			}
+			if progress.Lapses != tt.wantLapses {
+				t.Errorf("lapses = %d, want %d", progress.Lapses, tt.wantLapses)
+			}
+			if want := now.AddDate(0, 0, tt.wantInterval); !progress.NextReviewDate.Equal(want) {
+				t.Errorf("next review = %v, want %v", progress.NextReviewDate, want)
+			}
+		})
+	}
+}
+
+func TestApplyReviewEaseFloor(t *testing.T) {
+	progress := models.CardProgress{EaseFactor: 1.3, Interval: 10, Status: "review"}
+	applyReview(&progress, models.MinPerformance, "", time.Now(), nil)
+
+	if progress.EaseFactor != 1.3 {
+		t.Errorf("ease factor = %v, want the 1.3 floor", progress.EaseFactor)
+	}
+}
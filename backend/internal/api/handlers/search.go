@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/models"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SearchCardsRequest -> Query parameters for searching cards across decks
+type SearchCardsRequest struct {
+	Q             string `form:"q" binding:"required,min=2,max=200"`
+	IncludePublic bool   `form:"include_public"` // Also search other users' public decks
+	Page          int    `form:"page" binding:"omitempty,min=1"`
+	PageSize      int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// escapeLike -> Escapes LIKE wildcards so the text matches literally, for use with ESCAPE '\'
+func escapeLike(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(text)
+}
+
+// SearchCards -> Handler to find cards whose front or back contains the query, across all the
+// caller's decks. SQLite's LIKE ignores ASCII case. A substring match can't use an index, so this
+// scans the cards of the decks in scope, which the deck_id index keeps to the caller's own
+func (h *CardHandler) SearchCards(c *gin.Context) {
+	var req SearchCardsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	page := req.Page
+	if page == 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize == 0 {
+		pageSize = 20
+	}
+
+	decks := h.db.Model(&models.Deck{}).Select("id").Where("user_id = ?", userID)
+	if req.IncludePublic {
+		decks = decks.Or("is_public = ?", true)
+	}
+
+	pattern := "%" + escapeLike(strings.TrimSpace(req.Q)) + "%"
+	query := h.db.Model(&models.FlashCard{}).
+		Where("deck_id IN (?)", decks).
+		Where(`front_content LIKE ? ESCAPE '\' OR back_content LIKE ? ESCAPE '\'`, pattern, pattern)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to search flashcards", err))
+		return
+	}
+
+	var cards []models.FlashCard
+	if err := query.Preload("Deck").
+		Order("id ASC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&cards).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to search flashcards", err))
+		return
+	}
+
+	results := make([]gin.H, 0, len(cards))
+	for _, card := range cards {
+		results = append(results, gin.H{
+			"card": card,
+			"deck": gin.H{
+				"id":        card.Deck.ID,
+				"title":     card.Deck.Title,
+				"category":  card.Deck.Category,
+				"is_public": card.Deck.IsPublic,
+				"owned":     card.Deck.UserID == userID.(uint),
+			},
+		})
+	}
+
+	setPaginationHeaders(c, page, pageSize, total)
+
+	c.JSON(http.StatusOK, gin.H{
+		"results":   results,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/models"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// openStudySession -> The user's open study session, nil when there isn't one
+func openStudySession(db *gorm.DB, userID uint) (*models.StudySession, error) {
+	var session models.StudySession
+	err := db.Where("user_id = ? AND ended_at IS NULL", userID).Order("started_at DESC").First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// closeStudySession -> Ends the session at endedAt and fills in its totals from the reviews linked to it
+func closeStudySession(db *gorm.DB, session *models.StudySession, endedAt time.Time) error {
+	var totals struct {
+		Reviews       int
+		CardsReviewed int
+		CorrectCount  int
+	}
+	if err := db.Model(&models.ReviewLog{}).
+		Select("COUNT(*) AS reviews, COUNT(DISTINCT card_id) AS cards_reviewed, COALESCE(SUM(CASE WHEN performance >= ? THEN 1 ELSE 0 END), 0) AS correct_count", models.PassingPerformance).
+		Where("session_id = ?", session.ID).
+		Scan(&totals).Error; err != nil {
+		return err
+	}
+
+	session.EndedAt = &endedAt
+	session.Reviews = totals.Reviews
+	session.CardsReviewed = totals.CardsReviewed
+	session.CorrectCount = totals.CorrectCount
+	session.DurationSeconds = max(0, int(endedAt.Sub(session.StartedAt).Seconds()))
+	session.Accuracy = 0
+	if totals.Reviews > 0 {
+		session.Accuracy = float64(totals.CorrectCount) / float64(totals.Reviews) * 100
+	}
+	return db.Save(session).Error
+}
+
+// sessionSummary -> One line describing a closed session, e.g. "Studied 30 cards in 12 minutes, 80% correct"
+func sessionSummary(session models.StudySession) string {
+	return fmt.Sprintf("Studied %d cards in %d minutes, %.0f%% correct",
+		session.CardsReviewed, (session.DurationSeconds+30)/60, session.Accuracy)
+}
+
+// StartStudySession -> Handler to open a study session, reviews are linked to it until it's ended.
+// A session left open is closed first, at its last review, so it doesn't count the time in between
+func (h *StudyHandler) StartStudySession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	previous, err := openStudySession(h.db, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve study session", err))
+		return
+	}
+
+	if previous != nil {
+		endedAt := previous.StartedAt
+		var lastReview models.ReviewLog
+		err := h.db.Where("session_id = ?", previous.ID).Order("reviewed_at DESC").First(&lastReview).Error
+		if err == nil {
+			endedAt = lastReview.ReviewedAt
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			c.Error(apperrors.Internal("Failed to close previous study session", err))
+			return
+		}
+
+		if err := closeStudySession(h.db, previous, endedAt); err != nil {
+			c.Error(apperrors.Internal("Failed to close previous study session", err))
+			return
+		}
+	}
+
+	session := models.StudySession{
+		UserID:    userID.(uint),
+		StartedAt: time.Now(),
+	}
+	if err := h.db.Create(&session).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to start study session", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":          "Study session started",
+		"session":          session,
+		"previous_session": previous,
+	})
+}
+
+// EndStudySession -> Handler to close the open study session and summarize it
+func (h *StudyHandler) EndStudySession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	session, err := openStudySession(h.db, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve study session", err))
+		return
+	}
+	if session == nil {
+		c.Error(apperrors.NotFound("No open study session"))
+		return
+	}
+
+	if err := closeStudySession(h.db, session, time.Now()); err != nil {
+		c.Error(apperrors.Internal("Failed to end study session", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": sessionSummary(*session),
+		"session": session,
+	})
+}
+
+// GetStudySessionsRequest -> Query parameters for the study session history
+type GetStudySessionsRequest struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// GetStudySessions -> Handler to list the user's study sessions, newest first
+func (h *StudyHandler) GetStudySessions(c *gin.Context) {
+	var req GetStudySessionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	page := req.Page
+	if page == 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize == 0 {
+		pageSize = 20
+	}
+
+	query := h.db.Model(&models.StudySession{}).Where("user_id = ?", userID)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve study sessions", err))
+		return
+	}
+
+	var sessions []models.StudySession
+	if err := query.Order("started_at DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&sessions).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve study sessions", err))
+		return
+	}
+
+	setPaginationHeaders(c, page, pageSize, total)
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions":  sessions,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
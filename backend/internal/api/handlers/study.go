@@ -1,8 +1,15 @@
 package handlers
 
 import (
+	"FlashQuiz/internal/api/apperrors"
 	"FlashQuiz/internal/models"
+	"FlashQuiz/internal/stats"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -19,257 +26,1097 @@ func NewStudyHandler(db *gorm.DB) *StudyHandler {
 
 // GetNextCardsRequest -> Struct for getting next cards to study
 type GetNextCardsRequest struct {
-	DeckID uint `json:"deck_id" binding:"required"`
-	Limit  int  `json:"limit"`
+	DeckID uint   `json:"deck_id" binding:"required"`
+	Limit  int    `json:"limit"`
+	Mode   string `json:"mode" binding:"omitempty,oneof=scheduled cram"` // Defaults to "scheduled"
+	// Cram mode only: restrict to cards whose accuracy (correct/reviews) is below this value (0-1)
+	MasteryThreshold *float64 `json:"mastery_threshold" binding:"omitempty,min=0,max=1"`
 }
 
 // GetNextCards -> Get the next flashcards due for review
 func (h *StudyHandler) GetNextCards(c *gin.Context) {
 	var req GetNextCardsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperrors.Validation(err))
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
-	// Default limit to 20 if not specified
+	// Default limit to 20 if not specified (cram mode returns the whole deck unless limited)
 	limit := req.Limit
-	if limit <= 0 {
+	if limit <= 0 && req.Mode != "cram" {
 		limit = 20
 	}
 
 	// Verify the deck exists and user has access to it
 	var deck models.Deck
 	if err := h.db.First(&deck, req.DeckID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+		c.Error(apperrors.NotFound("Deck not found"))
 		return
 	}
 
-	if !deck.IsPublic && deck.UserID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to study this deck"})
+	allowed, err := canViewDeck(h.db, deck, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to study this deck"))
 		return
 	}
 
-	// Get cards and their progress
-	// First, get all cards from the deck
-	var cards []models.FlashCard
-	if err := h.db.Where("deck_id = ?", req.DeckID).Find(&cards).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve flashcards"})
+	now := time.Now()
+	queue, err := loadStudyQueue(h.db, userID.(uint), req.DeckID, now)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve flashcards", err))
 		return
 	}
 
-	if len(cards) == 0 {
+	if len(queue.cards) == 0 {
 		c.JSON(http.StatusOK, gin.H{"cards": []string{}, "message": "No cards in this deck"})
 		return
 	}
 
+	if req.Mode == "cram" {
+		c.JSON(http.StatusOK, cramCards(queue, req.MasteryThreshold, limit))
+		return
+	}
+
+	// Respect the user's daily new card and review caps
+	settings, err := loadUserSettings(h.db, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve settings", err))
+		return
+	}
+
+	newLeft, reviewsLeft, err := dailyAllowance(h.db, settings, now)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve card progress", err))
+		return
+	}
+
+	// Prioritize due cards, then new cards
+	var cardsToReturn []gin.H
+	remainingLimit := limit
+
+	for _, card := range queue.dueCards {
+		if remainingLimit <= 0 || reviewsLeft <= 0 {
+			break
+		}
+
+		progress := queue.progress[card.ID]
+		cardsToReturn = append(cardsToReturn, gin.H{
+			"card":     card,
+			"progress": progress,
+			"note":     queue.note(card.ID),
+			"status":   "due",
+		})
+		remainingLimit--
+		reviewsLeft--
+	}
+
+	for _, card := range queue.newCards {
+		if remainingLimit <= 0 || newLeft <= 0 {
+			break
+		}
+
+		cardsToReturn = append(cardsToReturn, gin.H{
+			"card":     card,
+			"progress": nil,
+			"note":     queue.note(card.ID),
+			"status":   "new",
+		})
+		remainingLimit--
+		newLeft--
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cards":          cardsToReturn,
+		"due_count":      len(queue.dueCards),
+		"new_count":      len(queue.newCards),
+		"learning_count": len(queue.learningCards),
+	})
+}
+
+// GetNextCardRequest -> Query parameters for fetching a single card to study
+type GetNextCardRequest struct {
+	DeckID uint `form:"deck_id" binding:"required"`
+}
+
+// GetNextCard -> Get the one card to study next in a deck, with the same priorities and daily caps
+// as GetNextCards. Clients fetch again after grading it, so the schedule stays authoritative
+func (h *StudyHandler) GetNextCard(c *gin.Context) {
+	var req GetNextCardRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	// Verify the deck exists and user has access to it
+	var deck models.Deck
+	if err := h.db.First(&deck, req.DeckID).Error; err != nil {
+		c.Error(apperrors.NotFound("Deck not found"))
+		return
+	}
+
+	allowed, err := canViewDeck(h.db, deck, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to study this deck"))
+		return
+	}
+
+	now := time.Now()
+	queue, err := loadStudyQueue(h.db, userID.(uint), req.DeckID, now)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve flashcards", err))
+		return
+	}
+
+	settings, err := loadUserSettings(h.db, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve settings", err))
+		return
+	}
+
+	newLeft, reviewsLeft, err := dailyAllowance(h.db, settings, now)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve card progress", err))
+		return
+	}
+
+	response := gin.H{
+		"card":           nil,
+		"progress":       nil,
+		"note":           nil,
+		"status":         nil,
+		"due_count":      len(queue.dueCards),
+		"new_count":      len(queue.newCards),
+		"learning_count": len(queue.learningCards),
+		"reviews_left":   reviewsLeft,
+		"new_left":       newLeft,
+	}
+
+	// Due cards first, then new cards, each only while today's allowance lasts
+	switch {
+	case len(queue.dueCards) > 0 && reviewsLeft > 0:
+		card := queue.dueCards[0]
+		response["card"], response["progress"], response["status"] = card, queue.progress[card.ID], "due"
+		response["note"] = queue.note(card.ID)
+	case len(queue.newCards) > 0 && newLeft > 0:
+		card := queue.newCards[0]
+		response["card"], response["status"] = card, "new"
+		response["note"] = queue.note(card.ID)
+	case len(queue.dueCards) > 0 || len(queue.newCards) > 0:
+		response["message"] = "Daily limit reached, come back tomorrow"
+	default:
+		response["message"] = "Nothing due right now"
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetDueAllRequest -> Query parameters for the cross-deck due queue
+type GetDueAllRequest struct {
+	Limit        int `form:"limit" binding:"omitempty,min=1,max=500"`          // Defaults to 50
+	PerDeckLimit int `form:"per_deck_limit" binding:"omitempty,min=1,max=500"` // No per-deck cap when omitted
+}
+
+// GetDueAll -> Get the cards due across all of the user's decks, interleaved round-robin by deck
+// so one large deck doesn't crowd out the rest. Each deck's most overdue cards come first
+func (h *StudyHandler) GetDueAll(c *gin.Context) {
+	var req GetDueAllRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = 50
+	}
+
+	var decks []models.Deck
+	if err := h.db.Where("user_id = ?", userID).Order("id ASC").Find(&decks).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve decks", err))
+		return
+	}
+
+	settings, err := loadUserSettings(h.db, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve settings", err))
+		return
+	}
+
+	now := time.Now()
+	_, reviewsLeft, err := dailyAllowance(h.db, settings, now)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve card progress", err))
+		return
+	}
+
+	type deckQueue struct {
+		deck  models.Deck
+		queue *studyQueue
+	}
+	queues := make([]deckQueue, 0, len(decks))
+	totalDue := 0
+	for _, deck := range decks {
+		queue, err := loadStudyQueue(h.db, userID.(uint), deck.ID, now)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to retrieve flashcards", err))
+			return
+		}
+		if len(queue.dueCards) == 0 {
+			continue
+		}
+
+		sort.SliceStable(queue.dueCards, func(i, j int) bool {
+			return queue.progress[queue.dueCards[i].ID].NextReviewDate.Before(queue.progress[queue.dueCards[j].ID].NextReviewDate)
+		})
+		if req.PerDeckLimit > 0 && len(queue.dueCards) > req.PerDeckLimit {
+			queue.dueCards = queue.dueCards[:req.PerDeckLimit]
+		}
+
+		queues = append(queues, deckQueue{deck: deck, queue: queue})
+		totalDue += len(queue.dueCards)
+	}
+
+	// Take one card from each deck in turn until the limit, the daily review cap or the cards run out
+	remaining := min(limit, reviewsLeft, totalDue)
+	cardsToReturn := make([]gin.H, 0, remaining)
+	for round := 0; len(cardsToReturn) < remaining; round++ {
+		for _, dq := range queues {
+			if len(cardsToReturn) >= remaining {
+				break
+			}
+			if round >= len(dq.queue.dueCards) {
+				continue
+			}
+
+			card := dq.queue.dueCards[round]
+			cardsToReturn = append(cardsToReturn, gin.H{
+				"card":       card,
+				"progress":   dq.queue.progress[card.ID],
+				"note":       dq.queue.note(card.ID),
+				"status":     "due",
+				"deck_id":    dq.deck.ID,
+				"deck_title": dq.deck.Title,
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cards":        cardsToReturn,
+		"due_count":    totalDue,
+		"reviews_left": reviewsLeft,
+	})
+}
+
+// studyQueue -> A deck's cards for one user, grouped by where they are in the schedule
+type studyQueue struct {
+	cards         []models.FlashCard
+	progress      map[uint]*models.CardProgress // Keyed by card ID, missing for cards never reviewed
+	notes         map[uint]string               // The user's private notes, keyed by card ID
+	newCards      []models.FlashCard
+	dueCards      []models.FlashCard
+	learningCards []models.FlashCard
+}
+
+// note -> The user's note on a card, nil when they haven't written one
+func (q *studyQueue) note(cardID uint) *string {
+	if note, ok := q.notes[cardID]; ok {
+		return &note
+	}
+	return nil
+}
+
+// loadStudyQueue -> Loads a deck's cards with the user's progress and groups them by status:
+// new, due for review, and learning
+func loadStudyQueue(db *gorm.DB, userID, deckID uint, now time.Time) (*studyQueue, error) {
+	queue := &studyQueue{progress: make(map[uint]*models.CardProgress), notes: make(map[uint]string)}
+
+	// First, get all cards from the deck
+	if err := db.Where("deck_id = ?", deckID).Find(&queue.cards).Error; err != nil {
+		return nil, err
+	}
+	if len(queue.cards) == 0 {
+		return queue, nil
+	}
+
 	// Extract card IDs
-	cardIDs := make([]uint, len(cards))
-	for i, card := range cards {
+	cardIDs := make([]uint, len(queue.cards))
+	for i, card := range queue.cards {
 		cardIDs[i] = card.ID
 	}
 
 	// Find existing progress records for these cards
 	var progresses []models.CardProgress
-	if err := h.db.Where("user_id = ? AND card_id IN ?", userID, cardIDs).Find(&progresses).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve card progress"})
-		return
+	if err := db.Where("user_id = ? AND card_id IN ?", userID, cardIDs).Find(&progresses).Error; err != nil {
+		return nil, err
 	}
 
 	// Map card IDs to their progress
-	progressMap := make(map[uint]*models.CardProgress)
 	for i := range progresses {
-		progressMap[progresses[i].CardID] = &progresses[i]
+		queue.progress[progresses[i].CardID] = &progresses[i]
 	}
 
-	// Group cards by their status: new, due for review, and learning
-	var newCards, dueCards, learningCards []models.FlashCard
-	now := time.Now()
+	var notes []models.CardNote
+	if err := db.Where("user_id = ? AND card_id IN ?", userID, cardIDs).Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	for _, note := range notes {
+		queue.notes[note.CardID] = note.Note
+	}
 
-	for _, card := range cards {
-		progress, exists := progressMap[card.ID]
+	// Suspended cards are left out entirely
+	active := queue.cards[:0]
+	for _, card := range queue.cards {
+		if progress, exists := queue.progress[card.ID]; exists && progress.Suspended {
+			continue
+		}
+		active = append(active, card)
+	}
+	queue.cards = active
+
+	for _, card := range queue.cards {
+		progress, exists := queue.progress[card.ID]
 		if !exists {
 			// Card has no progress record - it's new
-			newCards = append(newCards, card)
+			queue.newCards = append(queue.newCards, card)
 			continue
 		}
 
 		// Card has progress
 		if progress.NextReviewDate.Before(now) {
 			// Card is due for review
-			dueCards = append(dueCards, card)
+			queue.dueCards = append(queue.dueCards, card)
 		} else {
 			// Card is still being learned but not due yet
-			learningCards = append(learningCards, card)
+			queue.learningCards = append(queue.learningCards, card)
 		}
 	}
 
-	// Prioritize due cards, then new cards
-	var cardsToReturn []gin.H
-	remainingLimit := limit
+	// Starred cards go ahead of the rest, otherwise the order is kept
+	starredFirst := func(cards []models.FlashCard) {
+		sort.SliceStable(cards, func(i, j int) bool {
+			return cards[i].Starred && !cards[j].Starred
+		})
+	}
+	starredFirst(queue.newCards)
+	starredFirst(queue.dueCards)
 
-	for _, card := range dueCards {
-		if remainingLimit <= 0 {
-			break
-		}
+	return queue, nil
+}
 
-		progress := progressMap[card.ID]
-		cardsToReturn = append(cardsToReturn, gin.H{
-			"card":     card,
-			"progress": progress,
-			"status":   "due",
-		})
-		remainingLimit--
+// startOfDay -> Midnight of t's day in the given timezone
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// dailyAllowance -> How many new cards and reviews the user can still do today (in their timezone).
+// A card introduced today is one whose progress record was created today, a review is a card
+// known before today that was reviewed today
+func dailyAllowance(db *gorm.DB, settings models.UserSettings, now time.Time) (newLeft, reviewsLeft int, err error) {
+	// Compared in UTC, which is how timestamps are stored
+	today := startOfDay(now, settings.Location()).UTC()
+
+	var introducedToday, reviewedToday int64
+	if err := db.Model(&models.CardProgress{}).
+		Where("user_id = ? AND created_at >= ?", settings.UserID, today).
+		Count(&introducedToday).Error; err != nil {
+		return 0, 0, err
+	}
+	if err := db.Model(&models.CardProgress{}).
+		Where("user_id = ? AND created_at < ? AND last_reviewed_at >= ?", settings.UserID, today, today).
+		Count(&reviewedToday).Error; err != nil {
+		return 0, 0, err
 	}
 
-	for _, card := range newCards {
-		if remainingLimit <= 0 {
-			break
+	return max(0, settings.NewCardsPerDay-int(introducedToday)), max(0, settings.MaxReviewsPerDay-int(reviewedToday)), nil
+}
+
+// cramCards -> Every card in the deck in shuffled order, ignoring due dates entirely.
+// Cards never reviewed count as 0% mastered
+func cramCards(queue *studyQueue, masteryThreshold *float64, limit int) gin.H {
+	selected := make([]gin.H, 0, len(queue.cards))
+	for _, card := range queue.cards {
+		progress := queue.progress[card.ID]
+
+		if masteryThreshold != nil {
+			mastery := 0.0
+			if progress != nil && progress.ReviewCount > 0 {
+				mastery = float64(progress.CorrectCount) / float64(progress.ReviewCount)
+			}
+			if mastery >= *masteryThreshold {
+				continue
+			}
 		}
 
-		cardsToReturn = append(cardsToReturn, gin.H{
+		selected = append(selected, gin.H{
 			"card":     card,
-			"progress": nil,
-			"status":   "new",
+			"progress": progress,
+			"note":     queue.note(card.ID),
+			"status":   "cram",
 		})
-		remainingLimit--
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"cards":          cardsToReturn,
-		"due_count":      len(dueCards),
-		"new_count":      len(newCards),
-		"learning_count": len(learningCards),
+	rand.Shuffle(len(selected), func(i, j int) {
+		selected[i], selected[j] = selected[j], selected[i]
 	})
+	if limit > 0 && len(selected) > limit {
+		selected = selected[:limit]
+	}
+
+	return gin.H{
+		"cards":      selected,
+		"mode":       "cram",
+		"cram_count": len(selected),
+	}
 }
 
 // UpdateCardProgressRequest -> Struct for updating card progress
 type UpdateCardProgressRequest struct {
-	CardID      uint `json:"card_id" binding:"required"`
-	Performance int  `json:"performance" binding:"required,min=1,max=5"` // 1-5 scale, where 1=fail, 5=perfect
-	TimeSpent   int  `json:"time_spent"`                                 // Time spent on review in seconds
+	CardID      uint   `json:"card_id" binding:"required"`
+	Performance *int   `json:"performance" binding:"omitempty,min=0,max=5"`          // SM-2 0-5 scale, where 0=blackout, 3=pass, 5=perfect
+	Grade       string `json:"grade" binding:"omitempty,oneof=again hard good easy"` // Alternative to performance, mapped as described on the Grade constants
+	TimeSpent   int    `json:"time_spent" binding:"omitempty,min=0,max=3600"`        // Time spent on review in seconds, at most maxTimeSpentSeconds
+	// Cram reviews are non-scheduling: they're acknowledged but never touch the card's
+	// ease, interval, due date or review counts, so exam cramming can't distort the real schedule
+	Cram bool `json:"cram"`
 }
 
 // UpdateCardProgress -> Update a card's progress after the user reviews it
 func (h *StudyHandler) UpdateCardProgress(c *gin.Context) {
 	var req UpdateCardProgressRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	performance, err := resolvePerformance(req.Performance, req.Grade)
+	if err != nil {
+		c.Error(apperrors.BadRequest(err.Error()))
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
 	// Verify the card exists
 	var card models.FlashCard
 	if err := h.db.Preload("Deck").First(&card, req.CardID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Card not found"})
+		c.Error(apperrors.NotFound("Card not found"))
 		return
 	}
 
 	// Verify the user has access to the card's deck
-	if !card.Deck.IsPublic && card.Deck.UserID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to update this card's progress"})
+	allowed, err := canViewDeck(h.db, card.Deck, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to update this card's progress"))
 		return
 	}
 
-	// Get or create progress record
-	var progress models.CardProgress
-	err := h.db.Where("user_id = ? AND card_id = ?", userID, req.CardID).First(&progress).Error
-
-	isNew := err != nil
-
-	if isNew {
-		// Create a new progress record
-		progress = models.CardProgress{
-			UserID:       userID.(uint),
-			CardID:       req.CardID,
-			EaseFactor:   2.5, // Default value
-			Interval:     0,
-			ReviewCount:  0,
-			CorrectCount: 0,
-			Status:       "new",
-		}
+	if req.Cram {
+		c.JSON(http.StatusOK, gin.H{
+			"message":    "Cram review acknowledged, schedule unchanged",
+			"is_correct": performance >= models.PassingPerformance,
+			"scheduled":  false,
+		})
+		return
 	}
 
-	// Update the progress using the SuperMemo SM-2 algorithm
-	// This is a simplified version of the algorithm
+	progress, err := recordReview(h.db, userID.(uint), req.CardID, performance, req.Grade, req.TimeSpent)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to update card progress", err))
+		return
+	}
 
-	// Update last reviewed time
-	progress.LastReviewedAt = time.Now()
-	progress.ReviewCount++
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Card progress updated successfully",
+		"progress":         progress,
+		"next_review_date": progress.NextReviewDate.Format(time.RFC3339),
+		"interval_days":    progress.Interval,
+	})
+}
 
-	// Update ease factor and interval based on performance
-	// SM-2 algorithm uses a 0-5 scale, where:
-	// 0 = complete blackout, 1 = incorrect but remembered, 2 = incorrect but close
-	// 3 = correct but difficult, 4 = correct, 5 = correct and easy
+// GetReviewHistoryRequest -> Query parameters for the review history
+type GetReviewHistoryRequest struct {
+	CardID   uint `form:"card_id"`
+	DeckID   uint `form:"deck_id"`
+	Page     int  `form:"page" binding:"omitempty,min=1"`
+	PageSize int  `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
 
-	isCorrect := req.Performance >= 3
-	if isCorrect {
-		progress.CorrectCount++
+// GetReviewHistory -> Chronological log of the user's reviews, optionally for one card or deck
+func (h *StudyHandler) GetReviewHistory(c *gin.Context) {
+	var req GetReviewHistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
 	}
 
-	// Calculate new ease factor (EF)
-	easeFactor := progress.EaseFactor + (0.1 - (5-float64(req.Performance))*(0.08+(5-float64(req.Performance))*0.02))
-	if easeFactor < 1.3 {
-		easeFactor = 1.3 // Minimum ease factor
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
 	}
-	progress.EaseFactor = easeFactor
 
-	// Calculate new interval
-	var newInterval int
-	if req.Performance < 3 {
-		// If response was incorrect, start over
-		newInterval = 1
-		progress.Status = "learning"
-	} else {
-		// If response was correct, increase interval
-		if progress.Interval == 0 {
-			newInterval = 1
-		} else if progress.Interval == 1 {
-			newInterval = 6
-		} else {
-			newInterval = int(float64(progress.Interval) * progress.EaseFactor)
+	page := req.Page
+	if page == 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize == 0 {
+		pageSize = 50
+	}
+
+	query := h.db.Model(&models.ReviewLog{}).Where("review_logs.user_id = ?", userID)
+	if req.CardID > 0 {
+		query = query.Where("review_logs.card_id = ?", req.CardID)
+	}
+	if req.DeckID > 0 {
+		query = query.Joins("JOIN flash_cards ON flash_cards.id = review_logs.card_id").
+			Where("flash_cards.deck_id = ?", req.DeckID)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve review history", err))
+		return
+	}
+
+	var logs []models.ReviewLog
+	if err := query.Order("review_logs.reviewed_at ASC, review_logs.id ASC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&logs).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve review history", err))
+		return
+	}
+
+	setPaginationHeaders(c, page, pageSize, total)
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviews":   logs,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// RescheduleRequest -> Struct for spreading an overdue backlog over the coming days
+type RescheduleRequest struct {
+	Days   int  `json:"days" binding:"omitempty,min=1,max=365"` // Spread window, defaults to 7
+	DeckID uint `json:"deck_id"`                                // Optional, only reschedule this deck's cards
+}
+
+// rescheduleDay -> Day of the window the card at rank (0 = most overdue) of count overdue cards
+// moves to. Days go by rank quantile, so however the overdue times are spread every day gets an
+// even share and the longest waiting still come back first
+func rescheduleDay(rank, count, days int) int {
+	return rank * days / count
+}
+
+// RescheduleOverdue -> Handler to redistribute overdue cards across the next N days after a break.
+// Cards are spread evenly over the days in order of how overdue they are (see rescheduleDay), so
+// the longest waiting come back first. Only NextReviewDate changes, ease and interval stay
+func (h *StudyHandler) RescheduleOverdue(c *gin.Context) {
+	var req RescheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	days := req.Days
+	if days == 0 {
+		days = 7
+	}
+
+	now := time.Now()
+	query := h.db.Where("user_id = ? AND next_review_date < ?", userID, now)
+	if req.DeckID > 0 {
+		query = query.Where("card_id IN (?)", h.db.Model(&models.FlashCard{}).Select("id").Where("deck_id = ?", req.DeckID))
+	}
+
+	// Most overdue first
+	var overdue []models.CardProgress
+	if err := query.Order("next_review_date ASC").Find(&overdue).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve card progress", err))
+		return
+	}
+
+	perDay := make([]int, days)
+	tx := h.db.Begin()
+
+	for i, progress := range overdue {
+		day := rescheduleDay(i, len(overdue), days)
+		perDay[day]++
+
+		if err := tx.Model(&progress).Update("next_review_date", now.AddDate(0, 0, day)).Error; err != nil {
+			tx.Rollback()
+			c.Error(apperrors.Internal("Failed to reschedule cards", err))
+			return
 		}
+	}
 
-		if progress.Status == "new" {
-			progress.Status = "learning"
-		} else if newInterval > 21 {
-			// After 3 weeks interval, consider it "review" status
-			progress.Status = "review"
+	if err := tx.Commit().Error; err != nil {
+		c.Error(apperrors.Internal("Failed to reschedule cards", err))
+		return
+	}
+
+	if err := stats.RecomputeDueCounts(h.db, userID.(uint)); err != nil {
+		log.Printf("Failed to recompute due counts for user %d: %v", userID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Overdue cards rescheduled successfully",
+		"rescheduled": len(overdue),
+		"days":        days,
+		"per_day":     perDay,
+	})
+}
+
+// GetLeechesRequest -> Query parameters for listing leech cards
+type GetLeechesRequest struct {
+	DeckID uint `form:"deck_id"` // Optional, only this deck's leeches
+}
+
+// GetLeeches -> Handler to list the user's leech cards grouped by deck, so they can be fixed or deleted
+func (h *StudyHandler) GetLeeches(c *gin.Context) {
+	var req GetLeechesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	query := h.db.Preload("FlashCard.Deck").
+		Joins("JOIN flash_cards ON flash_cards.id = card_progresses.card_id AND flash_cards.deleted_at IS NULL").
+		Where("card_progresses.user_id = ? AND card_progresses.is_leech = ?", userID, true)
+	if req.DeckID > 0 {
+		query = query.Where("flash_cards.deck_id = ?", req.DeckID)
+	}
+
+	var leeches []models.CardProgress
+	if err := query.Order("flash_cards.deck_id ASC, card_progresses.lapses DESC").Find(&leeches).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve leeches", err))
+		return
+	}
+
+	decks := make([]gin.H, 0)
+	var cards []gin.H
+	for i, progress := range leeches {
+		cards = append(cards, gin.H{
+			"card":      progress.FlashCard,
+			"lapses":    progress.Lapses,
+			"suspended": progress.Suspended,
+		})
+
+		// Rows are ordered by deck, so a group ends where the deck changes
+		if i == len(leeches)-1 || leeches[i+1].FlashCard.DeckID != progress.FlashCard.DeckID {
+			decks = append(decks, gin.H{
+				"deck_id":    progress.FlashCard.DeckID,
+				"deck_title": progress.FlashCard.Deck.Title,
+				"cards":      cards,
+			})
+			cards = nil
 		}
 	}
 
-	progress.Interval = newInterval
-	progress.NextReviewDate = progress.LastReviewedAt.AddDate(0, 0, newInterval)
+	c.JSON(http.StatusOK, gin.H{
+		"decks": decks,
+		"total": len(leeches),
+	})
+}
+
+// GetMasteredCardsRequest -> Query parameters for listing mastered cards
+type GetMasteredCardsRequest struct {
+	DeckID   uint `form:"deck_id"` // Optional, only this deck's mastered cards
+	Page     int  `form:"page" binding:"omitempty,min=1"`
+	PageSize int  `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// GetMasteredCards -> Handler to list the cards the user has mastered, by the same rules and
+// mastery interval setting as mastered_count in the study stats. Longest intervals come first
+func (h *StudyHandler) GetMasteredCards(c *gin.Context) {
+	var req GetMasteredCardsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	page := req.Page
+	if page == 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize == 0 {
+		pageSize = 50
+	}
+
+	settings, err := loadUserSettings(h.db, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve settings", err))
+		return
+	}
+
+	query := h.db.Model(&models.CardProgress{}).
+		Joins("JOIN flash_cards ON flash_cards.id = card_progresses.card_id AND flash_cards.deleted_at IS NULL").
+		Where("card_progresses.user_id = ?", userID).
+		Scopes(stats.Mastered(settings.MasteryIntervalDays))
+	if req.DeckID > 0 {
+		query = query.Where("flash_cards.deck_id = ?", req.DeckID)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve mastered cards", err))
+		return
+	}
+
+	var mastered []models.CardProgress
+	if err := query.Preload("FlashCard").
+		Order("card_progresses.interval DESC, card_progresses.id ASC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&mastered).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve mastered cards", err))
+		return
+	}
+
+	cards := make([]gin.H, 0, len(mastered))
+	for _, progress := range mastered {
+		cards = append(cards, gin.H{
+			"card":             progress.FlashCard,
+			"interval":         progress.Interval,
+			"ease_factor":      progress.EaseFactor,
+			"next_review_date": progress.NextReviewDate,
+		})
+	}
+
+	setPaginationHeaders(c, page, pageSize, total)
+
+	c.JSON(http.StatusOK, gin.H{
+		"cards":                 cards,
+		"mastery_interval_days": settings.MasteryIntervalDays,
+		"total":                 total,
+		"page":                  page,
+		"page_size":             pageSize,
+	})
+}
+
+// UnsuspendCardRequest -> Struct for returning a leech to study
+type UnsuspendCardRequest struct {
+	CardID uint `json:"card_id" binding:"required"`
+}
+
+// UnsuspendCard -> Handler to clear a card's leech tag and suspension, typically after fixing it.
+// The lapse count starts over so the card isn't flagged again on its next failure
+func (h *StudyHandler) UnsuspendCard(c *gin.Context) {
+	var req UnsuspendCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var progress models.CardProgress
+	if err := h.db.Where("user_id = ? AND card_id = ?", userID, req.CardID).First(&progress).Error; err != nil {
+		c.Error(apperrors.NotFound("No progress found for this card"))
+		return
+	}
+
+	progress.IsLeech = false
+	progress.Suspended = false
+	progress.Lapses = 0
+	if err := h.db.Save(&progress).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to update card progress", err))
+		return
+	}
+
+	if err := stats.RecomputeDueCounts(h.db, userID.(uint)); err != nil {
+		log.Printf("Failed to recompute due counts for user %d: %v", userID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Card returned to study",
+		"progress": progress,
+	})
+}
+
+// PreviewIntervalsRequest -> Query parameters for previewing a card's next intervals
+type PreviewIntervalsRequest struct {
+	CardID uint `form:"card_id" binding:"required"`
+}
+
+// PreviewIntervals -> Handler to show what each grade would schedule the card for, e.g. to label
+// the again/hard/good/easy buttons. The scheduler runs on copies of the progress, nothing is saved
+func (h *StudyHandler) PreviewIntervals(c *gin.Context) {
+	var req PreviewIntervalsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var card models.FlashCard
+	if err := h.db.Preload("Deck").First(&card, req.CardID).Error; err != nil {
+		c.Error(apperrors.NotFound("Flashcard not found"))
+		return
+	}
+
+	allowed, err := canViewDeck(h.db, card.Deck, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to study this card"))
+		return
+	}
+
+	progress := newCardProgress(userID.(uint), card.ID)
+	err = h.db.Where("user_id = ? AND card_id = ?", userID, card.ID).First(&progress).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.Error(apperrors.Internal("Failed to retrieve card progress", err))
+		return
+	}
+
+	now := time.Now()
+	previews := make([]gin.H, 0, len(grades))
+	for _, grade := range grades {
+		next := progress
+		applyReview(&next, gradePerformance[grade], grade, now, card.Deck.LearningSteps)
+		previews = append(previews, gin.H{
+			"grade":            grade,
+			"performance":      gradePerformance[grade],
+			"status":           next.Status,
+			"interval":         next.Interval,
+			"next_review_date": next.NextReviewDate,
+			"due_in_seconds":   int(next.NextReviewDate.Sub(now).Seconds()),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"card_id":  card.ID,
+		"status":   progress.Status,
+		"previews": previews,
+	})
+}
 
-	// Save the progress
-	if isNew {
-		if err := h.db.Create(&progress).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create card progress"})
+// ResetCardRequest -> Struct for resetting the caller's progress on one card
+type ResetCardRequest struct {
+	CardID uint `json:"card_id" binding:"required"`
+}
+
+// ResetCard -> Handler to make one card new again for the caller, like the deck-wide reset. The
+// progress row is removed (review history is kept), so the card is scheduled from scratch
+func (h *StudyHandler) ResetCard(c *gin.Context) {
+	var req ResetCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var card models.FlashCard
+	if err := h.db.Preload("Deck").First(&card, req.CardID).Error; err != nil {
+		c.Error(apperrors.NotFound("Flashcard not found"))
+		return
+	}
+
+	allowed, err := canViewDeck(h.db, card.Deck, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to reset progress on this card"))
+		return
+	}
+
+	result := h.db.Where("user_id = ? AND card_id = ?", userID, card.ID).Delete(&models.CardProgress{})
+	if result.Error != nil {
+		c.Error(apperrors.Internal("Failed to reset card progress", result.Error))
+		return
+	}
+
+	if err := stats.RecomputeDueCounts(h.db, userID.(uint)); err != nil {
+		log.Printf("Failed to recompute due counts for user %d: %v", userID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Card progress reset successfully",
+		"card_id":     card.ID,
+		"status":      "new",
+		"progress":    nil,
+		"was_studied": result.RowsAffected > 0,
+	})
+}
+
+// MarkKnownRequest -> Struct for fast-tracking cards the user already knows
+type MarkKnownRequest struct {
+	CardIDs      []uint `json:"card_ids" binding:"required,min=1,max=500"`
+	IntervalDays int    `json:"interval_days" binding:"omitempty,min=1,max=365"` // Defaults to 21
+}
+
+// MarkKnown -> Handler to put cards straight into review with a long first interval, so cards
+// the user already knows don't come up as new. Cards with progress already are left alone
+func (h *StudyHandler) MarkKnown(c *gin.Context) {
+	var req MarkKnownRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	if req.IntervalDays == 0 {
+		req.IntervalDays = 21
+	}
+
+	var cards []models.FlashCard
+	if err := h.db.Preload("Deck").Where("id IN ?", req.CardIDs).Find(&cards).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve cards", err))
+		return
+	}
+
+	found := make(map[uint]bool, len(cards))
+	for _, card := range cards {
+		allowed, err := canViewDeck(h.db, card.Deck, userID.(uint))
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to check deck access", err))
 			return
 		}
-	} else {
-		if err := h.db.Save(&progress).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update card progress"})
+		if !allowed {
+			c.Error(apperrors.Forbidden(fmt.Sprintf("You don't have permission to study card %d", card.ID)))
+			return
+		}
+		found[card.ID] = true
+	}
+	for _, cardID := range req.CardIDs {
+		if !found[cardID] {
+			c.Error(apperrors.NotFound(fmt.Sprintf("Card %d not found", cardID)))
+			return
+		}
+	}
+
+	var studied []uint
+	if err := h.db.Model(&models.CardProgress{}).
+		Where("user_id = ? AND card_id IN ?", userID, req.CardIDs).
+		Pluck("card_id", &studied).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve card progress", err))
+		return
+	}
+	skip := make(map[uint]bool, len(studied))
+	for _, cardID := range studied {
+		skip[cardID] = true
+	}
+
+	now := time.Now()
+	tx := h.db.Begin()
+
+	marked := 0
+	for _, card := range cards {
+		if skip[card.ID] {
+			continue
+		}
+		progress := models.CardProgress{
+			UserID:         userID.(uint),
+			CardID:         card.ID,
+			EaseFactor:     2.5,
+			Interval:       req.IntervalDays,
+			LastReviewedAt: now,
+			NextReviewDate: now.AddDate(0, 0, req.IntervalDays),
+			Status:         "review",
+		}
+		if err := tx.Create(&progress).Error; err != nil {
+			tx.Rollback()
+			c.Error(apperrors.Internal("Failed to mark cards as known", err))
 			return
 		}
+		marked++
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Error(apperrors.Internal("Failed to mark cards as known", err))
+		return
+	}
+
+	if err := stats.RecomputeDueCounts(h.db, userID.(uint)); err != nil {
+		log.Printf("Failed to recompute due counts for user %d: %v", userID, err)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":          "Card progress updated successfully",
-		"progress":         progress,
-		"next_review_date": progress.NextReviewDate.Format(time.RFC3339),
-		"interval_days":    progress.Interval,
+		"message":         "Cards marked as known",
+		"marked":          marked,
+		"already_studied": len(studied),
+		"interval_days":   req.IntervalDays,
 	})
 }
 
@@ -282,13 +1129,13 @@ type GetStudyStatsRequest struct {
 func (h *StudyHandler) GetStudyStats(c *gin.Context) {
 	var req GetStudyStatsRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperrors.Validation(err))
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
@@ -300,12 +1147,17 @@ func (h *StudyHandler) GetStudyStats(c *gin.Context) {
 		// Verify the deck exists and user has access to it
 		var deck models.Deck
 		if err := h.db.First(&deck, req.DeckID).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+			c.Error(apperrors.NotFound("Deck not found"))
 			return
 		}
 
-		if !deck.IsPublic && deck.UserID != userID.(uint) {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to access this deck's stats"})
+		allowed, err := canViewDeck(h.db, deck, userID.(uint))
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to check deck access", err))
+			return
+		}
+		if !allowed {
+			c.Error(apperrors.Forbidden("You don't have permission to access this deck's stats"))
 			return
 		}
 
@@ -314,21 +1166,36 @@ func (h *StudyHandler) GetStudyStats(c *gin.Context) {
 			Where("flash_cards.deck_id = ?", req.DeckID)
 	}
 
+	settings, err := loadUserSettings(h.db, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve settings", err))
+		return
+	}
+
+	// Each count below chains its own conditions, so they must start from a fresh session of the base query
+	query = query.Session(&gorm.Session{})
+
 	// Get counts by status
 	var newCount, learningCount, reviewCount int64
 
 	if err := query.Where("status = ?", "new").Count(&newCount).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve stats"})
+		c.Error(apperrors.Internal("Failed to retrieve stats", err))
 		return
 	}
 
 	if err := query.Where("status = ?", "learning").Count(&learningCount).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve stats"})
+		c.Error(apperrors.Internal("Failed to retrieve stats", err))
 		return
 	}
 
 	if err := query.Where("status = ?", "review").Count(&reviewCount).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve stats"})
+		c.Error(apperrors.Internal("Failed to retrieve stats", err))
+		return
+	}
+
+	var masteredCount int64
+	if err := query.Scopes(stats.Mastered(settings.MasteryIntervalDays)).Count(&masteredCount).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve stats", err))
 		return
 	}
 
@@ -337,13 +1204,13 @@ func (h *StudyHandler) GetStudyStats(c *gin.Context) {
 
 	// Fix: Using Select() instead of Sum() for aggregation
 	if err := query.Select("COALESCE(SUM(review_count), 0)").Scan(&totalReviewed).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve stats"})
+		c.Error(apperrors.Internal("Failed to retrieve stats", err))
 		return
 	}
 
 	// Fix: Using Select() instead of Sum() for aggregation
 	if err := query.Select("COALESCE(SUM(correct_count), 0)").Scan(&totalCorrect).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve stats"})
+		c.Error(apperrors.Internal("Failed to retrieve stats", err))
 		return
 	}
 
@@ -353,18 +1220,54 @@ func (h *StudyHandler) GetStudyStats(c *gin.Context) {
 		accuracyPercentage = float64(totalCorrect) / float64(totalReviewed) * 100
 	}
 
-	// Get cards due today
-	now := time.Now()
-	endOfDay := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 999999999, now.Location())
+	// True retention only looks at mature cards recalled when due, so it's usually lower than accuracy
+	retention, deckRetention, err := stats.TrueRetention(h.db, userID.(uint), req.DeckID)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve stats", err))
+		return
+	}
+
+	// Progress toward the deck's study goal, when looking at one deck that has a goal,
+	// and how much of that deck is mastered
+	var goal gin.H
+	var masteryPercent *float64
+	if req.DeckID > 0 {
+		if goal, err = h.deckGoalProgress(userID.(uint), req.DeckID); err != nil {
+			c.Error(apperrors.Internal("Failed to retrieve study goal", err))
+			return
+		}
+
+		deckMastery, err := stats.DeckMastery(h.db, userID.(uint), req.DeckID, settings.MasteryIntervalDays)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to compute deck mastery", err))
+			return
+		}
+		masteryPercent = &deckMastery.Percent
+	}
 
-	var dueToday int64
-	if err := query.Where("next_review_date <= ?", endOfDay).Count(&dueToday).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve stats"})
+	// Total time reported on reviews, for the deck when filtering by one
+	timeQuery := h.db.Model(&models.ReviewLog{}).Where("review_logs.user_id = ?", userID)
+	if req.DeckID > 0 {
+		timeQuery = timeQuery.Joins("JOIN flash_cards ON flash_cards.id = review_logs.card_id").
+			Where("flash_cards.deck_id = ?", req.DeckID)
+	}
+	var totalTimeStudied int64
+	if err := timeQuery.Select("COALESCE(SUM(review_logs.time_spent), 0)").Scan(&totalTimeStudied).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve stats", err))
 		return
 	}
 
+	// Cards due today ("today" being the user's local day) come precomputed by the due count worker
+	dueToday, err := stats.DueToday(h.db, userID.(uint), req.DeckID)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve stats", err))
+		return
+	}
+
+	now := time.Now().In(settings.Location())
+
 	// Get recent activity (reviews per day for the last week)
-	startOfWeek := time.Date(now.Year(), now.Month(), now.Day()-7, 0, 0, 0, 0, now.Location())
+	startOfWeek := time.Date(now.Year(), now.Month(), now.Day()-7, 0, 0, 0, 0, now.Location()).UTC()
 
 	type DailyActivity struct {
 		Date    string `json:"date"`
@@ -375,9 +1278,12 @@ func (h *StudyHandler) GetStudyStats(c *gin.Context) {
 
 	// For simplicity, we're using SQLite's date() function
 	// In a production app, you might need to adjust this for your specific database
+	// The offset modifier shifts UTC timestamps into the user's timezone before bucketing
+	_, offset := now.Zone()
+	tzModifier := fmt.Sprintf("%+d seconds", offset)
 	rows, err := h.db.Raw(`
 		SELECT 
-			date(last_reviewed_at) as review_date, 
+			date(last_reviewed_at, ?) as review_date, 
 			COUNT(*) as reviews
 		FROM 
 			card_progresses
@@ -385,13 +1291,13 @@ func (h *StudyHandler) GetStudyStats(c *gin.Context) {
 			user_id = ? 
 			AND last_reviewed_at >= ?
 		GROUP BY 
-			date(last_reviewed_at)
+			review_date
 		ORDER BY 
 			review_date ASC
-	`, userID, startOfWeek).Rows()
+	`, tzModifier, userID, startOfWeek).Rows()
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve activity stats"})
+		c.Error(apperrors.Internal("Failed to retrieve activity stats", err))
 		return
 	}
 	defer rows.Close()
@@ -400,7 +1306,7 @@ func (h *StudyHandler) GetStudyStats(c *gin.Context) {
 		var date string
 		var reviews int64
 		if err := rows.Scan(&date, &reviews); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse activity stats"})
+			c.Error(apperrors.Internal("Failed to parse activity stats", err))
 			return
 		}
 		dailyActivity = append(dailyActivity, DailyActivity{Date: date, Reviews: reviews})
@@ -411,10 +1317,18 @@ func (h *StudyHandler) GetStudyStats(c *gin.Context) {
 			"new_count":      newCount,
 			"learning_count": learningCount,
 			"review_count":   reviewCount,
+			"mastered_count": masteredCount, // Review cards meeting the mastery rules, see stats.Mastered
 			"due_today":      dueToday,
 			"total_reviewed": totalReviewed,
-			"accuracy":       accuracyPercentage,
-			"daily_activity": dailyActivity,
+			"accuracy":       accuracyPercentage, // Lifetime share of correct answers, every review counted
+			"true_retention": gin.H{
+				"overall": retention,
+				"decks":   deckRetention,
+			},
+			"daily_activity":     dailyActivity,
+			"total_time_studied": totalTimeStudied, // Seconds, summed over reviews that reported their time
+			"goal":               goal,
+			"mastery_percent":    masteryPercent, // Only when filtering by deck, see stats.DeckMastery for the formula
 		},
 	})
 }
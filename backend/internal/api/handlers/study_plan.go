@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/models"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPlanDays -> Furthest ahead a study plan may target, which also bounds the simulation
+const maxPlanDays = 365
+
+// maxReviewsPerCardPerDay -> Guard against a card cycling through learning steps forever within one simulated day
+const maxReviewsPerCardPerDay = 20
+
+// GetStudyPlanRequest -> Query parameters for planning a deck's daily load up to a target date
+type GetStudyPlanRequest struct {
+	DeckID     uint   `form:"deck_id" binding:"required"`
+	TargetDate string `form:"target_date" binding:"required,datetime=2006-01-02"` // The day every card should have graduated by
+}
+
+// reviewThroughDay -> Reviews the card as "good" every time it comes due before end, starting no
+// earlier than from. Returns how many reviews that took
+func reviewThroughDay(progress *models.CardProgress, from, end time.Time, steps []int) int {
+	reviews := 0
+	for reviews < maxReviewsPerCardPerDay && progress.NextReviewDate.Before(end) {
+		at := progress.NextReviewDate
+		if at.Before(from) {
+			at = from
+		}
+		applyReview(progress, gradePerformance[GradeGood], GradeGood, at, steps)
+		reviews++
+	}
+	return reviews
+}
+
+// daysToGraduate -> Days a new card takes to reach review status when every answer is good
+func daysToGraduate(now time.Time, steps []int) int {
+	progress := newCardProgress(0, 0)
+	progress.NextReviewDate = now
+	for day := 0; day < maxPlanDays; day++ {
+		reviewThroughDay(&progress, now.AddDate(0, 0, day), now.AddDate(0, 0, day+1), steps)
+		if progress.Status == "review" {
+			return day
+		}
+	}
+	return maxPlanDays
+}
+
+// GetStudyPlan -> Handler to suggest how many new cards and reviews a day get every card of a deck
+// graduated by the target date. New cards are spread so the last of them still has time to
+// graduate, then the schedule is simulated day by day with the real scheduler, assuming every
+// review is answered "good". Real lapses push the load up, so the numbers are a floor
+func (h *StudyHandler) GetStudyPlan(c *gin.Context) {
+	var req GetStudyPlanRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var deck models.Deck
+	if err := h.db.First(&deck, req.DeckID).Error; err != nil {
+		c.Error(apperrors.NotFound("Deck not found"))
+		return
+	}
+	allowed, err := canViewDeck(h.db, deck, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to study this deck"))
+		return
+	}
+
+	settings, err := loadUserSettings(h.db, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve settings", err))
+		return
+	}
+
+	// Days left including today and the target day, in the user's timezone
+	now := time.Now()
+	today := startOfDay(now, settings.Location())
+	target, _ := time.ParseInLocation("2006-01-02", req.TargetDate, settings.Location())
+	days := int(math.Round(target.Sub(today).Hours()/24)) + 1
+	if days < 1 {
+		c.Error(apperrors.BadRequest("Target date must be today or later"))
+		return
+	}
+	if days > maxPlanDays {
+		c.Error(apperrors.BadRequest("Target date can be at most a year away"))
+		return
+	}
+
+	var progresses []models.CardProgress
+	if err := h.db.Joins("JOIN flash_cards ON flash_cards.id = card_progresses.card_id AND flash_cards.deleted_at IS NULL").
+		Where("card_progresses.user_id = ? AND flash_cards.deck_id = ? AND card_progresses.suspended = ?", userID, deck.ID, false).
+		Find(&progresses).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve card progress", err))
+		return
+	}
+
+	var totalCards int64
+	if err := h.db.Model(&models.FlashCard{}).Where("deck_id = ?", deck.ID).Count(&totalCards).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to count cards", err))
+		return
+	}
+
+	var suspended int64
+	if err := h.db.Model(&models.CardProgress{}).
+		Joins("JOIN flash_cards ON flash_cards.id = card_progresses.card_id AND flash_cards.deleted_at IS NULL").
+		Where("card_progresses.user_id = ? AND flash_cards.deck_id = ? AND card_progresses.suspended = ?", userID, deck.ID, true).
+		Count(&suspended).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve card progress", err))
+		return
+	}
+	unstudied := int(totalCards) - len(progresses) - int(suspended)
+
+	dueNow := 0
+	for _, progress := range progresses {
+		if !progress.NextReviewDate.After(now) {
+			dueNow++
+		}
+	}
+
+	// New cards must be introduced early enough to graduate by the target, when that's possible at all
+	graduation := daysToGraduate(now, deck.LearningSteps)
+	introductionDays := days - graduation
+	onTrack := introductionDays >= 1
+	if !onTrack {
+		introductionDays = days
+	}
+	newPerDay := 0
+	if unstudied > 0 {
+		newPerDay = int(math.Ceil(float64(unstudied) / float64(introductionDays)))
+	}
+
+	// Simulate the schedule, counting the reviews of cards already seen on each day
+	reviewsByDay := make([]int, days)
+	remaining := unstudied
+	for day := 0; day < days; day++ {
+		from := today.AddDate(0, 0, day)
+		if day == 0 {
+			from = now
+		}
+		end := today.AddDate(0, 0, day+1)
+
+		for i := range progresses {
+			reviewsByDay[day] += reviewThroughDay(&progresses[i], from, end, deck.LearningSteps)
+		}
+
+		// Today's new cards get their first look now, then join the cards being reviewed
+		introduced := min(newPerDay, remaining)
+		remaining -= introduced
+		for range introduced {
+			progress := newCardProgress(userID.(uint), 0)
+			progress.NextReviewDate = from
+			applyReview(&progress, gradePerformance[GradeGood], GradeGood, from, deck.LearningSteps)
+			reviewsByDay[day] += reviewThroughDay(&progress, from, end, deck.LearningSteps)
+			progresses = append(progresses, progress)
+		}
+	}
+
+	totalReviews, peak := 0, 0
+	for _, reviews := range reviewsByDay {
+		totalReviews += reviews
+		peak = max(peak, reviews)
+	}
+	reviewsPerDay := int(math.Ceil(float64(totalReviews) / float64(days)))
+
+	plan := gin.H{
+		"deck_id":               deck.ID,
+		"target_date":           req.TargetDate,
+		"days_remaining":        days,
+		"total_cards":           totalCards,
+		"unstudied_cards":       unstudied,
+		"due_now":               dueNow,
+		"new_per_day":           newPerDay,
+		"reviews_per_day":       reviewsPerDay, // Average over the plan, new cards' first look not included
+		"peak_reviews_per_day":  peak,
+		"days_to_graduate":      graduation, // How long a new card takes to reach review status
+		"on_track":              onTrack,    // False when there's too little time left for new cards to graduate
+		"within_daily_limits":   newPerDay <= settings.NewCardsPerDay && peak <= settings.MaxReviewsPerDay,
+		"introduce_new_through": today.AddDate(0, 0, introductionDays-1).Format("2006-01-02"),
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"plan": plan,
+	})
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/config"
+	"FlashQuiz/internal/models"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	studySocketWriteWait  = 10 * time.Second // Time allowed to write a message
+	studySocketPongWait   = 60 * time.Second // Time allowed between pongs before the client is considered gone
+	studySocketPingPeriod = 50 * time.Second // Must be shorter than the pong wait
+)
+
+// studySocketUpgrader -> Accepts WebSocket handshakes from the same origins CORS allows.
+// Requests without an Origin header come from non-browser clients and are let through
+var studySocketUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin: func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		allowed := config.AllowedOrigins()
+		return slices.Contains(allowed, origin) || slices.Contains(allowed, "*")
+	},
+}
+
+// StudySocketMessage -> A message sent by the client over the study socket
+type StudySocketMessage struct {
+	Type        string `json:"type"` // Only "grade" for now
+	CardID      uint   `json:"card_id"`
+	Performance *int   `json:"performance"` // 0-5, same scale as update-progress
+	Grade       string `json:"grade"`       // Or again/hard/good/easy instead of performance
+	TimeSpent   int    `json:"time_spent"`  // Seconds spent on the card, optional
+}
+
+// studySession -> One live study connection, bound to a user and a deck
+type studySession struct {
+	h      *StudyHandler
+	conn   *websocket.Conn
+	userID uint
+	deckID uint
+}
+
+// StudySocket -> WebSocket endpoint for live study sessions. The server pushes the queue stats and
+// the next card, the client answers with grade messages, and every grade is scheduled exactly like
+// update-progress before the next card is pushed. Browsers can't set headers on the handshake, so
+// the token may be passed as the "token" query param instead
+func (h *StudyHandler) StudySocket(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	deckID, err := strconv.ParseUint(c.Query("deck_id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("deck_id query parameter is required"))
+		return
+	}
+
+	// Access is checked before upgrading so failures are still plain HTTP errors
+	var deck models.Deck
+	if err := h.db.First(&deck, deckID).Error; err != nil {
+		c.Error(apperrors.NotFound("Deck not found"))
+		return
+	}
+
+	allowed, err := canViewDeck(h.db, deck, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to study this deck"))
+		return
+	}
+
+	// The upgrader writes its own error response when the handshake fails
+	conn, err := studySocketUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("Study socket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	session := &studySession{h: h, conn: conn, userID: userID.(uint), deckID: deck.ID}
+	session.run()
+}
+
+// run -> Serves the connection until the client disconnects or a write fails
+func (s *studySession) run() {
+	s.conn.SetReadLimit(4096)
+	s.conn.SetReadDeadline(time.Now().Add(studySocketPongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(studySocketPongWait))
+	})
+
+	// Pings go out from their own goroutine, WriteControl is safe to call alongside other writes
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(studySocketPingPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(studySocketWriteWait)); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	if err := s.pushQueue(); err != nil {
+		return
+	}
+
+	for {
+		var msg StudySocketMessage
+		if err := s.conn.ReadJSON(&msg); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("Study socket for user %d closed unexpectedly: %v", s.userID, err)
+			}
+			return
+		}
+
+		if err := s.handle(msg); err != nil {
+			return
+		}
+	}
+}
+
+// handle -> Processes one client message. Only write failures are returned, anything the
+// client got wrong is reported back as an error message and the session carries on
+func (s *studySession) handle(msg StudySocketMessage) error {
+	if msg.Type != "grade" {
+		return s.send(gin.H{"type": "error", "message": "Unknown message type"})
+	}
+	performance, err := resolvePerformance(msg.Performance, msg.Grade)
+	if err != nil {
+		return s.send(gin.H{"type": "error", "message": err.Error()})
+	}
+
+	if msg.TimeSpent < 0 || msg.TimeSpent > maxTimeSpentSeconds {
+		return s.send(gin.H{"type": "error", "message": fmt.Sprintf("time_spent must be between 0 and %d seconds", maxTimeSpentSeconds)})
+	}
+
+	var card models.FlashCard
+	if err := s.h.db.First(&card, msg.CardID).Error; err != nil || card.DeckID != s.deckID {
+		return s.send(gin.H{"type": "error", "message": "Card not found in this deck"})
+	}
+
+	progress, err := recordReview(s.h.db, s.userID, card.ID, performance, msg.Grade, msg.TimeSpent)
+	if err != nil {
+		log.Printf("Study socket failed to record review for user %d: %v", s.userID, err)
+		return s.send(gin.H{"type": "error", "message": "Failed to update card progress"})
+	}
+
+	if err := s.send(gin.H{
+		"type":             "graded",
+		"card_id":          card.ID,
+		"progress":         progress,
+		"next_review_date": progress.NextReviewDate.Format(time.RFC3339),
+		"interval_days":    progress.Interval,
+	}); err != nil {
+		return err
+	}
+
+	return s.pushQueue()
+}
+
+// pushQueue -> Sends the current queue stats followed by the next card, or "done" when nothing
+// is left for today. Due cards come first, then new ones, within the user's daily caps
+func (s *studySession) pushQueue() error {
+	now := time.Now()
+	queue, err := loadStudyQueue(s.h.db, s.userID, s.deckID, now)
+	if err != nil {
+		log.Printf("Study socket failed to load queue for user %d: %v", s.userID, err)
+		return s.send(gin.H{"type": "error", "message": "Failed to retrieve flashcards"})
+	}
+
+	settings, err := loadUserSettings(s.h.db, s.userID)
+	if err != nil {
+		log.Printf("Study socket failed to load settings for user %d: %v", s.userID, err)
+		return s.send(gin.H{"type": "error", "message": "Failed to retrieve settings"})
+	}
+
+	newLeft, reviewsLeft, err := dailyAllowance(s.h.db, settings, now)
+	if err != nil {
+		log.Printf("Study socket failed to load allowance for user %d: %v", s.userID, err)
+		return s.send(gin.H{"type": "error", "message": "Failed to retrieve card progress"})
+	}
+
+	if err := s.send(gin.H{
+		"type":           "stats",
+		"due_count":      len(queue.dueCards),
+		"new_count":      len(queue.newCards),
+		"learning_count": len(queue.learningCards),
+		"new_left":       newLeft,
+		"reviews_left":   reviewsLeft,
+	}); err != nil {
+		return err
+	}
+
+	if reviewsLeft > 0 && len(queue.dueCards) > 0 {
+		card := queue.dueCards[0]
+		return s.send(gin.H{"type": "card", "card": card, "progress": queue.progress[card.ID], "note": queue.note(card.ID), "status": "due"})
+	}
+	if newLeft > 0 && len(queue.newCards) > 0 {
+		card := queue.newCards[0]
+		return s.send(gin.H{"type": "card", "card": card, "progress": nil, "note": queue.note(card.ID), "status": "new"})
+	}
+	return s.send(gin.H{"type": "done", "message": "Nothing left to study in this deck today"})
+}
+
+// send -> Writes one JSON message to the client
+func (s *studySession) send(msg gin.H) error {
+	s.conn.SetWriteDeadline(time.Now().Add(studySocketWriteWait))
+	return s.conn.WriteJSON(msg)
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/models"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRescheduleDay(t *testing.T) {
+	tests := []struct {
+		name         string
+		rank, count  int
+		days, wantAt int
+	}{
+		{"most overdue comes back today", 0, 3, 7, 0},
+		{"least overdue comes back last", 2, 3, 7, 4},
+		{"middle lands in the middle", 3, 7, 7, 3},
+		{"more days than cards", 1, 2, 30, 15},
+		{"last of many lands on the last day", 999, 1000, 7, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rescheduleDay(tt.rank, tt.count, tt.days)
+			if got != tt.wantAt {
+				t.Errorf("rescheduleDay(%d, %d, %d) = %d, want %d", tt.rank, tt.count, tt.days, got, tt.wantAt)
+			}
+		})
+	}
+}
+
+func TestRescheduleOverdueSkewed(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice")
+
+	// One card 300 days overdue, the other 99 between 1 and 5 days
+	now := time.Now()
+	progress := []models.CardProgress{{UserID: user.ID, CardID: 1, NextReviewDate: now.AddDate(0, 0, -300)}}
+	for i := range 99 {
+		progress = append(progress, models.CardProgress{UserID: user.ID, CardID: uint(i + 2), NextReviewDate: now.AddDate(0, 0, -1-i%5)})
+	}
+	if err := db.Create(&progress).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestRouter(user.ID)
+	r.POST("/study/reschedule", NewStudyHandler(db).RescheduleOverdue)
+
+	code, out := doJSON(t, r, http.MethodPost, "/study/reschedule", map[string]any{"days": 5})
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %v", code, http.StatusOK, out)
+	}
+	for day, cards := range out["per_day"].([]any) {
+		if cards != float64(20) {
+			t.Errorf("day %d gets %v cards, want 20: %v", day, cards, out["per_day"])
+		}
+	}
+
+	var first models.CardProgress
+	if err := db.Where("card_id = ?", 1).First(&first).Error; err != nil {
+		t.Fatal(err)
+	}
+	if first.NextReviewDate.After(now.Add(time.Minute)) {
+		t.Errorf("most overdue card moved to %v, want today", first.NextReviewDate)
+	}
+}
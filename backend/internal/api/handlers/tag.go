@@ -0,0 +1,530 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/models"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxTagLength -> Longest tag name accepted, in characters
+const maxTagLength = 50
+
+type TagHandler struct {
+	db *gorm.DB
+}
+
+func NewTagHandler(db *gorm.DB) *TagHandler {
+	return &TagHandler{db: db}
+}
+
+// cleanTag -> Trims and collapses whitespace in a tag name, rejecting blank and overlong names
+func cleanTag(raw string) (string, error) {
+	name := strings.Join(strings.Fields(raw), " ")
+	if name == "" {
+		return "", apperrors.BadRequest("Tag name can't be blank")
+	}
+	if len([]rune(name)) > maxTagLength {
+		return "", apperrors.BadRequest("Tag name can't be longer than 50 characters")
+	}
+	return name, nil
+}
+
+// findTag -> The user's tag with the given name regardless of case, gorm.ErrRecordNotFound if there's none
+func findTag(db *gorm.DB, userID uint, name string) (models.Tag, error) {
+	var tag models.Tag
+	err := db.Where("user_id = ? AND LOWER(name) = LOWER(?)", userID, name).First(&tag).Error
+	return tag, err
+}
+
+// findOrCreateTag -> The user's tag with the given name, created on first use. An existing tag keeps
+// its spelling so "Verbs" and "verbs" don't fragment
+func findOrCreateTag(db *gorm.DB, userID uint, name string) (models.Tag, error) {
+	tag, err := findTag(db, userID, name)
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return tag, err
+	}
+
+	tag = models.Tag{UserID: userID, Name: name}
+	err = db.Create(&tag).Error
+	return tag, err
+}
+
+// cardTagsForUser -> The user's tags on a card, ordered by name
+func cardTagsForUser(db *gorm.DB, userID, cardID uint) ([]models.Tag, error) {
+	tags := make([]models.Tag, 0)
+	err := db.Joins("JOIN card_tags ON card_tags.tag_id = tags.id").
+		Where("tags.user_id = ? AND card_tags.card_id = ?", userID, cardID).
+		Order("tags.name").
+		Find(&tags).Error
+	return tags, err
+}
+
+// loadTag -> Loads the caller's tag in the :id param, writing the error and returning false if it can't
+func (h *TagHandler) loadTag(c *gin.Context, userID uint) (models.Tag, bool) {
+	var tag models.Tag
+	tagID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid tag ID"))
+		return tag, false
+	}
+
+	if err := h.db.Where("id = ? AND user_id = ?", tagID, userID).First(&tag).Error; err != nil {
+		c.Error(apperrors.NotFound("Tag not found"))
+		return tag, false
+	}
+
+	return tag, true
+}
+
+// GetTags -> Handler to list the user's tags with the number of cards each is on
+func (h *TagHandler) GetTags(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	type tagCount struct {
+		ID        uint   `json:"id"`
+		Name      string `json:"name"`
+		CardCount int64  `json:"card_count"`
+	}
+
+	// Cards in the trash don't count towards usage
+	tags := make([]tagCount, 0)
+	if err := h.db.Model(&models.Tag{}).
+		Select("tags.id, tags.name, COUNT(flash_cards.id) AS card_count").
+		Joins("LEFT JOIN card_tags ON card_tags.tag_id = tags.id").
+		Joins("LEFT JOIN flash_cards ON flash_cards.id = card_tags.card_id AND flash_cards.deleted_at IS NULL").
+		Where("tags.user_id = ?", userID).
+		Group("tags.id, tags.name").
+		Order("LOWER(tags.name) ASC").
+		Scan(&tags).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve tags", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tags": tags,
+	})
+}
+
+// RenameTagRequest -> Struct for renaming a tag
+type RenameTagRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// RenameTag -> Handler to rename a tag on every card it's on. Renaming onto another existing tag
+// merges the two, keeping the other tag
+func (h *TagHandler) RenameTag(c *gin.Context) {
+	var req RenameTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	name, err := cleanTag(req.Name)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	tag, ok := h.loadTag(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	target, err := findTag(h.db, tag.UserID, name)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.Error(apperrors.Internal("Failed to rename tag", err))
+		return
+	}
+
+	// No other tag has the name (a case-only rename finds the tag itself), rename in place
+	if err != nil || target.ID == tag.ID {
+		if err := h.db.Model(&tag).Update("name", name).Error; err != nil {
+			c.Error(apperrors.Internal("Failed to rename tag", err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Tag renamed successfully",
+			"tag":     tag,
+			"merged":  false,
+		})
+		return
+	}
+
+	// Move the cards over to the existing tag, skipping those that already have it
+	alreadyTagged := h.db.Model(&models.CardTag{}).Select("card_id").Where("tag_id = ?", target.ID)
+	tx := h.db.Begin()
+
+	steps := []func() error{
+		func() error {
+			return tx.Where("tag_id = ? AND card_id IN (?)", tag.ID, alreadyTagged).Delete(&models.CardTag{}).Error
+		},
+		func() error {
+			return tx.Model(&models.CardTag{}).Where("tag_id = ?", tag.ID).Update("tag_id", target.ID).Error
+		},
+		func() error { return tx.Unscoped().Delete(&tag).Error },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			tx.Rollback()
+			c.Error(apperrors.Internal("Failed to merge tags", err))
+			return
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Error(apperrors.Internal("Failed to merge tags", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tag merged into an existing tag",
+		"tag":     target,
+		"merged":  true,
+	})
+}
+
+// DeleteTag -> Handler to delete a tag, removing it from every card it's on
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	tag, ok := h.loadTag(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	var untagged int64
+	err := withRetry(h.db, func(tx *gorm.DB) error {
+		result := tx.Where("tag_id = ?", tag.ID).Delete(&models.CardTag{})
+		if result.Error != nil {
+			return result.Error
+		}
+		untagged = result.RowsAffected
+
+		// Hard delete so the name can be used again
+		return tx.Unscoped().Delete(&tag).Error
+	})
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to delete tag", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Tag deleted successfully",
+		"cards_untagged": untagged,
+	})
+}
+
+// loadEditableCard -> Loads the card in the :id param, writing the error and returning false unless
+// the caller may edit it
+func (h *CardHandler) loadEditableCard(c *gin.Context, userID uint) (models.FlashCard, bool) {
+	var card models.FlashCard
+	cardID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid card ID"))
+		return card, false
+	}
+
+	if err := h.db.Preload("Deck").First(&card, cardID).Error; err != nil {
+		c.Error(apperrors.NotFound("Flashcard not found"))
+		return card, false
+	}
+
+	allowed, err := canEditDeck(h.db, card.Deck, userID)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to check deck access", err))
+		return card, false
+	}
+	if !allowed {
+		c.Error(apperrors.Forbidden("You don't have permission to tag this flashcard"))
+		return card, false
+	}
+
+	return card, true
+}
+
+// TagCardRequest -> Struct for tagging a single card
+type TagCardRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// TagCard -> Handler to attach one of the caller's tags to a card, creating the tag if needed
+func (h *CardHandler) TagCard(c *gin.Context) {
+	var req TagCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	name, err := cleanTag(req.Name)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	card, ok := h.loadEditableCard(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	err = withRetry(h.db, func(tx *gorm.DB) error {
+		tag, err := findOrCreateTag(tx, userID.(uint), name)
+		if err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.CardTag{CardID: card.ID, TagID: tag.ID}).Error
+	})
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to tag flashcard", err))
+		return
+	}
+
+	tags, err := cardTagsForUser(h.db, userID.(uint), card.ID)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve tags", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"card_id": card.ID,
+		"tags":    tags,
+	})
+}
+
+// UntagCard -> Handler to remove one of the caller's tags from a card
+func (h *CardHandler) UntagCard(c *gin.Context) {
+	tagID, err := strconv.ParseUint(c.Param("tag_id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid tag ID"))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	card, ok := h.loadEditableCard(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	ownTags := h.db.Model(&models.Tag{}).Select("id").Where("user_id = ?", userID)
+	result := h.db.Where("card_id = ? AND tag_id = ? AND tag_id IN (?)", card.ID, tagID, ownTags).Delete(&models.CardTag{})
+	if result.Error != nil {
+		c.Error(apperrors.Internal("Failed to untag flashcard", result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(apperrors.NotFound("Flashcard doesn't have this tag"))
+		return
+	}
+
+	tags, err := cardTagsForUser(h.db, userID.(uint), card.ID)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve tags", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"card_id": card.ID,
+		"tags":    tags,
+	})
+}
+
+// BulkTagRequest -> Struct for tagging or untagging many cards at once
+type BulkTagRequest struct {
+	Name    string `json:"name" binding:"required"`
+	CardIDs []uint `json:"card_ids" binding:"omitempty,max=1000"`
+	DeckID  uint   `json:"deck_id"` // Every card in the deck, card_ids is ignored
+}
+
+// BulkTagCards -> Handler to attach a tag to many cards in one go, creating the tag if needed
+func (h *CardHandler) BulkTagCards(c *gin.Context) {
+	h.bulkTag(c, true)
+}
+
+// BulkUntagCards -> Handler to remove a tag from many cards in one go
+func (h *CardHandler) BulkUntagCards(c *gin.Context) {
+	h.bulkTag(c, false)
+}
+
+// editableCardIDs -> The IDs of the requested cards, or all of the deck's cards, that the user may
+// edit. Reports false after writing the error if the deck can't be used
+func (h *CardHandler) editableCardIDs(c *gin.Context, userID uint, req BulkTagRequest) ([]uint, bool) {
+	if req.DeckID > 0 {
+		var deck models.Deck
+		if err := h.db.First(&deck, req.DeckID).Error; err != nil {
+			c.Error(apperrors.NotFound("Deck not found"))
+			return nil, false
+		}
+		allowed, err := canEditDeck(h.db, deck, userID)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to check deck access", err))
+			return nil, false
+		}
+		if !allowed {
+			c.Error(apperrors.Forbidden("You don't have permission to tag this deck's cards"))
+			return nil, false
+		}
+
+		var cardIDs []uint
+		if err := h.db.Model(&models.FlashCard{}).Where("deck_id = ?", deck.ID).Pluck("id", &cardIDs).Error; err != nil {
+			c.Error(apperrors.Internal("Failed to retrieve flashcards", err))
+			return nil, false
+		}
+		return cardIDs, true
+	}
+
+	var cards []models.FlashCard
+	if err := h.db.Preload("Deck").Where("id IN ?", req.CardIDs).Find(&cards).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve flashcards", err))
+		return nil, false
+	}
+
+	// Access is checked once per deck, cards in decks the user can't edit are left out
+	editable := make(map[uint]bool)
+	cardIDs := make([]uint, 0, len(cards))
+	for _, card := range cards {
+		allowed, checked := editable[card.DeckID]
+		if !checked {
+			var err error
+			if allowed, err = canEditDeck(h.db, card.Deck, userID); err != nil {
+				c.Error(apperrors.Internal("Failed to check deck access", err))
+				return nil, false
+			}
+			editable[card.DeckID] = allowed
+		}
+		if allowed {
+			cardIDs = append(cardIDs, card.ID)
+		}
+	}
+	return cardIDs, true
+}
+
+// bulkTag -> Tags (add) or untags the cards in the request in one transaction
+func (h *CardHandler) bulkTag(c *gin.Context, add bool) {
+	var req BulkTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	if req.DeckID == 0 && len(req.CardIDs) == 0 {
+		c.Error(apperrors.BadRequest("Either card_ids or deck_id is required"))
+		return
+	}
+	slices.Sort(req.CardIDs)
+	req.CardIDs = slices.Compact(req.CardIDs)
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	name, err := cleanTag(req.Name)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	cardIDs, ok := h.editableCardIDs(c, userID.(uint), req)
+	if !ok {
+		return
+	}
+
+	var tag models.Tag
+	var affected int64
+	err = withRetry(h.db, func(tx *gorm.DB) error {
+		affected = 0
+
+		if !add {
+			var err error
+			if tag, err = findTag(tx, userID.(uint), name); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return apperrors.NotFound("Tag not found")
+				}
+				return apperrors.Internal("Failed to untag flashcards", err)
+			}
+			if len(cardIDs) == 0 {
+				return nil
+			}
+
+			result := tx.Where("tag_id = ? AND card_id IN ?", tag.ID, cardIDs).Delete(&models.CardTag{})
+			if result.Error != nil {
+				return apperrors.Internal("Failed to untag flashcards", result.Error)
+			}
+			affected = result.RowsAffected
+			return nil
+		}
+
+		var err error
+		if tag, err = findOrCreateTag(tx, userID.(uint), name); err != nil {
+			return apperrors.Internal("Failed to tag flashcards", err)
+		}
+		if len(cardIDs) == 0 {
+			return nil
+		}
+
+		rows := make([]models.CardTag, 0, len(cardIDs))
+		for _, cardID := range cardIDs {
+			rows = append(rows, models.CardTag{CardID: cardID, TagID: tag.ID})
+		}
+		// Cards that already have the tag are skipped and don't count as affected
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&rows, 500)
+		if result.Error != nil {
+			return apperrors.Internal("Failed to tag flashcards", result.Error)
+		}
+		affected = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		c.Error(txError(err, "Failed to update tags"))
+		return
+	}
+
+	message := "Cards tagged successfully"
+	if !add {
+		message = "Cards untagged successfully"
+	}
+	response := gin.H{
+		"message":  message,
+		"tag":      tag,
+		"affected": affected,
+	}
+	// Requested cards that don't exist or belong to decks the user can't edit are left alone
+	if req.DeckID == 0 {
+		response["skipped"] = len(req.CardIDs) - len(cardIDs)
+	}
+	c.JSON(http.StatusOK, response)
+}
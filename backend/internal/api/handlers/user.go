@@ -0,0 +1,410 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/models"
+	"FlashQuiz/internal/password"
+	"FlashQuiz/internal/stats"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type UserHandler struct {
+	db *gorm.DB
+}
+
+func NewUserHandler(db *gorm.DB) *UserHandler {
+	return &UserHandler{db: db}
+}
+
+// exportBatchSize -> Number of rows loaded per query while streaming an export
+const exportBatchSize = 200
+
+// streamJSONArray -> Writes the rows matched by query as a JSON array, loading them in batches
+// so large accounts never have to be held in memory all at once
+func streamJSONArray[T any](w io.Writer, query *gorm.DB) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	first := true
+	var batch []T
+	err := query.FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+		for _, item := range batch {
+			if !first {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			first = false
+
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "]")
+	return err
+}
+
+// ExportUserData -> Handler to download everything stored about the calling user
+func (h *UserHandler) ExportUserData(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, userID).Error; err != nil {
+		c.Error(apperrors.NotFound("User not found"))
+		return
+	}
+
+	// Subqueries for rows owned indirectly through decks and quizzes
+	deckIDs := h.db.Model(&models.Deck{}).Select("id").Where("user_id = ?", userID)
+	quizIDs := h.db.Model(&models.Quiz{}).Select("id").Where("user_id = ?", userID)
+	tagIDs := h.db.Model(&models.Tag{}).Select("id").Where("user_id = ?", userID)
+
+	// Password hash is never exported
+	profile, err := json.Marshal(gin.H{
+		"id":         user.ID,
+		"username":   user.Username,
+		"email":      user.Email,
+		"created_at": user.CreatedAt,
+		"updated_at": user.UpdatedAt,
+	})
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to build export", err))
+		return
+	}
+	generatedAt, _ := json.Marshal(time.Now())
+
+	c.Header("Content-Type", "application/json")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"user-%d-export.json\"", user.ID))
+	c.Status(http.StatusOK)
+
+	// The response is streamed section by section, so once writing has started
+	// errors can only be logged and the stream cut short
+	w := c.Writer
+	sections := []struct {
+		name  string
+		write func() error
+	}{
+		{"decks", func() error {
+			return streamJSONArray[models.Deck](w, h.db.Where("user_id = ?", userID))
+		}},
+		{"flash_cards", func() error {
+			return streamJSONArray[models.FlashCard](w, h.db.Where("deck_id IN (?)", deckIDs))
+		}},
+		{"tags", func() error {
+			return streamJSONArray[models.Tag](w, h.db.Where("user_id = ?", userID))
+		}},
+		{"card_tags", func() error {
+			return streamJSONArray[models.CardTag](w, h.db.Where("tag_id IN (?)", tagIDs))
+		}},
+		{"card_notes", func() error {
+			return streamJSONArray[models.CardNote](w, h.db.Where("user_id = ?", userID))
+		}},
+		{"card_progresses", func() error {
+			return streamJSONArray[models.CardProgress](w, h.db.Where("user_id = ?", userID))
+		}},
+		{"review_logs", func() error {
+			return streamJSONArray[models.ReviewLog](w, h.db.Where("user_id = ?", userID))
+		}},
+		{"study_sessions", func() error {
+			return streamJSONArray[models.StudySession](w, h.db.Where("user_id = ?", userID))
+		}},
+		{"quizzes", func() error {
+			return streamJSONArray[models.Quiz](w, h.db.Where("user_id = ?", userID))
+		}},
+		{"quiz_questions", func() error {
+			return streamJSONArray[models.QuizQuestion](w, h.db.Where("quiz_id IN (?)", quizIDs))
+		}},
+		{"deck_collaborators", func() error {
+			// Both sides: who the user shares their decks with, and the decks shared with them
+			return streamJSONArray[models.DeckCollaborator](w, h.db.Where("deck_id IN (?) OR user_id = ?", deckIDs, userID))
+		}},
+		{"study_goals", func() error {
+			return streamJSONArray[models.StudyGoal](w, h.db.Where("user_id = ?", userID))
+		}},
+		{"user_settings", func() error {
+			return streamJSONArray[models.UserSettings](w, h.db.Where("user_id = ?", userID))
+		}},
+	}
+
+	if _, err := fmt.Fprintf(w, `{"generated_at":%s,"user":%s`, generatedAt, profile); err != nil {
+		log.Printf("User export for %d aborted: %v", user.ID, err)
+		return
+	}
+
+	for _, section := range sections {
+		if _, err := fmt.Fprintf(w, `,"%s":`, section.name); err != nil {
+			log.Printf("User export for %d aborted: %v", user.ID, err)
+			return
+		}
+		if err := section.write(); err != nil {
+			log.Printf("User export for %d failed while writing %s: %v", user.ID, section.name, err)
+			return
+		}
+	}
+
+	if _, err := io.WriteString(w, "}"); err != nil {
+		log.Printf("User export for %d aborted: %v", user.ID, err)
+	}
+}
+
+// loadUserSettings -> Returns the user's settings, creating the defaults on first access
+func loadUserSettings(db *gorm.DB, userID uint) (models.UserSettings, error) {
+	settings := models.UserSettings{
+		UserID:              userID,
+		NewCardsPerDay:      20,
+		MaxReviewsPerDay:    200,
+		Timezone:            "UTC",
+		QuizMatchMode:       models.MatchExact,
+		LeechThreshold:      8,
+		LeechAction:         models.LeechActionTag,
+		ReminderTime:        "09:00",
+		ReminderChannel:     models.ReminderChannelEmail,
+		MasteryIntervalDays: stats.MasteryIntervalDays,
+	}
+	err := db.Where("user_id = ?", userID).Attrs(settings).FirstOrCreate(&settings).Error
+	return settings, err
+}
+
+// GetSettings -> Handler to get the calling user's study settings
+func (h *UserHandler) GetSettings(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	settings, err := loadUserSettings(h.db, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve settings", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"settings": settings,
+	})
+}
+
+// UpdateSettingsRequest -> Struct for partially updating study settings
+type UpdateSettingsRequest struct {
+	NewCardsPerDay      *int    `json:"new_cards_per_day" binding:"omitempty,min=0,max=1000"`
+	MaxReviewsPerDay    *int    `json:"max_reviews_per_day" binding:"omitempty,min=0,max=10000"`
+	Timezone            *string `json:"timezone"`
+	QuizMatchMode       *string `json:"quiz_match_mode" binding:"omitempty,oneof=exact case_insensitive"`
+	LeechThreshold      *int    `json:"leech_threshold" binding:"omitempty,min=1,max=100"`
+	LeechAction         *string `json:"leech_action" binding:"omitempty,oneof=tag suspend"`
+	ReminderEnabled     *bool   `json:"reminder_enabled"`
+	ReminderTime        *string `json:"reminder_time"` // "HH:MM" in the user's timezone
+	ReminderChannel     *string `json:"reminder_channel" binding:"omitempty,oneof=email log"`
+	DefaultDeckID       *uint   `json:"default_deck_id"` // Deck quick captures go to, 0 goes back to the Inbox deck
+	MasteryIntervalDays *int    `json:"mastery_interval_days" binding:"omitempty,min=1,max=3650"`
+}
+
+// UpdateSettings -> Handler to update the calling user's study settings
+func (h *UserHandler) UpdateSettings(c *gin.Context) {
+	var req UpdateSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	settings, err := loadUserSettings(h.db, userID.(uint))
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve settings", err))
+		return
+	}
+
+	// Update fields if provided
+	if req.NewCardsPerDay != nil {
+		settings.NewCardsPerDay = *req.NewCardsPerDay
+	}
+	if req.MaxReviewsPerDay != nil {
+		settings.MaxReviewsPerDay = *req.MaxReviewsPerDay
+	}
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" {
+			c.Error(apperrors.BadRequest("Unknown timezone, use an IANA name such as \"Europe/Berlin\""))
+			return
+		}
+		settings.Timezone = *req.Timezone
+	}
+	if req.QuizMatchMode != nil {
+		settings.QuizMatchMode = *req.QuizMatchMode
+	}
+	if req.LeechThreshold != nil {
+		settings.LeechThreshold = *req.LeechThreshold
+	}
+	if req.LeechAction != nil {
+		settings.LeechAction = *req.LeechAction
+	}
+	if req.ReminderEnabled != nil {
+		settings.ReminderEnabled = *req.ReminderEnabled
+	}
+	if req.ReminderTime != nil {
+		reminderTime, err := time.Parse(models.ReminderTimeLayout, *req.ReminderTime)
+		if err != nil {
+			c.Error(apperrors.BadRequest("Reminder time must be HH:MM on a 24-hour clock, e.g. \"08:30\""))
+			return
+		}
+		settings.ReminderTime = reminderTime.Format(models.ReminderTimeLayout)
+	}
+	if req.ReminderChannel != nil {
+		settings.ReminderChannel = *req.ReminderChannel
+	}
+	if req.MasteryIntervalDays != nil {
+		settings.MasteryIntervalDays = *req.MasteryIntervalDays
+	}
+	if req.DefaultDeckID != nil {
+		if *req.DefaultDeckID == 0 {
+			settings.DefaultDeckID = nil
+		} else {
+			deck, err := ownedDeck(h.db, *req.DefaultDeckID, userID.(uint))
+			if err != nil {
+				c.Error(apperrors.Internal("Failed to retrieve deck", err))
+				return
+			}
+			if deck == nil {
+				c.Error(apperrors.BadRequest("Default deck must be one of your own decks"))
+				return
+			}
+			settings.DefaultDeckID = &deck.ID
+		}
+	}
+
+	if err := h.db.Save(&settings).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to update settings", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Settings updated successfully",
+		"settings": settings,
+	})
+}
+
+// ChangePasswordRequest -> Struct for changing the password of a logged-in user
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"` // Checked against password.Validate
+}
+
+// ChangePassword -> Handler to replace the calling user's password, the current one must be supplied
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, userID).Error; err != nil {
+		c.Error(apperrors.NotFound("User not found"))
+		return
+	}
+
+	if err := user.CheckPassword(req.CurrentPassword); err != nil {
+		c.Error(apperrors.BadRequest("Current password is incorrect"))
+		return
+	}
+
+	if req.NewPassword == req.CurrentPassword {
+		c.Error(apperrors.BadRequest("New password must be different from the current one"))
+		return
+	}
+
+	if err := password.Validate(req.NewPassword); err != nil {
+		c.Error(apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	if err := user.HashPassword(req.NewPassword); err != nil {
+		c.Error(apperrors.Internal("Failed to hash password", err))
+		return
+	}
+
+	// Change the password and revoke every outstanding token together
+	tx := h.db.Begin()
+
+	if err := tx.Model(&user).Update("password_hash", user.PasswordHash).Error; err != nil {
+		tx.Rollback()
+		c.Error(apperrors.Internal("Failed to change password", err))
+		return
+	}
+
+	version, err := models.RevokeTokens(tx, user.ID)
+	if err != nil {
+		tx.Rollback()
+		c.Error(apperrors.Internal("Failed to change password", err))
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Error(apperrors.Internal("Failed to change password", err))
+		return
+	}
+
+	// The caller's own token was revoked with the rest, hand them a fresh one
+	user.TokenVersion = version
+	token, err := generateJWT(user)
+	if err != nil {
+		c.Error(apperrors.Internal("Failed to generate token", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password changed successfully",
+		"token":   token,
+	})
+}
+
+// LogoutEverywhere -> Handler to revoke every token issued to the calling user, including the one used
+func (h *UserHandler) LogoutEverywhere(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	if _, err := models.RevokeTokens(h.db, userID.(uint)); err != nil {
+		c.Error(apperrors.Internal("Failed to log out", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logged out of every session",
+	})
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/middleware"
+	"FlashQuiz/internal/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// newAuthRouter -> A router with login and the token-revoking endpoints behind the real auth middleware
+func newAuthRouter(t *testing.T) (*gin.Engine, models.User) {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	db := newTestDB(t)
+	user := models.User{Username: "alice", Email: "alice@example.com", Role: models.RoleUser}
+	if err := user.HashPassword("first-Passw0rd"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	r.POST("/auth/login", NewAuthHandler(db, nil).Login)
+
+	userHandler := NewUserHandler(db)
+	me := r.Group("/me", middleware.AuthMiddleware(db))
+	me.GET("/settings", userHandler.GetSettings)
+	me.PUT("/password", userHandler.ChangePassword)
+	me.POST("/logout-all", userHandler.LogoutEverywhere)
+	return r, user
+}
+
+// login -> Logs in through the handler and returns the issued token
+func login(t *testing.T, r *gin.Engine, username, password string) string {
+	t.Helper()
+
+	code, out := doJSON(t, r, http.MethodPost, "/auth/login", map[string]any{"username": username, "password": password})
+	if code != http.StatusOK {
+		t.Fatalf("login: status = %d, want %d: %v", code, http.StatusOK, out)
+	}
+	return out["token"].(string)
+}
+
+// doAuthed -> Like doJSON, sending token as the bearer token
+func doAuthed(t *testing.T, r *gin.Engine, token, method, path string, body any) (int, map[string]any) {
+	t.Helper()
+
+	req := newJSONRequest(t, method, path, body)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return serveJSON(t, r, req)
+}
+
+func TestTokensRevokedByPasswordChange(t *testing.T) {
+	r, user := newAuthRouter(t)
+	old := login(t, r, user.Username, "first-Passw0rd")
+
+	code, out := doAuthed(t, r, old, http.MethodPut, "/me/password", map[string]any{
+		"current_password": "first-Passw0rd",
+		"new_password":     "second-Passw0rd",
+	})
+	if code != http.StatusOK {
+		t.Fatalf("change password: status = %d, want %d: %v", code, http.StatusOK, out)
+	}
+
+	if code, out := doAuthed(t, r, old, http.MethodGet, "/me/settings", nil); code != http.StatusUnauthorized {
+		t.Errorf("token from before the change: status = %d, want %d: %v", code, http.StatusUnauthorized, out)
+	}
+
+	// Both the token handed back by the change and a fresh login use the new version
+	for name, token := range map[string]string{
+		"returned": out["token"].(string),
+		"login":    login(t, r, user.Username, "second-Passw0rd"),
+	} {
+		if code, out := doAuthed(t, r, token, http.MethodGet, "/me/settings", nil); code != http.StatusOK {
+			t.Errorf("%s token: status = %d, want %d: %v", name, code, http.StatusOK, out)
+		}
+	}
+}
+
+func TestTokensRevokedByLogoutEverywhere(t *testing.T) {
+	r, user := newAuthRouter(t)
+	first := login(t, r, user.Username, "first-Passw0rd")
+	second := login(t, r, user.Username, "first-Passw0rd")
+
+	if code, out := doAuthed(t, r, first, http.MethodPost, "/me/logout-all", nil); code != http.StatusOK {
+		t.Fatalf("logout everywhere: status = %d, want %d: %v", code, http.StatusOK, out)
+	}
+
+	for name, token := range map[string]string{"caller": first, "other session": second} {
+		if code, out := doAuthed(t, r, token, http.MethodGet, "/me/settings", nil); code != http.StatusUnauthorized {
+			t.Errorf("%s token: status = %d, want %d: %v", name, code, http.StatusUnauthorized, out)
+		}
+	}
+
+	fresh := login(t, r, user.Username, "first-Passw0rd")
+	if code, out := doAuthed(t, r, fresh, http.MethodGet, "/me/settings", nil); code != http.StatusOK {
+		t.Errorf("new token: status = %d, want %d: %v", code, http.StatusOK, out)
+	}
+}
+
+// exportUserData -> The caller's data export, each section left as raw JSON
+func exportUserData(t *testing.T, db *gorm.DB, userID uint) map[string]json.RawMessage {
+	t.Helper()
+
+	r := newTestRouter(userID)
+	r.GET("/me/export", NewUserHandler(db).ExportUserData)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/me/export", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("export: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &sections); err != nil {
+		t.Fatalf("export isn't JSON: %v", err)
+	}
+	return sections
+}
+
+func TestExportUserDataSections(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	deck := createTestDeck(t, db, alice.ID, "Capitals")
+	for _, user := range []models.User{alice, bob} {
+		if _, err := loadUserSettings(db, user.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Create(&models.StudyGoal{UserID: user.ID, DeckID: deck.ID, DailyReviews: 10}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Alice shares her deck with bob, bob shares one with carol and one with alice
+	carol := createTestUser(t, db, "carol")
+	bobsDeck := createTestDeck(t, db, bob.ID, "Rivers")
+	bobsOther := createTestDeck(t, db, bob.ID, "Mountains")
+	for _, share := range []models.DeckCollaborator{
+		{DeckID: deck.ID, UserID: bob.ID, Role: "viewer"},
+		{DeckID: bobsDeck.ID, UserID: alice.ID, Role: "editor"},
+		{DeckID: bobsOther.ID, UserID: carol.ID, Role: "viewer"},
+	} {
+		if err := db.Create(&share).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sections := exportUserData(t, db, alice.ID)
+
+	var settings []models.UserSettings
+	if err := json.Unmarshal(sections["user_settings"], &settings); err != nil {
+		t.Fatalf("user_settings: %v", err)
+	}
+	if len(settings) != 1 || settings[0].UserID != alice.ID {
+		t.Errorf("user_settings = %+v, want alice's settings only", settings)
+	}
+
+	var goals []models.StudyGoal
+	if err := json.Unmarshal(sections["study_goals"], &goals); err != nil {
+		t.Fatalf("study_goals: %v", err)
+	}
+	if len(goals) != 1 || goals[0].UserID != alice.ID {
+		t.Errorf("study_goals = %+v, want alice's goal only", goals)
+	}
+
+	var shares []models.DeckCollaborator
+	if err := json.Unmarshal(sections["deck_collaborators"], &shares); err != nil {
+		t.Fatalf("deck_collaborators: %v", err)
+	}
+	if len(shares) != 2 || shares[0].DeckID != deck.ID || shares[1].DeckID != bobsDeck.ID {
+		t.Errorf("deck_collaborators = %+v, want alice's deck shared with bob and bob's deck shared with alice", shares)
+	}
+}
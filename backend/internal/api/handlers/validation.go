@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/models"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Custom binding rules shared by the request structs in this package
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		// content_type -> One of models.ContentTypes
+		v.RegisterValidation("content_type", func(fl validator.FieldLevel) bool {
+			return models.IsValidContentType(fl.Field().String())
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetWeakCardsRequest -> Query parameters for the caller's most missed cards
+type GetWeakCardsRequest struct {
+	DeckID uint   `form:"deck_id"`
+	From   string `form:"from" binding:"omitempty,datetime=2006-01-02"` // Optional start date
+	To     string `form:"to" binding:"omitempty,datetime=2006-01-02"`   // Optional end date (inclusive)
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// WeakCard -> A card the caller keeps getting wrong in quizzes
+type WeakCard struct {
+	CardID         uint     `json:"card_id"`
+	FrontContent   string   `json:"front_content"`
+	DeckID         uint     `json:"deck_id"`
+	DeckTitle      string   `json:"deck_title"`
+	Misses         int64    `json:"misses"`
+	Attempts       int64    `json:"attempts"`
+	ReviewAccuracy *float64 `json:"review_accuracy"` // Share of study reviews answered correctly, null when never studied
+}
+
+// GetWeakCards -> Handler to list the cards the caller misses most across all their quizzes,
+// finished or not, as a trouble list for targeted practice. Ties go to the card with the worse
+// study accuracy, then to the one quizzed most recently. Cards and decks since deleted are left out
+func (h *QuizHandler) GetWeakCards(c *gin.Context) {
+	var req GetWeakCardsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = 20
+	}
+
+	query := h.db.Table("quiz_questions").
+		Joins("JOIN quizzes ON quizzes.id = quiz_questions.quiz_id AND quizzes.deleted_at IS NULL").
+		Joins("JOIN flash_cards ON flash_cards.id = quiz_questions.card_id AND flash_cards.deleted_at IS NULL").
+		Joins("JOIN decks ON decks.id = flash_cards.deck_id AND decks.deleted_at IS NULL").
+		Joins("LEFT JOIN card_progresses ON card_progresses.card_id = quiz_questions.card_id AND card_progresses.user_id = ? AND card_progresses.deleted_at IS NULL", userID).
+		Where("quiz_questions.deleted_at IS NULL AND quiz_questions.answered_at IS NOT NULL AND quizzes.user_id = ?", userID)
+	if req.DeckID > 0 {
+		query = query.Where("flash_cards.deck_id = ?", req.DeckID)
+	}
+
+	// Dates are whole UTC days, like quiz analytics, "to" is inclusive
+	if req.From != "" {
+		from, _ := time.Parse("2006-01-02", req.From)
+		query = query.Where("quiz_questions.answered_at >= ?", from)
+	}
+	if req.To != "" {
+		to, _ := time.Parse("2006-01-02", req.To)
+		query = query.Where("quiz_questions.answered_at < ?", to.AddDate(0, 0, 1))
+	}
+
+	weakCards := make([]WeakCard, 0)
+	if err := query.
+		Select(`quiz_questions.card_id, flash_cards.front_content, flash_cards.deck_id, decks.title AS deck_title,
+			SUM(CASE WHEN quiz_questions.is_correct THEN 0 ELSE 1 END) AS misses,
+			COUNT(*) AS attempts,
+			CASE WHEN MAX(card_progresses.review_count) > 0
+				THEN 1.0 * MAX(card_progresses.correct_count) / MAX(card_progresses.review_count) END AS review_accuracy`).
+		Group("quiz_questions.card_id, flash_cards.front_content, flash_cards.deck_id, decks.title").
+		Having("misses > 0").
+		Order("misses DESC, review_accuracy IS NULL, review_accuracy ASC, MAX(quiz_questions.answered_at) DESC").
+		Limit(limit).
+		Scan(&weakCards).Error; err != nil {
+		c.Error(apperrors.Internal("Failed to retrieve weak cards", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"weak_cards": weakCards,
+	})
+}
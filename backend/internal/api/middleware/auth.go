@@ -1,27 +1,35 @@
 package middleware
 
 import (
+	"FlashQuiz/internal/api/apperrors"
+	"FlashQuiz/internal/models"
 	"errors"
 	"fmt"
-	"net/http"
 	"os"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
 )
 
-// Verifies the JWT Token and passes user information into the context
-func AuthMiddleware() gin.HandlerFunc {
+// Verifies the JWT Token and passes user information into the context.
+// Tokens minted before the user's last password change or "log out everywhere" are rejected
+func AuthMiddleware(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context){
 		// Debug Output
 		fmt.Println("Auth middleware processing request: ", c.Request.URL.Path)
 
 		// Getting Authorization Header
 		authHeader := c.GetHeader("Authorization")
+
+		// Browsers can't set headers on WebSocket handshakes, so those may pass the token as a query param
+		if authHeader == "" && c.Query("token") != "" && strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+			authHeader = "Bearer " + c.Query("token")
+		}
 		if authHeader == "" {
 			fmt.Println("No Authorization Header found")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization Header Missing"})
+			c.Error(apperrors.Unauthorized("Authorization Header Missing"))
 			c.Abort()
 			return
 		}
@@ -30,14 +38,14 @@ func AuthMiddleware() gin.HandlerFunc {
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
 			fmt.Println("Invalid Authorization format: ", authHeader)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Header must be in the format Bearer <token>"})
+			c.Error(apperrors.Unauthorized("Header must be in the format Bearer <token>"))
 			c.Abort()
 			return
 		}
 
 		// Getting the token
 		tokenString := parts[1]
-		fmt.Println("Token recieved: ", tokenString[:10], "...")
+		fmt.Println("Token recieved: ", tokenString[:min(10, len(tokenString))], "...")
 
 		// Parse and validate token
 		claims := &jwt.MapClaims{}
@@ -54,14 +62,14 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		if err != nil {
 			fmt.Println("Token parsing error: ", err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			c.Error(apperrors.Unauthorized("Invalid or expired token"))
 			c.Abort()
 			return
 		}
 
 		if !token.Valid{
 			fmt.Println("Invalid Token")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Token"})
+			c.Error(apperrors.Unauthorized("Invalid Token"))
 			c.Abort()
 			return
 		}
@@ -70,16 +78,49 @@ func AuthMiddleware() gin.HandlerFunc {
 		userID, ok := (*claims)["user_id"]
 		if !ok {
 			fmt.Println("No user_id found in token claims")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+			c.Error(apperrors.Unauthorized("Invalid token claims"))
 			c.Abort()
 			return
 		}
 
 		// Convert userId into uint and pass into context
 		userIDValue := uint(userID.(float64))
+
+		// Tokens issued before versioning existed carry no version and count as version 0
+		tokenVersion, _ := (*claims)["token_version"].(float64)
+		var user models.User
+		if err := db.Select("id", "token_version").First(&user, userIDValue).Error; err != nil {
+			c.Error(apperrors.Unauthorized("Invalid or expired token"))
+			c.Abort()
+			return
+		}
+		if int(tokenVersion) != user.TokenVersion {
+			c.Error(apperrors.Unauthorized("Token has been revoked, please log in again"))
+			c.Abort()
+			return
+		}
 		fmt.Println("Setting user_id in context: ", userIDValue)
 		c.Set("user_id", userIDValue)
 		c.Set("username", (*claims)["username"])
+
+		// Tokens issued before roles existed carry no role claim and are treated as regular users
+		role, _ := (*claims)["role"].(string)
+		if role == "" {
+			role = models.RoleUser
+		}
+		c.Set("role", role)
+		c.Next()
+	}
+}
+
+// RequireRole -> Only lets requests through when the token's role matches, must run after AuthMiddleware
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("role") != role {
+			c.Error(apperrors.Forbidden("You don't have permission to access this resource"))
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
\ No newline at end of file
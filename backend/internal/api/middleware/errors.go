@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// ErrorHandler -> Recovers from panics and renders errors attached with c.Error
+// as a consistent {"error": {"code", "message", "request_id"}} envelope, plus "details" when the
+// error carries any
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				log.Printf("Panic recovered on %s %s: %v\n%s", c.Request.Method, c.Request.URL.Path, rec, debug.Stack())
+				writeError(c, apperrors.Internal("Internal server error", fmt.Errorf("panic: %v", rec)))
+				c.Abort()
+			}
+		}()
+
+		c.Next()
+
+		// Nothing to do if the handler succeeded or already wrote its own response
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		writeError(c, c.Errors.Last().Err)
+	}
+}
+
+// toAppError -> Maps known error types to their HTTP representation
+func toAppError(err error) *apperrors.Error {
+	var appErr *apperrors.Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	var validationErrors validator.ValidationErrors
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return apperrors.New(http.StatusNotFound, "not_found", "Resource not found", err)
+	case errors.As(err, &validationErrors), errors.As(err, &syntaxErr), errors.As(err, &typeErr), errors.As(err, &maxBytesErr):
+		return apperrors.Validation(err)
+	default:
+		return apperrors.Internal("Internal server error", err)
+	}
+}
+
+func writeError(c *gin.Context, err error) {
+	appErr := toAppError(err)
+
+	// Log the full error server-side, clients only get the safe message
+	requestID := c.GetString("request_id")
+	log.Printf("Request %s %s failed with %d (request_id=%s): %v", c.Request.Method, c.Request.URL.Path, appErr.Status, requestID, appErr)
+
+	// Include the request ID so a user reporting an error can quote it
+	body := gin.H{
+		"code":       appErr.Code,
+		"message":    appErr.Message,
+		"request_id": requestID,
+	}
+	if appErr.Details != nil {
+		body["details"] = appErr.Details
+	}
+	c.JSON(appErr.Status, gin.H{"error": body})
+}
@@ -1,17 +1,47 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func LoggerMiddleware() gin.HandlerFunc{
-	return func(c *gin.Context){
+// newRequestLogger -> Builds the access logger, LOG_FORMAT=text switches from JSON lines to key=value text
+func newRequestLogger() *slog.Logger {
+	if strings.EqualFold(os.Getenv("LOG_FORMAT"), "text") {
+		return slog.New(slog.NewTextHandler(os.Stdout, nil))
+	}
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+}
+
+// LoggerMiddleware -> Emits one structured log line per request
+func LoggerMiddleware() gin.HandlerFunc {
+	logger := newRequestLogger()
+
+	return func(c *gin.Context) {
 		start := time.Now()
 		c.Next()
 		duration := time.Since(start)
-		log.Printf("Request: %s %s took %v", c.Request.Method, c.Request.URL.Path, duration)
+
+		attrs := []any{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", c.Writer.Status()),
+			slog.Float64("latency_ms", float64(duration.Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+		}
+
+		// Only present once AuthMiddleware has run
+		if userID, exists := c.Get("user_id"); exists {
+			attrs = append(attrs, slog.Any("user_id", userID))
+		}
+		if requestID := c.GetString("request_id"); requestID != "" {
+			attrs = append(attrs, slog.String("request_id", requestID))
+		}
+
+		logger.Info("request", attrs...)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userRateLimiter -> Sliding window log of each user's recent requests, kept in memory so limits
+// are per server instance
+type userRateLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu        sync.Mutex
+	hits      map[uint][]time.Time // Oldest first, only those still inside the window
+	lastSweep time.Time
+}
+
+// allow -> Records a request by the user at now if they're under the limit. Otherwise returns
+// how long until their oldest request leaves the window and frees a slot
+func (l *userRateLimiter) allow(userID uint, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cutoff := now.Add(-l.window)
+
+	// Forget users who went quiet, so the map doesn't grow with every account ever seen
+	if now.Sub(l.lastSweep) > l.window {
+		for id, times := range l.hits {
+			if !times[len(times)-1].After(cutoff) {
+				delete(l.hits, id)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	times := l.hits[userID]
+	expired := 0
+	for expired < len(times) && !times[expired].After(cutoff) {
+		expired++
+	}
+	times = times[expired:]
+
+	if len(times) >= l.limit {
+		l.hits[userID] = times
+		return false, times[0].Sub(cutoff)
+	}
+
+	l.hits[userID] = append(times, now)
+	return true, 0
+}
+
+// UserRateLimit -> Allows each authenticated user at most limit write requests (anything but GET,
+// HEAD and OPTIONS) per sliding window, answering the rest with 429 and Retry-After. Routes in
+// reads, given as their full path pattern, are reads sent with another method and never limited.
+// Must run after AuthMiddleware. A limit of 0 or less turns it off
+func UserRateLimit(limit int, window time.Duration, reads ...string) gin.HandlerFunc {
+	if limit <= 0 || window <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	limiter := &userRateLimiter{limit: limit, window: window, hits: make(map[uint][]time.Time)}
+
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if slices.Contains(reads, c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.Next()
+			return
+		}
+
+		allowed, retryAfter := limiter.allow(userID.(uint), time.Now())
+		if !allowed {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(max(seconds, 1)))
+			c.Error(apperrors.TooManyRequests("Too many requests, slow down and try again shortly"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
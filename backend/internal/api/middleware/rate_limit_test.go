@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUserRateLimitReads(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(ErrorHandler())
+	r.Use(func(c *gin.Context) { c.Set("user_id", uint(1)) })
+	r.Use(UserRateLimit(2, time.Minute, "/study/next-cards"))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.POST("/study/next-cards", ok)
+	r.POST("/study/update-progress", ok)
+
+	post := func(path string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		return w.Code
+	}
+
+	for i := range 5 {
+		if code := post("/study/next-cards"); code != http.StatusOK {
+			t.Fatalf("read %d: status = %d, want %d", i, code, http.StatusOK)
+		}
+	}
+
+	// The reads above used none of the budget
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		if code := post("/study/update-progress"); code != want {
+			t.Errorf("write %d: status = %d, want %d", i, code, want)
+		}
+	}
+}
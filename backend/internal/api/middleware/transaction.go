@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"FlashQuiz/internal/api/apperrors"
+	"bytes"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Context keys holding the request's transaction and what to run once it's committed
+const (
+	txKey          = "db_tx"
+	afterCommitKey = "db_after_commit"
+)
+
+// DB -> The request's transaction when the route runs under Transaction, otherwise fallback
+func DB(c *gin.Context, fallback *gorm.DB) *gorm.DB {
+	if tx, ok := c.Get(txKey); ok {
+		return tx.(*gorm.DB)
+	}
+	return fallback
+}
+
+// AfterCommit -> Runs fn once the request's transaction is committed, and never if it's rolled
+// back. Meant for side effects others shouldn't see early, like dropping cached copies of what
+// changed. Outside Transaction there's nothing to wait for, so fn runs right away
+func AfterCommit(c *gin.Context, fn func()) {
+	if _, ok := c.Get(txKey); !ok {
+		fn()
+		return
+	}
+	hooks, _ := c.Get(afterCommitKey)
+	list, _ := hooks.([]func())
+	c.Set(afterCommitKey, append(list, fn))
+}
+
+// bufferedWriter -> Holds the handler's response back until the transaction is committed, so a
+// client is never told a change succeeded when the commit then fails
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) { w.status = code }
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) { return w.body.Write(data) }
+
+func (w *bufferedWriter) WriteString(s string) (int, error) { return w.body.WriteString(s) }
+
+func (w *bufferedWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int { return w.body.Len() }
+
+func (w *bufferedWriter) Written() bool { return w.status != 0 || w.body.Len() > 0 }
+
+// Flush -> Nothing can be streamed before the commit, the whole response goes out afterwards
+func (w *bufferedWriter) Flush() {}
+
+// Transaction -> Runs the handler inside one database transaction, which handlers reach through
+// DB. It's committed when the handler succeeds and rolled back when it attached an error, answered
+// with an error status or panicked; the panic then carries on to ErrorHandler. The response is
+// only sent once the commit went through, a failed commit is reported as an internal error instead.
+// A busy database isn't retried, since the handler chain can't be run a second time, so handlers
+// that must ride out lock contention run their own retried transaction instead
+func Transaction(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tx := db.WithContext(c.Request.Context()).Begin()
+		if tx.Error != nil {
+			c.Error(apperrors.Internal("Failed to start transaction", tx.Error))
+			c.Abort()
+			return
+		}
+
+		original := c.Writer
+		buffered := &bufferedWriter{ResponseWriter: original}
+		c.Writer = buffered
+		c.Set(txKey, tx)
+
+		defer func() {
+			c.Writer = original
+			if rec := recover(); rec != nil {
+				tx.Rollback()
+				panic(rec)
+			}
+		}()
+
+		c.Next()
+
+		if len(c.Errors) > 0 || buffered.Status() >= http.StatusBadRequest {
+			if err := tx.Rollback().Error; err != nil {
+				log.Printf("Failed to roll back transaction for %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+			}
+			if len(c.Errors) > 0 && buffered.body.Len() == 0 {
+				// ErrorHandler renders the attached error once the original writer is back
+				return
+			}
+		} else {
+			if err := tx.Commit().Error; err != nil {
+				c.Error(apperrors.Internal("Failed to save changes", err))
+				return
+			}
+			if hooks, ok := c.Get(afterCommitKey); ok {
+				for _, fn := range hooks.([]func()) {
+					fn()
+				}
+			}
+		}
+
+		original.WriteHeader(buffered.Status())
+		if buffered.body.Len() == 0 {
+			original.WriteHeaderNow()
+			return
+		}
+		if _, err := original.Write(buffered.body.Bytes()); err != nil {
+			log.Printf("Failed to write response for %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		}
+	}
+}
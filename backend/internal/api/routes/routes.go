@@ -3,70 +3,210 @@ package routes
 import (
 	"FlashQuiz/internal/api/handlers"
 	"FlashQuiz/internal/api/middleware"
+	"FlashQuiz/internal/cache"
+	"FlashQuiz/internal/config"
+	"FlashQuiz/internal/mailer"
+	"FlashQuiz/internal/models"
+	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func SetupRoutes(router *gin.Engine, db *gorm.DB) {
+func SetupRoutes(router *gin.Engine, db *gorm.DB, deckCache *cache.Cache, mail mailer.Mailer) {
 	// Middleware
+	router.Use(middleware.RequestIDMiddleware())
 	router.Use(middleware.LoggerMiddleware())
+	router.Use(middleware.ErrorHandler())
+	router.Use(middleware.BodyLimit(int64(config.Int("MAX_BODY_BYTES", 1<<20))))
+
+	// Imports carry whole decks, so they get a much larger body limit than everything else
+	importBodyLimit := middleware.BodyLimit(int64(config.Int("MAX_IMPORT_BODY_BYTES", 10<<20)))
+
+	// Per-user limits on write requests, one budget per route group. Reads are never limited,
+	// including the given routes that read with a POST
+	rateWindow := config.Duration("RATE_LIMIT_WINDOW", time.Minute)
+	writeLimit := func(group string, fallback int, reads ...string) gin.HandlerFunc {
+		return middleware.UserRateLimit(config.Int("RATE_LIMIT_"+group+"_WRITES", fallback), rateWindow, reads...)
+	}
+
+	// Runs a write route in one transaction, for handlers that reach the database through middleware.DB
+	withTx := middleware.Transaction(db)
 
 	// Initialize Handlers
-	authHandler := handlers.NewAuthHandler(db)
-	deckHandler := handlers.NewDeckHandler(db)
-	cardHandler := handlers.NewCardHandler(db)
+	authHandler := handlers.NewAuthHandler(db, mail)
+	deckHandler := handlers.NewDeckHandler(db, deckCache)
+	cardHandler := handlers.NewCardHandler(db, deckCache)
 	quizHandler := handlers.NewQuizHandler(db)
 	studyHandler := handlers.NewStudyHandler(db)
+	userHandler := handlers.NewUserHandler(db)
+	adminHandler := handlers.NewAdminHandler(db, deckCache)
+	categoryHandler := handlers.NewCategoryHandler(db, deckCache)
+	tagHandler := handlers.NewTagHandler(db)
+
+	// Cache hit/miss metrics
+	router.GET("/metrics/cache", func(c *gin.Context) {
+		c.JSON(http.StatusOK, deckCache.Stats())
+	})
 
 	// Public routes for authentication
 	authRoutes := router.Group("/auth")
 	{
 		authRoutes.POST("/register", authHandler.RegisterUser)
 		authRoutes.POST("/login", authHandler.Login)
+		authRoutes.POST("/forgot-password", authHandler.ForgotPassword)
+		authRoutes.POST("/reset-password", authHandler.ResetPassword)
+	}
+
+	// Public read-only routes, no account needed. Everything here must only expose public decks
+	public := router.Group("/public")
+	{
+		public.GET("/decks/:id/preview", deckHandler.PreviewPublicDeck)
 	}
 
 	// Protected routes that require authentication
 	api := router.Group("/api")
-	api.Use(middleware.AuthMiddleware())
+	api.Use(middleware.AuthMiddleware(db))
 	{
+		// Current user routes
+		me := api.Group("/me")
+		me.Use(writeLimit("ME", 30))
+		{
+			me.GET("/export", userHandler.ExportUserData)
+			me.GET("/decks/export", userHandler.ExportAllDecks)
+			me.GET("/weak-cards", quizHandler.GetWeakCards)
+			me.GET("/settings", userHandler.GetSettings)
+			me.PATCH("/settings", userHandler.UpdateSettings)
+			me.PUT("/password", userHandler.ChangePassword)
+			me.POST("/logout-all", userHandler.LogoutEverywhere)
+			me.POST("/recompute-stats", withTx, userHandler.RecomputeStats)
+		}
+
 		// Deck routes
 		decks := api.Group("/decks")
+		decks.Use(writeLimit("DECKS", 60))
 		{
 			decks.POST("", deckHandler.CreateDeck)
 			decks.GET("", deckHandler.GetDecks)
 			decks.GET("/:id", deckHandler.GetDeckByID)
 			decks.PUT("/:id", deckHandler.UpdateDeck)
 			decks.DELETE("/:id", deckHandler.DeleteDeck)
+			decks.GET("/trash", deckHandler.GetDeckTrash)
+			decks.POST("/:id/restore", deckHandler.RestoreDeck)
+			decks.DELETE("/:id/permanent", deckHandler.PurgeDeck)
+			decks.GET("/:id/export", deckHandler.ExportDeck)
+			decks.POST("/:id/reset-progress", deckHandler.ResetDeckProgress)
+			decks.GET("/:id/unstudied", deckHandler.GetUnstudiedCards)
+			decks.GET("/:id/random-card", deckHandler.GetRandomCard)
+			decks.GET("/:id/goal", studyHandler.GetStudyGoal)
+			decks.PUT("/:id/goal", studyHandler.SetStudyGoal)
+			decks.GET("/:id/mastery", studyHandler.GetDeckMastery)
+			decks.POST("/import", importBodyLimit, deckHandler.ImportDeck)
+			decks.POST("/merge", deckHandler.MergeDecks)
+			decks.GET("/:id/collaborators", deckHandler.GetCollaborators)
+			decks.POST("/:id/collaborators", deckHandler.AddCollaborator)
+			decks.DELETE("/:id/collaborators/:username", deckHandler.RemoveCollaborator)
 		}
 
 		// Flashcard routes
 		cards := api.Group("/cards")
+		cards.Use(writeLimit("CARDS", 120))
 		{
 			cards.POST("", cardHandler.CreateCard)
+			cards.POST("/quick", cardHandler.QuickCreateCard)
+			cards.GET("/search", cardHandler.SearchCards)
 			cards.GET("/:id", cardHandler.GetCardByID)
 			cards.GET("/deck/:deck_id", cardHandler.GetCardsByDeck)
 			cards.PUT("/:id", cardHandler.UpdateCard)
 			cards.DELETE("/:id", cardHandler.DeleteCard)
-			cards.POST("/bulk-import", cardHandler.BulkImportCards)
+			cards.POST("/:id/star", cardHandler.StarCard)
+			cards.DELETE("/:id/star", cardHandler.UnstarCard)
+			cards.POST("/:id/duplicate", cardHandler.DuplicateCard)
+			cards.POST("/bulk-import", importBodyLimit, cardHandler.BulkImportCards)
+			cards.POST("/import-text", importBodyLimit, cardHandler.ImportTextCards)
+			cards.POST("/bulk-update", cardHandler.BulkUpdateCards)
+			cards.POST("/bulk-move", cardHandler.BulkMoveCards)
+			cards.POST("/bulk-tag", cardHandler.BulkTagCards)
+			cards.POST("/bulk-untag", cardHandler.BulkUntagCards)
+			cards.POST("/:id/tags", cardHandler.TagCard)
+			cards.DELETE("/:id/tags/:tag_id", cardHandler.UntagCard)
+			cards.GET("/:id/note", cardHandler.GetCardNote)
+			cards.PUT("/:id/note", cardHandler.SaveCardNote)
+			cards.DELETE("/:id/note", cardHandler.DeleteCardNote)
 		}
 
 		// Quiz routes
 		quizzes := api.Group("/quizzes")
+		quizzes.Use(writeLimit("QUIZZES", 60))
 		{
 			quizzes.POST("", quizHandler.CreateQuiz)
 			quizzes.GET("", quizHandler.GetUserQuizzes)
+			quizzes.GET("/analytics", quizHandler.GetQuizAnalytics)
 			quizzes.GET("/:id", quizHandler.GetQuiz)
+			quizzes.GET("/:id/next", quizHandler.GetNextQuizQuestion)
+			quizzes.GET("/:id/resume", quizHandler.GetResumeQuiz)
+			quizzes.GET("/:id/report.pdf", quizHandler.GetQuizReport)
 			quizzes.POST("/answer", quizHandler.SubmitQuizAnswer)
 			quizzes.POST("/complete", quizHandler.CompleteQuiz)
+			quizzes.POST("/:id/invite", quizHandler.InviteToQuiz)
+			quizzes.POST("/:id/attempt", quizHandler.StartQuizAttempt)
+			quizzes.POST("/:id/regenerate", quizHandler.RegenerateQuiz)
+			quizzes.GET("/:id/leaderboard", quizHandler.GetQuizLeaderboard)
+			quizzes.POST("/:id/share", quizHandler.ShareQuiz)
+			quizzes.DELETE("/:id/share", quizHandler.UnshareQuiz)
+			quizzes.GET("/shared/:token", quizHandler.GetSharedQuiz)
+		}
+
+		// Deck category routes
+		categories := api.Group("/categories")
+		categories.Use(writeLimit("CATEGORIES", 30))
+		{
+			categories.GET("", categoryHandler.GetCategories)
+			categories.PUT("/rename", categoryHandler.RenameCategory)
+		}
+
+		// Card tag routes
+		tags := api.Group("/tags")
+		tags.Use(writeLimit("TAGS", 60))
+		{
+			tags.GET("", tagHandler.GetTags)
+			tags.PATCH("/:id", tagHandler.RenameTag)
+			tags.DELETE("/:id", tagHandler.DeleteTag)
 		}
 
 		// Study/Spaced repetition routes
 		study := api.Group("/study")
+		study.Use(writeLimit("STUDY", 300, "/api/study/next-cards"))
 		{
 			study.POST("/next-cards", studyHandler.GetNextCards)
+			study.GET("/next", studyHandler.GetNextCard)
+			study.GET("/due-all", studyHandler.GetDueAll)
 			study.POST("/update-progress", studyHandler.UpdateCardProgress)
 			study.GET("/stats", studyHandler.GetStudyStats)
+			study.GET("/plan", studyHandler.GetStudyPlan)
+			study.GET("/history", studyHandler.GetReviewHistory)
+			study.GET("/heatmap", studyHandler.GetStudyHeatmap)
+			study.POST("/reschedule", studyHandler.RescheduleOverdue)
+			study.GET("/leeches", studyHandler.GetLeeches)
+			study.GET("/mastered", studyHandler.GetMasteredCards)
+			study.POST("/unsuspend", studyHandler.UnsuspendCard)
+			study.POST("/reset-card", studyHandler.ResetCard)
+			study.GET("/preview", studyHandler.PreviewIntervals)
+			study.POST("/mark-known", studyHandler.MarkKnown)
+			study.POST("/session/start", studyHandler.StartStudySession)
+			study.POST("/session/end", studyHandler.EndStudySession)
+			study.GET("/sessions", studyHandler.GetStudySessions)
+			study.GET("/ws", studyHandler.StudySocket)
+		}
+
+		// Admin-only moderation routes
+		admin := api.Group("/admin")
+		admin.Use(middleware.RequireRole(models.RoleAdmin))
+		{
+			admin.GET("/decks", adminHandler.ListPublicDecks)
+			admin.POST("/decks/:id/unpublish", adminHandler.UnpublishDeck)
+			admin.DELETE("/decks/:id", adminHandler.DeleteDeck)
 		}
 	}
 }
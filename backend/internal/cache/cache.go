@@ -0,0 +1,134 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Cache -> Optional Redis-backed JSON cache. A nil *Cache is valid and behaves as
+// an always-missing cache, so callers fall through to the database transparently
+type Cache struct {
+	client *redis.Client
+	ttl    time.Duration
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// New -> Connects to the Redis instance at redisURL, returns nil (caching disabled) when it's empty
+func New(redisURL string, ttl time.Duration) (*Cache, error) {
+	if redisURL == "" {
+		return nil, nil
+	}
+
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, err
+	}
+
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return &Cache{client: client, ttl: ttl}, nil
+}
+
+// Get -> Loads the cached JSON value for key into dest, reporting whether it was a hit
+func (c *Cache) Get(ctx context.Context, key string, dest any) bool {
+	if c == nil {
+		return false
+	}
+
+	data, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("Cache get %s failed: %v", key, err)
+		}
+		c.misses.Add(1)
+		return false
+	}
+
+	if err := json.Unmarshal(data, dest); err != nil {
+		log.Printf("Cache entry %s is corrupt: %v", key, err)
+		c.misses.Add(1)
+		return false
+	}
+
+	c.hits.Add(1)
+	return true
+}
+
+// Set -> Stores value as JSON under key with the configured TTL. Failures are only logged
+func (c *Cache) Set(ctx context.Context, key string, value any) {
+	if c == nil {
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("Cache set %s failed: %v", key, err)
+		return
+	}
+
+	if err := c.client.Set(ctx, key, data, c.ttl).Err(); err != nil {
+		log.Printf("Cache set %s failed: %v", key, err)
+	}
+}
+
+// Delete -> Removes the given keys
+func (c *Cache) Delete(ctx context.Context, keys ...string) {
+	if c == nil || len(keys) == 0 {
+		return
+	}
+
+	if err := c.client.Del(ctx, keys...).Err(); err != nil {
+		log.Printf("Cache delete %v failed: %v", keys, err)
+	}
+}
+
+// DeletePrefix -> Removes every key starting with prefix, used for query-keyed entries
+func (c *Cache) DeletePrefix(ctx context.Context, prefix string) {
+	if c == nil {
+		return
+	}
+
+	iter := c.client.Scan(ctx, 0, prefix+"*", 100).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		log.Printf("Cache scan %s* failed: %v", prefix, err)
+		return
+	}
+
+	c.Delete(ctx, keys...)
+}
+
+// Stats -> Hit/miss counters since startup
+func (c *Cache) Stats() map[string]any {
+	if c == nil {
+		return map[string]any{"enabled": false}
+	}
+
+	hits, misses := c.hits.Load(), c.misses.Load()
+	hitRate := 0.0
+	if hits+misses > 0 {
+		hitRate = float64(hits) / float64(hits+misses) * 100
+	}
+
+	return map[string]any{
+		"enabled":  true,
+		"hits":     hits,
+		"misses":   misses,
+		"hit_rate": hitRate,
+	}
+}
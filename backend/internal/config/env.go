@@ -0,0 +1,87 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// String -> Returns the env var or the fallback when it's unset or empty
+func String(key, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// List -> Splits a comma-separated env var, dropping empty entries. Falls back (with a warning)
+// when nothing is left, e.g. a value of only commas
+func List(key string, fallback []string) []string {
+	raw := os.Getenv(key)
+	if strings.TrimSpace(raw) == "" {
+		return fallback
+	}
+
+	var values []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	if len(values) == 0 {
+		log.Printf("Invalid value %q for %s, using default %v", raw, key, fallback)
+		return fallback
+	}
+	return values
+}
+
+// Int -> Parses an integer env var, falling back (with a warning) on invalid input
+func Int(key string, fallback int) int {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return fallback
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		log.Printf("Invalid value %q for %s, using default %d", raw, key, fallback)
+		return fallback
+	}
+	return value
+}
+
+// Duration -> Parses a Go duration env var (e.g. "30m"), falling back (with a warning) on invalid input
+func Duration(key string, fallback time.Duration) time.Duration {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return fallback
+	}
+
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		log.Printf("Invalid value %q for %s, using default %s", raw, key, fallback)
+		return fallback
+	}
+	return value
+}
+
+// AllowedOrigins -> Browser origins allowed to call the API, from the comma-separated
+// CORS_ALLOWED_ORIGINS. localhost is only the dev fallback
+func AllowedOrigins() []string {
+	return List("CORS_ALLOWED_ORIGINS", []string{"http://localhost:3000"})
+}
+
+// TrustedProxies -> Proxies whose X-Forwarded-For header is believed when working out the client IP,
+// from the comma-separated TRUSTED_PROXIES (IPs or CIDR ranges such as 10.0.0.0/8). "none" trusts no
+// proxy, so the client IP is always the connecting address. The client IP is what the request log
+// records, so it's only as good as this list: a proxy trusted by mistake lets clients pick their own
+// IP by sending the header, and a real load balancer left out logs every client under its address
+func TrustedProxies() []string {
+	proxies := List("TRUSTED_PROXIES", []string{"127.0.0.1"})
+	if len(proxies) == 1 && strings.EqualFold(proxies[0], "none") {
+		return nil
+	}
+	return proxies
+}
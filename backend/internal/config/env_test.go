@@ -0,0 +1,30 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestList(t *testing.T) {
+	fallback := []string{"http://localhost:3000"}
+
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{"unset", "", fallback},
+		{"one", "https://quiz.example.com", []string{"https://quiz.example.com"}},
+		{"several with spaces", " https://a.example.com , https://b.example.com,", []string{"https://a.example.com", "https://b.example.com"}},
+		{"only separators", " , ,", fallback},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CORS_ALLOWED_ORIGINS", tt.value)
+			if got := List("CORS_ALLOWED_ORIGINS", fallback); !slices.Equal(got, tt.want) {
+				t.Errorf("List() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package mailer
+
+import (
+	"context"
+	"log"
+)
+
+// Mailer -> Delivers outgoing emails, swap the implementation to change providers
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// LogMailer -> Development mailer that writes emails to the server log instead of sending them
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(ctx context.Context, to, subject, body string) error {
+	log.Printf("Email to %s\nSubject: %s\n\n%s", to, subject, body)
+	return nil
+}
@@ -0,0 +1,27 @@
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+)
+
+// policy -> User-generated content policy: basic formatting, links, lists, tables and code blocks.
+// Scripts, styles, event handler attributes and javascript: URLs are stripped
+var policy = func() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	// Keep the fenced code block language so the frontend can highlight it
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[a-zA-Z0-9+#-]+$`)).OnElements("code")
+	return p
+}()
+
+// Render -> Converts markdown to HTML that is safe to insert into a page as-is
+func Render(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := goldmark.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+	return policy.Sanitize(buf.String()), nil
+}
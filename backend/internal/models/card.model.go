@@ -1,6 +1,9 @@
 package models
 
 import (
+	"FlashQuiz/internal/answermatch"
+	"FlashQuiz/internal/markdown"
+	"slices"
 	"time"
 
 	"gorm.io/gorm"
@@ -9,69 +12,268 @@ import (
 // Deck -> Group of flashcards
 type Deck struct {
 	gorm.Model
-	Title       string      `json:"title" gorm:"not null"`
-	Description string      `json:"description"`
-	Category    string      `json:"category"`
-	CardCount   int         `json:"card_count"`
-	IsPublic    bool        `json:"is_public"`
-	UserID      uint        `json:"user_id" gorm:"index"`
-	User        User        `json:"-" gorm:"foreignKey:UserID"`
-	FlashCards  []FlashCard `json:"flash_cards,omitempty" gorm:"foreignKey:DeckID"`
-	Quizzes     []Quiz      `json:"quizzes,omitempty" gorm:"foreignKey:DeckID"`
+	Title         string      `json:"title" gorm:"not null"`
+	Description   string      `json:"description"`
+	Category      string      `json:"category"`
+	CardCount     int         `json:"card_count"`
+	IsPublic      bool        `json:"is_public"`
+	LearningSteps []int       `json:"learning_steps" gorm:"serializer:json"` // Minutes, e.g. [1, 10], walked before day intervals
+	UserID        uint        `json:"user_id" gorm:"index"`
+	User          User        `json:"-" gorm:"foreignKey:UserID"`
+	FlashCards    []FlashCard `json:"flash_cards,omitempty" gorm:"foreignKey:DeckID"`
+	Quizzes       []Quiz      `json:"quizzes,omitempty" gorm:"foreignKey:DeckID"`
+
+	// How typed quiz answers are compared with the cards. Unset falls back to the user's quiz_match_mode
+	AnswerMatch answermatch.Config `json:"answer_match" gorm:"serializer:json"`
+
+	// Taken down by an admin, the owner can still purge it from the trash but not restore it
+	RemovedByAdmin bool `json:"removed_by_admin" gorm:"default:false"`
+}
+
+// Collaborator roles: editors may add, edit and delete cards, viewers may only study
+const (
+	CollaboratorEditor = "editor"
+	CollaboratorViewer = "viewer"
+)
+
+// DeckCollaborator -> Gives another user access to a private deck, or edit rights on any deck
+type DeckCollaborator struct {
+	gorm.Model
+	DeckID uint   `json:"deck_id" gorm:"uniqueIndex:idx_deck_collaborator;not null"`
+	Deck   Deck   `json:"-" gorm:"foreignKey:DeckID"`
+	UserID uint   `json:"user_id" gorm:"uniqueIndex:idx_deck_collaborator;index;not null"`
+	User   User   `json:"-" gorm:"foreignKey:UserID"`
+	Role   string `json:"role" gorm:"not null;default:'viewer'"`
+}
+
+// Card content types, "text" is the default
+const (
+	ContentText     = "text"
+	ContentMarkdown = "markdown"
+	ContentImage    = "image"
+	ContentCloze    = "cloze"
+	ContentAudio    = "audio"
+)
+
+// ContentTypes -> Every content type a card may have
+var ContentTypes = []string{ContentText, ContentMarkdown, ContentImage, ContentCloze, ContentAudio}
+
+// IsValidContentType -> Whether contentType is one of ContentTypes
+func IsValidContentType(contentType string) bool {
+	return slices.Contains(ContentTypes, contentType)
 }
 
 type FlashCard struct {
 	gorm.Model
-	DeckID          uint           `json:"deck_id" gorm:"index"`
-	Deck            Deck           `json:"-" gorm:"foreignKey:DeckID"`
-	FrontContent    string         `json:"front_content" gorm:"not null"`
-	BackContent     string         `json:"back_content" gorm:"not null"`
-	ContentType     string         `json:"content_type" gorm:"default:'text'"`
-	DifficultyLevel float64        `json:"difficulty_level" gorm:"default:0.5"`
-	CardProgresses  []CardProgress `json:"card_progresses,omitempty" gorm:"foreignKey:CardID"`
-	QuizQuestions   []QuizQuestion `json:"quiz_questions,omitempty" gorm:"foreignKey:CardID"`
+	DeckID            uint           `json:"deck_id" gorm:"index"`
+	Deck              Deck           `json:"-" gorm:"foreignKey:DeckID"`
+	FrontContent      string         `json:"front_content" gorm:"not null"`
+	BackContent       string         `json:"back_content" gorm:"not null"`
+	AcceptableAnswers []string       `json:"acceptable_answers" gorm:"serializer:json"` // Other answers graded as correct in recall questions
+	Explanation       string         `json:"explanation"`                               // Optional, why the answer is what it is, shown after answering
+	ContentType       string         `json:"content_type" gorm:"default:'text'"`
+	DifficultyLevel   float64        `json:"difficulty_level" gorm:"default:0.5"` // 0 (easy) to 1 (hard), drifts with every user's reviews
+	FrontHTML         string         `json:"front_html,omitempty"`                // Sanitized render of markdown cards, kept in sync by BeforeSave
+	BackHTML          string         `json:"back_html,omitempty"`
+	Starred           bool           `json:"starred" gorm:"default:false"` // Flagged by the deck owner as important, studied first
+	CardProgresses    []CardProgress `json:"card_progresses,omitempty" gorm:"foreignKey:CardID"`
+	QuizQuestions     []QuizQuestion `json:"quiz_questions,omitempty" gorm:"foreignKey:CardID"`
 }
 
-// CardProgress -> User's progress on a specific flashcard
+// BeforeSave -> Re-renders the cached HTML of markdown cards whenever they're saved, and clears
+// it for every other content type
+func (card *FlashCard) BeforeSave(tx *gorm.DB) error {
+	if card.ContentType != ContentMarkdown {
+		card.FrontHTML, card.BackHTML = "", ""
+		return nil
+	}
+
+	var err error
+	if card.FrontHTML, err = markdown.Render(card.FrontContent); err != nil {
+		return err
+	}
+	card.BackHTML, err = markdown.Render(card.BackContent)
+	return err
+}
+
+// Tag -> A user's label for grouping cards across decks, names are unique per user regardless of case
+type Tag struct {
+	gorm.Model
+	UserID uint   `json:"user_id" gorm:"uniqueIndex:idx_user_tag;not null"`
+	User   User   `json:"-" gorm:"foreignKey:UserID"`
+	Name   string `json:"name" gorm:"uniqueIndex:idx_user_tag;not null"`
+}
+
+// CardTag -> Attaches one of a user's tags to a card
+type CardTag struct {
+	CardID    uint      `json:"card_id" gorm:"primaryKey"`
+	TagID     uint      `json:"tag_id" gorm:"primaryKey;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CardNote -> A user's private study note on a card. Kept apart from the card so anyone who can
+// study a card, including one in another user's public deck, can annotate it for themselves
+type CardNote struct {
+	gorm.Model
+	UserID uint   `json:"user_id" gorm:"uniqueIndex:idx_card_note_user_card;not null"`
+	CardID uint   `json:"card_id" gorm:"uniqueIndex:idx_card_note_user_card;index;not null"`
+	Note   string `json:"note" gorm:"not null"`
+}
+
+// CardProgress -> User's progress on a specific flashcard.
+// The composite indexes back the study queries: progress lookups by user and card,
+// due cards by user and date, and the per-status stats counts
 type CardProgress struct {
 	gorm.Model
-	UserID         uint      `json:"user_id" gorm:"index;not null"`
+	UserID         uint      `json:"user_id" gorm:"not null;index:idx_progress_user_card,priority:1;index:idx_progress_user_due,priority:1;index:idx_progress_user_status,priority:1"`
 	User           User      `json:"-" gorm:"foreignKey:UserID"`
-	CardID         uint      `json:"card_id" gorm:"index;not null"`
+	CardID         uint      `json:"card_id" gorm:"index;not null;index:idx_progress_user_card,priority:2"`
 	FlashCard      FlashCard `json:"-" gorm:"foreignKey:CardID"`
 	EaseFactor     float64   `json:"ease_factor" gorm:"default:2.5"`
 	Interval       int       `json:"interval" gorm:"default:0"` // days
-	NextReviewDate time.Time `json:"next_review_date"`
+	NextReviewDate time.Time `json:"next_review_date" gorm:"index:idx_progress_user_due,priority:2"`
 	ReviewCount    int       `json:"review_count" gorm:"default:0"`
 	CorrectCount   int       `json:"correct_count" gorm:"default:0"`
 	LastReviewedAt time.Time `json:"last_reviewed_at"`
-	Status         string    `json:"status" gorm:"default:'new'"` // e.g., "new", "learning", "review"
+	Status         string    `json:"status" gorm:"default:'new';index:idx_progress_user_status,priority:2"` // e.g., "new", "learning", "review"
+	Lapses         int       `json:"lapses" gorm:"default:0"`                                               // Times the card was failed while in review
+	IsLeech        bool      `json:"is_leech" gorm:"default:false"`
+	Suspended      bool      `json:"suspended" gorm:"default:false"` // Suspended cards are left out of study sessions
+	LearningStep   int       `json:"learning_step" gorm:"default:0"` // Index into the deck's learning steps while the card hasn't graduated
+}
+
+// DeckDueCount -> Precomputed number of a user's cards in a deck due by the end of their day.
+// Refreshed by the background worker and after every review
+type DeckDueCount struct {
+	ID         uint      `json:"-" gorm:"primarykey"`
+	UserID     uint      `json:"user_id" gorm:"uniqueIndex:idx_due_count_user_deck;not null"`
+	DeckID     uint      `json:"deck_id" gorm:"uniqueIndex:idx_due_count_user_deck;not null"`
+	DueToday   int       `json:"due_today"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// StudyGoal -> A user's goal for one deck: a number of reviews every day, finishing (mastering)
+// the deck by a date, or both
+type StudyGoal struct {
+	gorm.Model
+	UserID       uint       `json:"user_id" gorm:"uniqueIndex:idx_study_goal_user_deck;not null"`
+	DeckID       uint       `json:"deck_id" gorm:"uniqueIndex:idx_study_goal_user_deck;not null"`
+	Deck         Deck       `json:"-" gorm:"foreignKey:DeckID"`
+	DailyReviews int        `json:"daily_reviews" gorm:"default:0"` // 0 means no daily goal
+	TargetDate   *time.Time `json:"target_date"`                    // Nil means no deadline
+}
+
+// Review performance uses the SM-2 0-5 scale: 0 = complete blackout, 1 = incorrect but remembered,
+// 2 = incorrect but close, 3 = correct but difficult, 4 = correct, 5 = correct and easy.
+// Anything at or above PassingPerformance counts as recalled
+const (
+	MinPerformance     = 0
+	MaxPerformance     = 5
+	PassingPerformance = 3
+)
+
+// ReviewLog -> One scheduled review of a card, recorded with the schedule it produced
+type ReviewLog struct {
+	gorm.Model
+	UserID         uint      `json:"user_id" gorm:"not null;index:idx_review_log_user_time,priority:1"`
+	CardID         uint      `json:"card_id" gorm:"index;not null"`
+	FlashCard      FlashCard `json:"-" gorm:"foreignKey:CardID"`
+	Performance    int       `json:"performance"`
+	IntervalBefore int       `json:"interval_before"` // days, the interval the card was recalled after
+	EaseAfter      float64   `json:"ease_after"`
+	IntervalAfter  int       `json:"interval_after"` // days
+	ReviewedAt     time.Time `json:"reviewed_at" gorm:"not null;index:idx_review_log_user_time,priority:2"`
+	SessionID      *uint     `json:"session_id" gorm:"index"` // The study session open at the time, if any
+	TimeSpent      int       `json:"time_spent"`              // Seconds, 0 when the client didn't report it
+}
+
+// StudySession -> A stretch of studying between an explicit start and end. Reviews made while it's
+// open are linked to it, and the totals are filled in when it's closed
+type StudySession struct {
+	gorm.Model
+	UserID          uint       `json:"user_id" gorm:"not null;index"`
+	User            User       `json:"-" gorm:"foreignKey:UserID"`
+	StartedAt       time.Time  `json:"started_at" gorm:"not null"`
+	EndedAt         *time.Time `json:"ended_at"` // Nil while the session is open
+	Reviews         int        `json:"reviews" gorm:"default:0"`
+	CardsReviewed   int        `json:"cards_reviewed" gorm:"default:0"` // Distinct cards, a card failed and retried counts once
+	CorrectCount    int        `json:"correct_count" gorm:"default:0"`
+	DurationSeconds int        `json:"duration_seconds" gorm:"default:0"`
+	Accuracy        float64    `json:"accuracy" gorm:"default:0"` // Percent of reviews passed
 }
 
 type Quiz struct {
 	gorm.Model
-	UserID         uint           `json:"user_id" gorm:"index;not null"`
-	User           User           `json:"-" gorm:"foreignKey:UserID"`
-	DeckID         uint           `json:"deck_id" gorm:"index;not null"`
-	Deck           Deck           `json:"-" gorm:"foreignKey:DeckID"`
-	Title          string         `json:"title" gorm:"not null"`
-	Description    string         `json:"description"`
-	CompletedAt    *time.Time     `json:"completed_at"` // Using pointer for nullable time
-	Score          float64        `json:"score" gorm:"default:0"`
-	TotalQuestions int            `json:"total_questions" gorm:"default:0"`
-	CorrectAnswers int            `json:"correct_answers" gorm:"default:0"`
-	Questions      []QuizQuestion `json:"questions,omitempty" gorm:"foreignKey:QuizID"`
+	UserID             uint           `json:"user_id" gorm:"index;not null"`
+	User               User           `json:"-" gorm:"foreignKey:UserID"`
+	DeckID             uint           `json:"deck_id" gorm:"index;not null"` // The first deck of a multi-deck quiz
+	Deck               Deck           `json:"-" gorm:"foreignKey:DeckID"`
+	DeckIDs            []uint         `json:"deck_ids" gorm:"serializer:json"` // Every deck questions were drawn from
+	Title              string         `json:"title" gorm:"not null"`
+	Description        string         `json:"description"`
+	CompletedAt        *time.Time     `json:"completed_at"` // Using pointer for nullable time
+	Score              float64        `json:"score" gorm:"default:0"`
+	TotalQuestions     int            `json:"total_questions" gorm:"default:0"`
+	CorrectAnswers     int            `json:"correct_answers" gorm:"default:0"`
+	TimeLimitSeconds   int            `json:"time_limit_seconds" gorm:"default:0"`   // 0 means untimed
+	PerQuestionSeconds int            `json:"per_question_seconds" gorm:"default:0"` // 0 means no per-question limit
+	StartedAt          *time.Time     `json:"started_at"`                            // Set when the first answer is submitted
+	TemplateID         *uint          `json:"template_id" gorm:"index"`              // Set on attempts of a shared quiz, points at the original
+	Shuffle            bool           `json:"shuffle" gorm:"default:false"`          // Questions are served in a random order, fixed per attempt
+	ShareToken         *string        `json:"-" gorm:"uniqueIndex;size:64"`          // Lets anyone holding the link view the quiz, nil when not shared
+	Questions          []QuizQuestion `json:"questions,omitempty" gorm:"foreignKey:QuizID"`
+}
+
+// QuizIdempotencyKey -> Remembers which quiz a client's Idempotency-Key created, so a retried
+// or double-submitted create returns that quiz instead of making another
+type QuizIdempotencyKey struct {
+	gorm.Model
+	UserID uint   `json:"user_id" gorm:"uniqueIndex:idx_quiz_idempotency_user_key;not null"`
+	Key    string `json:"key" gorm:"uniqueIndex:idx_quiz_idempotency_user_key;size:255;not null"`
+	QuizID uint   `json:"quiz_id" gorm:"not null"`
+}
+
+// QuizInvite -> Grants another user access to attempt a quiz and view its leaderboard
+type QuizInvite struct {
+	gorm.Model
+	QuizID uint `json:"quiz_id" gorm:"uniqueIndex:idx_quiz_invite;not null"`
+	Quiz   Quiz `json:"-" gorm:"foreignKey:QuizID"`
+	UserID uint `json:"user_id" gorm:"uniqueIndex:idx_quiz_invite;index;not null"`
+	User   User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// Deadline -> When a timed quiz runs out, ok is false for untimed or not yet started quizzes
+func (q *Quiz) Deadline() (deadline time.Time, ok bool) {
+	if q.TimeLimitSeconds <= 0 || q.StartedAt == nil {
+		return time.Time{}, false
+	}
+	return q.StartedAt.Add(time.Duration(q.TimeLimitSeconds) * time.Second), true
 }
 
+// Quiz question types
+const (
+	QuestionRecall    = "recall"
+	QuestionFillBlank = "fill_blank"
+	QuestionTrueFalse = "true_false"
+)
+
 // QuizQuestion -> Represents a question in a quiz
 type QuizQuestion struct {
 	gorm.Model
-	QuizID       uint      `json:"quiz_id" gorm:"index;not null"`
-	Quiz         Quiz      `json:"-" gorm:"foreignKey:QuizID"`
-	CardID       uint      `json:"card_id" gorm:"index;not null"`
-	FlashCard    FlashCard `json:"-" gorm:"foreignKey:CardID"`
-	QuestionType string    `json:"question_type" gorm:"default:'recall'"` // e.g., "multiple_choice", "true_false", "recall"
-	UserAnswer   string    `json:"user_answer"`
-	IsCorrect    bool      `json:"is_correct" gorm:"default:false"`
-	TimeSpent    int       `json:"time_spent"` // in seconds
+	QuizID          uint       `json:"quiz_id" gorm:"index;not null"`
+	Quiz            Quiz       `json:"-" gorm:"foreignKey:QuizID"`
+	CardID          uint       `json:"card_id" gorm:"index;not null"`
+	FlashCard       FlashCard  `json:"-" gorm:"foreignKey:CardID"`
+	DeckID          uint       `json:"deck_id" gorm:"index"`                  // Deck the card was drawn from
+	QuestionType    string     `json:"question_type" gorm:"default:'recall'"` // e.g., "multiple_choice", "true_false", "recall"
+	Prompt          string     `json:"prompt"`                                // What is shown to the user, empty means the card's front
+	ExpectedAnswer  string     `json:"-"`                                     // Answer graded against, empty means the card's back
+	Statement       string     `json:"statement"`                             // True/false only: the back content presented with the prompt
+	StatementIsTrue bool       `json:"-"`                                     // True/false only: whether the statement is the card's real back
+	UserAnswer      string     `json:"user_answer"`
+	IsCorrect       bool       `json:"is_correct" gorm:"default:false"`
+	TimeSpent       int        `json:"time_spent"` // in seconds
+	ServedAt        *time.Time `json:"served_at"`  // When the question was first fetched, the server-side clock for per-question limits
+	AnsweredAt      *time.Time `json:"answered_at"`
+	Position        int        `json:"position" gorm:"default:0"` // Order the question is served in, ties (older quizzes) fall back to ID
 }
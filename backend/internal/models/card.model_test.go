@@ -0,0 +1,105 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Size of the seeded progress table, large enough for a full scan to show up next to an index lookup
+const (
+	benchUsers        = 50
+	benchCardsPerUser = 2000
+)
+
+// seedProgressTable -> An in-memory database holding benchUsers users with progress on
+// benchCardsPerUser cards each, spread over every status and a year of due dates
+func seedProgressTable(b *testing.B) *gorm.DB {
+	b.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		b.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		b.Fatal(err)
+	}
+	// Every connection to :memory: is its own database, so keep to one
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.AutoMigrate(&User{}, &Deck{}, &FlashCard{}, &CardProgress{}); err != nil {
+		b.Fatal(err)
+	}
+
+	statuses := []string{"new", "learning", "review"}
+	now := time.Now()
+	progress := make([]CardProgress, 0, benchCardsPerUser)
+	for user := uint(1); user <= benchUsers; user++ {
+		progress = progress[:0]
+		for card := range benchCardsPerUser {
+			progress = append(progress, CardProgress{
+				UserID:         user,
+				CardID:         uint(card + 1),
+				NextReviewDate: now.AddDate(0, 0, card%365-180),
+				Status:         statuses[card%len(statuses)],
+			})
+		}
+		if err := db.CreateInBatches(progress, 500).Error; err != nil {
+			b.Fatal(err)
+		}
+	}
+	return db
+}
+
+// BenchmarkStudyQueries -> The card progress lookups behind the study endpoints, with the
+// composite indexes and again after dropping them, to show what the indexes save
+func BenchmarkStudyQueries(b *testing.B) {
+	db := seedProgressTable(b)
+	const user = benchUsers / 2
+	dueBy := time.Now()
+
+	queries := []struct {
+		name string
+		run  func() error
+	}{
+		{"user_card", func() error {
+			var progress CardProgress
+			return db.Where("user_id = ? AND card_id = ?", user, benchCardsPerUser/2).First(&progress).Error
+		}},
+		{"user_due", func() error {
+			var count int64
+			return db.Model(&CardProgress{}).Where("user_id = ? AND next_review_date <= ?", user, dueBy).Count(&count).Error
+		}},
+		{"user_status", func() error {
+			var count int64
+			return db.Model(&CardProgress{}).Where("user_id = ? AND status = ?", user, "review").Count(&count).Error
+		}},
+	}
+
+	run := func(label string) {
+		for _, query := range queries {
+			b.Run(fmt.Sprintf("%s/%s", label, query.name), func(b *testing.B) {
+				for b.Loop() {
+					if err := query.run(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+
+	run("indexed")
+
+	// The single-column card_id index stays, it predates the composite ones
+	for _, index := range []string{"idx_progress_user_card", "idx_progress_user_due", "idx_progress_user_status"} {
+		if err := db.Migrator().DropIndex(&CardProgress{}, index); err != nil {
+			b.Fatal(err)
+		}
+	}
+	run("unindexed")
+}
@@ -1,15 +1,25 @@
 package models
 
 import (
-	"golang.org/x/crypto/bcrypt"
+	"FlashQuiz/internal/password"
+	"time"
+
 	"gorm.io/gorm"
 )
 
+// Roles -> Admins can moderate any public deck, everyone else is a regular user
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
 	gorm.Model
 	Username     string `json:"username" gorm:"uniqueIndex;not null"`
 	Email        string `json:"email" gorm:"uniqueIndex;not null"`
 	PasswordHash string `json:"-" gorm:"not null"` // "-" means don't show in JSON responses
+	Role         string `json:"role" gorm:"default:'user';not null"`
+	TokenVersion int    `json:"-" gorm:"default:0;not null"` // Embedded in issued tokens, bumping it revokes every older token
 
 	// Relationships
 	Decks          []Deck         `json:"decks,omitempty" gorm:"foreignKey:UserID"`
@@ -17,19 +27,135 @@ type User struct {
 	Quizzes        []Quiz         `json:"quizzes,omitempty" gorm:"foreignKey:UserID"`
 }
 
-// Hash Password -> Hashes the password using bcrypt and stores it in PasswordHash, straight from documentation
-func (u *User) HashPassword(password string) error {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+// Quiz answer matching modes
+const (
+	MatchExact           = "exact"
+	MatchCaseInsensitive = "case_insensitive"
+)
+
+// What happens to a card once it becomes a leech
+const (
+	LeechActionTag     = "tag"
+	LeechActionSuspend = "suspend"
+)
+
+// Channels a due review reminder can be sent through
+const (
+	ReminderChannelEmail = "email"
+	ReminderChannelLog   = "log" // Only writes to the server log, for development
+)
+
+// ReminderTimeLayout -> Format of UserSettings.ReminderTime
+const ReminderTimeLayout = "15:04"
+
+// UserSettings -> Per-user scheduling and quiz preferences, created lazily with defaults
+type UserSettings struct {
+	gorm.Model
+	UserID              uint   `json:"user_id" gorm:"uniqueIndex;not null"`
+	User                User   `json:"-" gorm:"foreignKey:UserID"`
+	NewCardsPerDay      int    `json:"new_cards_per_day" gorm:"default:20"`
+	MaxReviewsPerDay    int    `json:"max_reviews_per_day" gorm:"default:200"`
+	Timezone            string `json:"timezone" gorm:"default:'UTC'"` // IANA name, e.g. "Asia/Kolkata"
+	QuizMatchMode       string `json:"quiz_match_mode" gorm:"default:'exact'"`
+	LeechThreshold      int    `json:"leech_threshold" gorm:"default:8"` // A card becomes a leech once its lapses exceed this
+	LeechAction         string `json:"leech_action" gorm:"default:'tag'"`
+	ReminderEnabled     bool   `json:"reminder_enabled"`
+	ReminderTime        string `json:"reminder_time" gorm:"default:'09:00'"` // Local time of day, "HH:MM"
+	ReminderChannel     string `json:"reminder_channel" gorm:"default:'email'"`
+	LastRemindedOn      string `json:"-"` // Local date of the last reminder, so each day gets at most one
+	DefaultDeckID       *uint  `json:"default_deck_id"`
+	MasteryIntervalDays int    `json:"mastery_interval_days" gorm:"default:21"` // Shortest review interval a card needs to count as mastered
+}
+
+// Location -> The user's timezone, falling back to UTC for unknown names
+func (s *UserSettings) Location() *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// PasswordResetToken -> Single-use password reset token, only the SHA-256 hash of the token is stored
+type PasswordResetToken struct {
+	gorm.Model
+	UserID    uint       `json:"user_id" gorm:"index;not null"`
+	User      User       `json:"-" gorm:"foreignKey:UserID"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+// Notification events written to the outbox
+const (
+	OutboxEventDeckShared     = "deck_shared"
+	OutboxEventReviewReminder = "review_reminder"
+)
 
+// OutboxMessage -> A notification written in the same transaction as the change that caused it and
+// delivered afterwards by the outbox dispatcher, so a crash in between can't lose it
+type OutboxMessage struct {
+	gorm.Model
+	UserID        uint       `json:"user_id" gorm:"index;not null"` // Recipient
+	User          User       `json:"-" gorm:"foreignKey:UserID"`
+	Event         string     `json:"event" gorm:"not null"`
+	Subject       string     `json:"subject"`
+	Body          string     `json:"body"`
+	Attempts      int        `json:"attempts" gorm:"default:0"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"index;not null"`
+	SentAt        *time.Time `json:"sent_at" gorm:"index"`
+	LastError     string     `json:"last_error"`
+}
+
+// Hash Password -> Hashes the password with the preferred scheme and stores it in PasswordHash
+func (u *User) HashPassword(plain string) error {
+	hash, err := password.Hash(plain)
 	if err != nil {
 		return err
 	}
-	
-	u.PasswordHash = string(bytes)
+	u.PasswordHash = hash
 	return nil
 }
 
-// CheckPassword -> Compares the provided password with the stored hashed password, also from documentation
-func (u *User) CheckPassword(password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password))
+// CheckPassword -> Compares the provided password with the stored hash, using whichever scheme made it
+func (u *User) CheckPassword(plain string) error {
+	return password.Verify(u.PasswordHash, plain)
+}
+
+// PasswordNeedsRehash -> Whether the stored hash predates the preferred scheme or its settings,
+// so it should be replaced the next time the plain password is at hand
+func (u *User) PasswordNeedsRehash() bool {
+	return password.NeedsRehash(u.PasswordHash)
+}
+
+// FindUserByUsername -> Looks a user up by username ignoring case. An exact match wins over
+// accounts that only differ in case, which may predate case-insensitive usernames
+func FindUserByUsername(db *gorm.DB, username string) (User, error) {
+	var users []User
+	if err := db.Where("LOWER(username) = LOWER(?)", username).Find(&users).Error; err != nil {
+		return User{}, err
+	}
+	if len(users) == 0 {
+		return User{}, gorm.ErrRecordNotFound
+	}
+	for _, user := range users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return users[0], nil
+}
+
+// RevokeTokens -> Bumps the user's token version so every token issued so far stops working,
+// returns the new version for minting a replacement
+func RevokeTokens(db *gorm.DB, userID uint) (int, error) {
+	err := db.Model(&User{}).Where("id = ?", userID).
+		UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var version int
+	err = db.Model(&User{}).Select("token_version").Where("id = ?", userID).Scan(&version).Error
+	return version, err
 }
@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"FlashQuiz/internal/mailer"
+	"FlashQuiz/internal/models"
+	"context"
+	"log"
+)
+
+// Notification -> A message for one user, each channel decides how to render it
+type Notification struct {
+	Subject string
+	Body    string
+}
+
+// Channel -> Delivers notifications to users, e.g. by email. Users pick one by its name in their settings
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, user models.User, n Notification) error
+}
+
+// EmailChannel -> Sends notifications to the user's email address through the mailer
+type EmailChannel struct {
+	mailer mailer.Mailer
+}
+
+func NewEmailChannel(mailer mailer.Mailer) *EmailChannel {
+	return &EmailChannel{mailer: mailer}
+}
+
+func (ch *EmailChannel) Name() string {
+	return models.ReminderChannelEmail
+}
+
+func (ch *EmailChannel) Send(ctx context.Context, user models.User, n Notification) error {
+	return ch.mailer.Send(ctx, user.Email, n.Subject, n.Body)
+}
+
+// LogChannel -> Development channel that writes notifications to the server log instead of delivering them
+type LogChannel struct{}
+
+func NewLogChannel() *LogChannel {
+	return &LogChannel{}
+}
+
+func (ch *LogChannel) Name() string {
+	return models.ReminderChannelLog
+}
+
+func (ch *LogChannel) Send(ctx context.Context, user models.User, n Notification) error {
+	log.Printf("Notification for %s (user %d)\nSubject: %s\n\n%s", user.Username, user.ID, n.Subject, n.Body)
+	return nil
+}
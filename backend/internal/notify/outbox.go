@@ -0,0 +1,133 @@
+package notify
+
+import (
+	"FlashQuiz/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Delivery retries back off exponentially from outboxRetryBase up to outboxRetryMax, and a message
+// is given up on after outboxMaxAttempts. It stays in the table with its last error for inspection
+const (
+	outboxRetryBase   = 30 * time.Second
+	outboxRetryMax    = time.Hour
+	outboxMaxAttempts = 10
+	outboxBatchSize   = 100
+)
+
+// Enqueue -> Writes a notification for the user to the outbox. Call it with the transaction that
+// makes the change the notification is about, so both are committed or neither is
+func Enqueue(tx *gorm.DB, userID uint, event string, n Notification) error {
+	return tx.Create(&models.OutboxMessage{
+		UserID:        userID,
+		Event:         event,
+		Subject:       n.Subject,
+		Body:          n.Body,
+		NextAttemptAt: time.Now(),
+	}).Error
+}
+
+// OutboxDispatcher -> Delivers outbox messages through each recipient's chosen channel and marks
+// them sent. A message is only marked after its channel accepted it, so delivery is at least once:
+// a crash in between sends it again
+type OutboxDispatcher struct {
+	db       *gorm.DB
+	channels map[string]Channel
+	interval time.Duration
+}
+
+// NewOutboxDispatcher -> Creates a dispatcher that looks for messages to deliver every interval
+func NewOutboxDispatcher(db *gorm.DB, interval time.Duration, channels ...Channel) *OutboxDispatcher {
+	byName := make(map[string]Channel, len(channels))
+	for _, ch := range channels {
+		byName[ch.Name()] = ch
+	}
+	return &OutboxDispatcher{db: db, channels: byName, interval: interval}
+}
+
+// Run -> Dispatches immediately and then on every tick until ctx is cancelled
+func (d *OutboxDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatch(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			log.Println("Outbox dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch -> One pass over the messages that are due, oldest first, stopping early on shutdown
+func (d *OutboxDispatcher) dispatch(ctx context.Context, now time.Time) {
+	var messages []models.OutboxMessage
+	if err := d.db.Preload("User").
+		Where("sent_at IS NULL AND attempts < ? AND next_attempt_at <= ?", outboxMaxAttempts, now).
+		Order("id ASC").
+		Limit(outboxBatchSize).
+		Find(&messages).Error; err != nil {
+		log.Printf("Outbox dispatcher failed to list messages: %v", err)
+		return
+	}
+
+	for _, message := range messages {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := d.deliver(ctx, message, now); err != nil {
+			log.Printf("Outbox dispatcher failed to record delivery of message %d: %v", message.ID, err)
+		}
+	}
+}
+
+// deliver -> Sends one message and records the outcome, scheduling a retry when sending failed
+func (d *OutboxDispatcher) deliver(ctx context.Context, message models.OutboxMessage, now time.Time) error {
+	sendErr := d.send(ctx, message)
+	if sendErr == nil {
+		return d.db.Model(&message).Updates(map[string]any{"sent_at": now, "attempts": message.Attempts + 1}).Error
+	}
+
+	attempts := message.Attempts + 1
+	if attempts >= outboxMaxAttempts {
+		log.Printf("Outbox giving up on message %d after %d attempts: %v", message.ID, attempts, sendErr)
+	}
+	backoff := min(outboxRetryBase<<(attempts-1), outboxRetryMax)
+	return d.db.Model(&message).Updates(map[string]any{
+		"attempts":        attempts,
+		"last_error":      sendErr.Error(),
+		"next_attempt_at": now.Add(backoff),
+	}).Error
+}
+
+// send -> Delivers the message through the channel the recipient picked in their settings, email
+// for users who never changed them
+func (d *OutboxDispatcher) send(ctx context.Context, message models.OutboxMessage) error {
+	if message.User.ID == 0 {
+		return errors.New("recipient no longer exists")
+	}
+
+	name := models.ReminderChannelEmail
+	var settings models.UserSettings
+	err := d.db.Where("user_id = ?", message.UserID).First(&settings).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err == nil && settings.ReminderChannel != "" {
+		name = settings.ReminderChannel
+	}
+
+	channel, ok := d.channels[name]
+	if !ok {
+		return fmt.Errorf("unknown notification channel %q", name)
+	}
+	return channel.Send(ctx, message.User, Notification{Subject: message.Subject, Body: message.Body})
+}
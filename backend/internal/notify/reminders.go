@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"FlashQuiz/internal/models"
+	"FlashQuiz/internal/stats"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReminderWorker -> Periodically queues each opted-in user one daily summary of their due reviews,
+// once their reminder time has passed in their own timezone. The outbox dispatcher delivers it
+type ReminderWorker struct {
+	db       *gorm.DB
+	interval time.Duration
+}
+
+// NewReminderWorker -> Creates a worker that checks for reminders to send every interval
+func NewReminderWorker(db *gorm.DB, interval time.Duration) *ReminderWorker {
+	return &ReminderWorker{db: db, interval: interval}
+}
+
+// Run -> Checks immediately and then on every tick until ctx is cancelled
+func (w *ReminderWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.check(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			log.Println("Reminder worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check -> One pass over every user with reminders turned on, stopping early on shutdown
+func (w *ReminderWorker) check(ctx context.Context, now time.Time) {
+	var settings []models.UserSettings
+	if err := w.db.Preload("User").Where("reminder_enabled = ?", true).Find(&settings).Error; err != nil {
+		log.Printf("Reminder worker failed to list users: %v", err)
+		return
+	}
+
+	for _, s := range settings {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := w.remind(ctx, s, now); err != nil {
+			log.Printf("Reminder worker failed for user %d: %v", s.UserID, err)
+		}
+	}
+}
+
+// remind -> Queues the user's reminder if it's due and they have reviews waiting. Either way the day
+// is marked done once the reminder time has passed, in the same transaction, so a reminder is
+// neither lost nor queued twice
+func (w *ReminderWorker) remind(ctx context.Context, s models.UserSettings, now time.Time) error {
+	local := now.In(s.Location())
+	today := local.Format("2006-01-02")
+	if s.LastRemindedOn == today {
+		return nil
+	}
+
+	at, err := time.Parse(models.ReminderTimeLayout, s.ReminderTime)
+	if err != nil {
+		return fmt.Errorf("invalid reminder time %q: %w", s.ReminderTime, err)
+	}
+	if local.Hour()*60+local.Minute() < at.Hour()*60+at.Minute() {
+		return nil
+	}
+
+	// Refresh first so the summary counts cards that became due since the last due count pass
+	if err := stats.RecomputeDueCounts(w.db, s.UserID); err != nil {
+		return err
+	}
+
+	type deckDue struct {
+		Title    string
+		DueToday int
+	}
+	var decks []deckDue
+	if err := w.db.Model(&models.DeckDueCount{}).
+		Select("decks.title AS title, deck_due_counts.due_today AS due_today").
+		Joins("JOIN decks ON decks.id = deck_due_counts.deck_id AND decks.deleted_at IS NULL").
+		Where("deck_due_counts.user_id = ? AND deck_due_counts.due_today > 0", s.UserID).
+		Order("deck_due_counts.due_today DESC, decks.title ASC").
+		Scan(&decks).Error; err != nil {
+		return err
+	}
+
+	return w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(decks) > 0 {
+			total := 0
+			lines := make([]string, 0, len(decks))
+			for _, deck := range decks {
+				total += deck.DueToday
+				lines = append(lines, fmt.Sprintf("- %s: %d", deck.Title, deck.DueToday))
+			}
+
+			n := Notification{
+				Subject: fmt.Sprintf("You have %d cards to review today", total),
+				Body: fmt.Sprintf("Hi %s,\n\n%d cards are due for review today:\n\n%s\n\nA few minutes now keeps them from piling up.",
+					s.User.Username, total, strings.Join(lines, "\n")),
+			}
+			if err := Enqueue(tx, s.UserID, models.OutboxEventReviewReminder, n); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.UserSettings{}).Where("id = ?", s.ID).Update("last_reminded_on", today).Error
+	})
+}
@@ -0,0 +1,200 @@
+package password
+
+import (
+	"FlashQuiz/internal/config"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Hasher -> One password hashing scheme. Stored hashes are prefixed with the scheme's name, so
+// accounts hashed under an older scheme keep working while they're moved to the preferred one
+type Hasher interface {
+	Name() string
+	Hash(password string) (string, error)
+	Verify(encoded, password string) error
+	// NeedsRehash -> Whether a hash of this scheme was made with weaker settings than the current ones
+	NeedsRehash(encoded string) bool
+}
+
+// Errors returned by Verify
+var (
+	ErrMismatch       = errors.New("password doesn't match")
+	ErrUnknownHasher  = errors.New("password hash uses an unknown scheme")
+	ErrMalformedHash  = errors.New("password hash is malformed")
+	errUnknownSetting = errors.New("unknown PASSWORD_HASHER")
+)
+
+// prefixSeparator -> Sits between the scheme name and the scheme's own encoding of the hash
+const prefixSeparator = ":"
+
+// BcryptHasher -> bcrypt at the given cost, the default scheme
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h BcryptHasher) Name() string { return "bcrypt" }
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+func (h BcryptHasher) Verify(encoded, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrMismatch
+	}
+	return err
+}
+
+func (h BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost < h.Cost
+}
+
+// Argon2idHasher -> argon2id, encoded the way the reference implementation does:
+// $argon2id$v=19$m=<KiB>,t=<passes>,p=<threads>$<salt>$<key>
+type Argon2idHasher struct {
+	Memory  uint32 // KiB
+	Time    uint32
+	Threads uint8
+	KeyLen  uint32
+	SaltLen uint32
+}
+
+func (h Argon2idHasher) Name() string { return "argon2id" }
+
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Threads, h.KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.Memory, h.Time, h.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h Argon2idHasher) Verify(encoded, password string) error {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return err
+	}
+	given := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(given, key) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+func (h Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params.Memory < h.Memory || params.Time < h.Time || params.Threads < h.Threads ||
+		uint32(len(salt)) < h.SaltLen || uint32(len(key)) < h.KeyLen
+}
+
+// decodeArgon2id -> Splits an encoded argon2id hash into its parameters, salt and key
+func decodeArgon2id(encoded string) (Argon2idHasher, []byte, []byte, error) {
+	var params Argon2idHasher
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrMalformedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrMalformedHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return params, nil, nil, ErrMalformedHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrMalformedHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrMalformedHash
+	}
+	return params, salt, key, nil
+}
+
+// hashers -> Every scheme a stored hash may use, with the settings new hashes are made with
+var hashers = map[string]Hasher{
+	"bcrypt": BcryptHasher{Cost: bcrypt.DefaultCost},
+	// RFC 9106's second recommended option, for memory-constrained servers
+	"argon2id": Argon2idHasher{Memory: 64 * 1024, Time: 3, Threads: 4, KeyLen: 32, SaltLen: 16},
+}
+
+// Preferred -> The scheme new hashes are made with, picked by PASSWORD_HASHER ("bcrypt" or "argon2id")
+func Preferred() (Hasher, error) {
+	name := config.String("PASSWORD_HASHER", "bcrypt")
+	hasher, ok := hashers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", errUnknownSetting, name)
+	}
+	return hasher, nil
+}
+
+// Hash -> Hashes password with the preferred scheme, prefixed with the scheme's name
+func Hash(password string) (string, error) {
+	hasher, err := Preferred()
+	if err != nil {
+		return "", err
+	}
+	encoded, err := hasher.Hash(password)
+	if err != nil {
+		return "", err
+	}
+	return hasher.Name() + prefixSeparator + encoded, nil
+}
+
+// split -> The scheme a stored hash was made with and the scheme's encoding of it. Hashes from
+// before schemes were named carry no prefix and are always bcrypt
+func split(stored string) (Hasher, string, error) {
+	name, encoded, found := strings.Cut(stored, prefixSeparator)
+	if !found {
+		return hashers["bcrypt"], stored, nil
+	}
+	hasher, ok := hashers[name]
+	if !ok {
+		return nil, "", ErrUnknownHasher
+	}
+	return hasher, encoded, nil
+}
+
+// Verify -> Checks password against a stored hash with whichever scheme made it, nil when it matches
+func Verify(stored, password string) error {
+	hasher, encoded, err := split(stored)
+	if err != nil {
+		return err
+	}
+	return hasher.Verify(encoded, password)
+}
+
+// NeedsRehash -> Whether a stored hash should be replaced on the next successful login: it has no
+// scheme prefix, uses a scheme other than the preferred one, or weaker settings than the current
+func NeedsRehash(stored string) bool {
+	preferred, err := Preferred()
+	if err != nil {
+		return false
+	}
+	hasher, encoded, err := split(stored)
+	if err != nil || !strings.Contains(stored, prefixSeparator) {
+		return true
+	}
+	return hasher.Name() != preferred.Name() || hasher.NeedsRehash(encoded)
+}
@@ -0,0 +1,95 @@
+package password
+
+import (
+	"FlashQuiz/internal/config"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// MaxBytes -> Upper bound on password length in bytes. bcrypt refuses to hash anything longer, so
+// it applies whichever hasher is configured, or switching back to bcrypt would lock users out
+const MaxBytes = 72
+
+// Policy -> Rules a new password has to satisfy. Every place that sets a password (register,
+// reset, change) goes through Validate so they can't drift apart
+type Policy struct {
+	MinLength  int // Characters, not bytes
+	MinClasses int // How many of lowercase, uppercase, digits and symbols must appear
+}
+
+//go:embed common.txt
+var commonList string
+
+// common -> Lowercased set of well-known passwords, matched case-insensitively
+var common = func() map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, line := range strings.Split(commonList, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			set[strings.ToLower(line)] = struct{}{}
+		}
+	}
+	return set
+}()
+
+// Errors returned by Validate, worded to be shown to the user as they are
+var (
+	ErrTooLong   = fmt.Errorf("password must be at most %d bytes, fewer characters when using accented letters or other non-ASCII ones", MaxBytes)
+	ErrTooCommon = errors.New("password is too common, choose something less predictable")
+)
+
+// FromEnv -> The policy configured by PASSWORD_MIN_LENGTH and PASSWORD_MIN_CLASSES
+func FromEnv() Policy {
+	policy := Policy{
+		MinLength:  config.Int("PASSWORD_MIN_LENGTH", 8),
+		MinClasses: config.Int("PASSWORD_MIN_CLASSES", 2),
+	}
+	policy.MinLength = min(max(policy.MinLength, 1), MaxBytes)
+	policy.MinClasses = min(max(policy.MinClasses, 0), 4)
+	return policy
+}
+
+// Validate -> Checks password against the policy configured in the environment
+func Validate(password string) error {
+	return FromEnv().Validate(password)
+}
+
+// Validate -> Returns the first rule password breaks, nil when it's acceptable
+func (p Policy) Validate(password string) error {
+	if len([]rune(password)) < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters", p.MinLength)
+	}
+	if len(password) > MaxBytes {
+		return ErrTooLong
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			classes++
+		}
+	}
+	if classes < p.MinClasses {
+		return fmt.Errorf("password must mix at least %d of: lowercase letters, uppercase letters, digits, symbols", p.MinClasses)
+	}
+
+	if _, ok := common[strings.ToLower(password)]; ok {
+		return ErrTooCommon
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	policy := Policy{MinLength: 8, MinClasses: 2}
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  error // nil for acceptable passwords, only compared for the sentinel errors
+		valid    bool
+	}{
+		{"acceptable", "correct7horse", nil, true},
+		{"too short", "ab1", nil, false},
+		{"too few classes", "onlylowercase", nil, false},
+		{"too common", "Password1", ErrTooCommon, false},
+		{"exactly the byte limit", "a1" + strings.Repeat("x", MaxBytes-2), nil, true},
+		{"one byte over the limit", "a1" + strings.Repeat("x", MaxBytes-1), ErrTooLong, false},
+		{"few characters but too many bytes", "1" + strings.Repeat("é", 36), ErrTooLong, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password)
+			if tt.valid != (err == nil) {
+				t.Fatalf("Validate() error = %v, want valid = %v", err, tt.valid)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Anything Validate lets through must hash, the longest passwords are where bcrypt gives up
+func TestLongestValidPasswordHashes(t *testing.T) {
+	password := "a1" + strings.Repeat("é", (MaxBytes-2)/2)
+	if err := (Policy{MinLength: 8, MinClasses: 2}).Validate(password); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if _, err := (BcryptHasher{Cost: bcrypt.MinCost}).Hash(password); err != nil {
+		t.Errorf("Hash() of a %d byte password error = %v", len(password), err)
+	}
+}
@@ -0,0 +1,126 @@
+package stats
+
+import (
+	"FlashQuiz/internal/models"
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// endOfDay -> The last instant of now's day in loc, in UTC so it compares correctly with stored times
+func endOfDay(now time.Time, loc *time.Location) time.Time {
+	local := now.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 23, 59, 59, 999999999, loc).UTC()
+}
+
+// RecomputeDueCounts -> Rebuilds the cached per-deck due counts for one user, "today" being the
+// user's local day. Every deck the user has progress in gets a row, even when nothing is due
+func RecomputeDueCounts(db *gorm.DB, userID uint) error {
+	var settings models.UserSettings
+	if err := db.Where("user_id = ?", userID).Limit(1).Find(&settings).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	type deckCount struct {
+		DeckID   uint
+		DueToday int
+	}
+	var counts []deckCount
+	if err := db.Model(&models.CardProgress{}).
+		Select("flash_cards.deck_id AS deck_id, SUM(CASE WHEN card_progresses.next_review_date <= ? THEN 1 ELSE 0 END) AS due_today", endOfDay(now, settings.Location())).
+		Joins("JOIN flash_cards ON flash_cards.id = card_progresses.card_id AND flash_cards.deleted_at IS NULL").
+		Where("card_progresses.user_id = ? AND card_progresses.suspended = ?", userID, false).
+		Group("flash_cards.deck_id").
+		Scan(&counts).Error; err != nil {
+		return err
+	}
+
+	tx := db.Begin()
+
+	if err := tx.Where("user_id = ?", userID).Delete(&models.DeckDueCount{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, count := range counts {
+		row := models.DeckDueCount{UserID: userID, DeckID: count.DeckID, DueToday: count.DueToday, ComputedAt: now}
+		if err := tx.Create(&row).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// DueToday -> The user's precomputed due count, for one deck or across all decks when deckID is 0.
+// Users who were never computed (e.g. right after the worker started) are computed on the spot
+func DueToday(db *gorm.DB, userID, deckID uint) (int64, error) {
+	var computed int64
+	if err := db.Model(&models.DeckDueCount{}).Where("user_id = ?", userID).Count(&computed).Error; err != nil {
+		return 0, err
+	}
+	if computed == 0 {
+		if err := RecomputeDueCounts(db, userID); err != nil {
+			return 0, err
+		}
+	}
+
+	query := db.Model(&models.DeckDueCount{}).Where("user_id = ?", userID)
+	if deckID > 0 {
+		query = query.Where("deck_id = ?", deckID)
+	}
+
+	var due int64
+	err := query.Select("COALESCE(SUM(due_today), 0)").Scan(&due).Error
+	return due, err
+}
+
+// DueCountWorker -> Periodically recomputes the due counts of every user with study progress
+type DueCountWorker struct {
+	db       *gorm.DB
+	interval time.Duration
+}
+
+// NewDueCountWorker -> Creates a worker that refreshes all due counts every interval
+func NewDueCountWorker(db *gorm.DB, interval time.Duration) *DueCountWorker {
+	return &DueCountWorker{db: db, interval: interval}
+}
+
+// Run -> Refreshes immediately and then on every tick until ctx is cancelled
+func (w *DueCountWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Println("Due count worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh -> One pass over every user with progress, stopping early on shutdown
+func (w *DueCountWorker) refresh(ctx context.Context) {
+	var userIDs []uint
+	if err := w.db.Model(&models.CardProgress{}).Distinct("user_id").Pluck("user_id", &userIDs).Error; err != nil {
+		log.Printf("Due count worker failed to list users: %v", err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := RecomputeDueCounts(w.db, userID); err != nil {
+			log.Printf("Due count worker failed for user %d: %v", userID, err)
+		}
+	}
+}
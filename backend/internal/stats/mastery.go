@@ -0,0 +1,69 @@
+package stats
+
+import (
+	"FlashQuiz/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// A card counts as mastered once it has graduated to review with an interval of at least the
+// user's mastery interval (MasteryIntervalDays unless they changed it), its ease hasn't sunk below
+// MasteryMinEase (the card isn't one the user keeps struggling with), and its last
+// MasteryRecentReviews reviews were all recalled
+const (
+	MasteryMinEase       = 2.3
+	MasteryRecentReviews = 2
+	MasteryIntervalDays  = 21
+)
+
+// recentMiss -> A miss among the card's most recent reviews
+const recentMiss = `EXISTS (SELECT 1 FROM review_logs WHERE review_logs.performance < ? AND review_logs.id IN (
+	SELECT recent.id FROM review_logs AS recent
+	WHERE recent.user_id = card_progresses.user_id AND recent.card_id = card_progresses.card_id AND recent.deleted_at IS NULL
+	ORDER BY recent.reviewed_at DESC, recent.id DESC LIMIT ?))`
+
+// Mastered -> Scope narrowing a card_progresses query down to mastered cards, with minIntervalDays
+// as the shortest interval that counts
+func Mastered(minIntervalDays int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("card_progresses.status = ? AND card_progresses.interval >= ? AND card_progresses.ease_factor >= ?",
+			"review", minIntervalDays, MasteryMinEase).
+			Where("NOT "+recentMiss, models.PassingPerformance, MasteryRecentReviews)
+	}
+}
+
+// Mastery -> How many of a deck's cards the user has mastered
+type Mastery struct {
+	MasteredCards int64   `json:"mastered_cards"`
+	StudiedCards  int64   `json:"studied_cards"` // Cards with any progress, mastered or not
+	TotalCards    int64   `json:"total_cards"`
+	Percent       float64 `json:"mastery_percent"` // MasteredCards out of TotalCards, 0 for an empty deck
+}
+
+// DeckMastery -> The user's mastery of a deck: mastered cards as a percentage of all the deck's
+// cards, so cards never studied count against it and a deck with no progress is at 0%.
+// Cards marked as known skip the review history and count once they meet the interval and ease requirements
+func DeckMastery(db *gorm.DB, userID, deckID uint, minIntervalDays int) (Mastery, error) {
+	var mastery Mastery
+	if err := db.Model(&models.FlashCard{}).Where("deck_id = ?", deckID).Count(&mastery.TotalCards).Error; err != nil {
+		return mastery, err
+	}
+
+	progress := db.Model(&models.CardProgress{}).
+		Joins("JOIN flash_cards ON flash_cards.id = card_progresses.card_id AND flash_cards.deleted_at IS NULL").
+		Where("card_progresses.user_id = ? AND flash_cards.deck_id = ?", userID, deckID).
+		Session(&gorm.Session{})
+
+	if err := progress.Count(&mastery.StudiedCards).Error; err != nil {
+		return mastery, err
+	}
+
+	if err := progress.Scopes(Mastered(minIntervalDays)).Count(&mastery.MasteredCards).Error; err != nil {
+		return mastery, err
+	}
+
+	if mastery.TotalCards > 0 {
+		mastery.Percent = float64(mastery.MasteredCards) / float64(mastery.TotalCards) * 100
+	}
+	return mastery, nil
+}
@@ -0,0 +1,55 @@
+package stats
+
+import (
+	"FlashQuiz/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MatureInterval -> Days a card's interval must have reached for its reviews to count towards retention
+const MatureInterval = 21
+
+// Retention -> Reviews of mature cards and how many of them were recalled
+type Retention struct {
+	DeckID    uint    `json:"deck_id,omitempty"`
+	DeckTitle string  `json:"deck_title,omitempty"`
+	Reviews   int64   `json:"mature_reviews"`
+	Passed    int64   `json:"passed"`
+	Rate      float64 `json:"rate"` // Percentage, 0 when there were no mature reviews
+}
+
+func (r *Retention) computeRate() {
+	if r.Reviews > 0 {
+		r.Rate = float64(r.Passed) / float64(r.Reviews) * 100
+	}
+}
+
+// TrueRetention -> Share of mature cards the user recalled when they came due, per deck and overall.
+// Unlike lifetime accuracy, learning reviews and re-asks of failed cards aren't counted. Only
+// deckID's deck is included when it's non-zero
+func TrueRetention(db *gorm.DB, userID, deckID uint) (Retention, []Retention, error) {
+	query := db.Model(&models.ReviewLog{}).
+		Select("flash_cards.deck_id AS deck_id, decks.title AS deck_title, COUNT(*) AS reviews, "+
+			"SUM(CASE WHEN review_logs.performance >= ? THEN 1 ELSE 0 END) AS passed", models.PassingPerformance).
+		Joins("JOIN flash_cards ON flash_cards.id = review_logs.card_id AND flash_cards.deleted_at IS NULL").
+		Joins("JOIN decks ON decks.id = flash_cards.deck_id").
+		Where("review_logs.user_id = ? AND review_logs.interval_before >= ?", userID, MatureInterval)
+	if deckID > 0 {
+		query = query.Where("flash_cards.deck_id = ?", deckID)
+	}
+
+	var overall Retention
+	decks := make([]Retention, 0)
+	if err := query.Group("flash_cards.deck_id, decks.title").Order("flash_cards.deck_id").Scan(&decks).Error; err != nil {
+		return overall, nil, err
+	}
+
+	for i := range decks {
+		decks[i].computeRate()
+		overall.Reviews += decks[i].Reviews
+		overall.Passed += decks[i].Passed
+	}
+	overall.computeRate()
+
+	return overall, decks, nil
+}